@@ -0,0 +1,216 @@
+package feedhandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/etag"
+	"blog/utils/feed"
+	"blog/utils/httputil"
+	"blog/utils/sitelink"
+	"blog/utils/validation"
+)
+
+// summaryLength is the length of the summaries of the posts, in characters.
+const summaryLength = 200
+
+type feedHandler struct {
+	feedUsecase interfaces.FeedUsecase
+	links       sitelink.Links
+	siteTitle   string
+}
+
+// format is a feed format served by the handler.
+type format struct {
+	ext         string
+	contentType string
+	encode      func(*feed.Feed) ([]byte, error)
+}
+
+var (
+	rss      = format{ext: "rss", contentType: feed.RSSContentType, encode: feed.RSS}
+	atom     = format{ext: "atom", contentType: feed.AtomContentType, encode: feed.Atom}
+	jsonFeed = format{ext: "json", contentType: feed.JSONFeedContentType, encode: feed.JSONFeed}
+)
+
+// NewFeedHandler serves the RSS, Atom and JSON feeds of the blog, of each author and of each tag.
+// siteURL is the absolute URL of the site the links of the feeds point to.
+func NewFeedHandler(g *gin.RouterGroup, feedUsecase interfaces.FeedUsecase, siteURL, siteTitle string) {
+	handler := &feedHandler{
+		feedUsecase: feedUsecase,
+		links:       sitelink.New(siteURL),
+		siteTitle:   siteTitle,
+	}
+	for _, f := range []format{rss, atom, jsonFeed} {
+		f := f
+		g.GET("feed."+f.ext, func(ctx *gin.Context) { handler.SiteFeedHandler(ctx, f) })
+		g.GET("user/:user_id/feed."+f.ext, func(ctx *gin.Context) { handler.AuthorFeedHandler(ctx, f) })
+		g.GET("tag/:name/feed."+f.ext, func(ctx *gin.Context) { handler.TagFeedHandler(ctx, f) })
+	}
+}
+
+// SiteFeedHandler serves the latest posts of the blog.
+func (h *feedHandler) SiteFeedHandler(ctx *gin.Context, f format) {
+	entries, err := h.feedUsecase.GetFeed(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	h.write(ctx, f, entries, &feed.Feed{
+		ID:          h.links.Home(),
+		Title:       h.siteTitle,
+		Link:        h.links.Home(),
+		Description: "Latest posts of " + h.siteTitle,
+	})
+}
+
+// AuthorFeedHandler serves the latest posts of an author.
+func (h *feedHandler) AuthorFeedHandler(ctx *gin.Context, f format) {
+	var req dto.GetAuthorFeedRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	entries, err := h.feedUsecase.GetAuthorFeed(ctx, req.AuthorID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	link := h.links.Author(req.AuthorID)
+	h.write(ctx, f, entries, &feed.Feed{
+		ID:          link,
+		Title:       entries.Author.Name + " - " + h.siteTitle,
+		Link:        link,
+		Description: "Latest posts of " + entries.Author.Name,
+		Author:      entries.Author.Name,
+	})
+}
+
+// TagFeedHandler serves the latest posts carrying a tag.
+func (h *feedHandler) TagFeedHandler(ctx *gin.Context, f format) {
+	var req dto.GetTagFeedRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	entries, err := h.feedUsecase.GetTagFeed(ctx, req.Name)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	link := h.links.Tag(entries.Tag.Name)
+	h.write(ctx, f, entries, &feed.Feed{
+		ID:          link,
+		Title:       entries.Tag.Name + " - " + h.siteTitle,
+		Link:        link,
+		Description: "Latest posts tagged " + entries.Tag.Name,
+	})
+}
+
+// write completes out with the entries and answers the request with it in format f, or with
+// a 304 when the client has the current version.
+func (h *feedHandler) write(ctx *gin.Context, f format, entries *dto.Feed, out *feed.Feed) {
+	tag, lastModified := feedValidators(f, entries)
+	if httputil.NotModified(ctx.Writer, ctx.Request, tag, lastModified) {
+		return
+	}
+
+	out.Self = h.links.URL(ctx.Request.URL.Path)
+	if out.Author == "" {
+		out.Author = h.siteTitle
+	}
+	out.Updated = lastModified
+	for i := range entries.Posts {
+		item, err := h.item(&entries.Posts[i])
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		out.Items = append(out.Items, item)
+	}
+
+	data, err := f.encode(out)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, f.contentType, data)
+}
+
+// item converts a post. Its id is a tag URI minted from the creation date of the post, so it
+// does not change with the title, the content or the URLs of the site.
+func (h *feedHandler) item(post *dto.Post) (feed.Item, error) {
+	id, err := feed.TagURI(h.links.Home(), post.CreatedAt, "post/"+strconv.FormatInt(post.ID, 10))
+	if err != nil {
+		return feed.Item{}, err
+	}
+	item := feed.Item{
+		ID:        id,
+		Title:     post.Title,
+		Link:      h.links.Post(post.ID),
+		Author:    post.Author.Name,
+		Summary:   feed.Summarize(post.Content, summaryLength),
+		Content:   post.Content,
+		Published: post.CreatedAt,
+		Updated:   post.UpdatedAt,
+	}
+	for _, t := range post.Tags {
+		item.Categories = append(item.Categories, t.Name)
+	}
+	return item, nil
+}
+
+// feedValidators derives the ETag and Last-Modified of a feed from its posts, their authors
+// and tags, and the author or tag of the feed.
+func feedValidators(f format, entries *dto.Feed) (string, time.Time) {
+	parts := []interface{}{"feed", f.ext}
+	var lastModified time.Time
+	if entries.Author != nil {
+		parts = append(parts, entries.Author.ETag())
+		lastModified = latest(lastModified, entries.Author.UpdatedAt)
+	}
+	if entries.Tag != nil {
+		parts = append(parts, entries.Tag.ETag())
+		lastModified = latest(lastModified, entries.Tag.UpdatedAt)
+	}
+	for i := range entries.Posts {
+		post := &entries.Posts[i]
+		parts = append(parts, post.ETag(), post.Author.ETag())
+		lastModified = latest(lastModified, post.UpdatedAt, post.Author.UpdatedAt)
+		for j := range post.Tags {
+			parts = append(parts, post.Tags[j].ETag())
+			lastModified = latest(lastModified, post.Tags[j].UpdatedAt)
+		}
+	}
+	return etag.Strong(parts...), lastModified
+}
+
+func latest(t time.Time, others ...time.Time) time.Time {
+	for _, o := range others {
+		if o.After(t) {
+			t = o
+		}
+	}
+	return t
+}
+
+// writeError answers with the status of a usecase error: 404 for an unknown author or tag,
+// 500 otherwise.
+func writeError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if dto.KindOf(err) == dto.KindNotFound {
+		status = http.StatusNotFound
+	}
+	httputil.WriteErrorResponse(ctx.Writer, status, []httputil.StandardError{{
+		Code:   strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}})
+}
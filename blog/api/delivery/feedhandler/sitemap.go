@@ -0,0 +1,200 @@
+package feedhandler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/etag"
+	"blog/utils/httputil"
+	"blog/utils/sitelink"
+	"blog/utils/sitemap"
+)
+
+// sitemapFile matches the names of the pages of the sitemap, such as posts-1.xml.
+var sitemapFile = regexp.MustCompile(`^(` + dto.SitemapPosts + `|` + dto.SitemapTags + `|` + dto.SitemapUsers + `)-([1-9][0-9]*)\.xml$`)
+
+type sitemapHandler struct {
+	feedUsecase interfaces.FeedUsecase
+	links       sitelink.Links
+
+	// mu guards the rendered pages, which are kept until their resources change
+	mu    sync.Mutex
+	pages map[string]*sitemapPage
+	// whole is the single sitemap listing every page while they fit in one
+	whole *sitemapPage
+}
+
+// sitemapPage is a rendered page of the sitemap and the version of its resources it lists.
+type sitemapPage struct {
+	version dto.SitemapPage
+	etag    string
+	urls    []sitemap.URL
+	// lastMod is the last update of the listed resources. modified, the Last-Modified of the
+	// page, is when it was rendered again instead, as removing a resource changes the page
+	// without updating the others.
+	lastMod  time.Time
+	modified time.Time
+	doc      []byte
+}
+
+// NewSitemapHandler serves the sitemap of the posts, tags and authors at sitemap.xml. Past
+// sitemap.MaxURLs URLs it becomes an index of the pages under sitemaps/. Pages are rendered
+// again only when one of their resources changed.
+func NewSitemapHandler(g *gin.RouterGroup, feedUsecase interfaces.FeedUsecase, siteURL string) {
+	handler := &sitemapHandler{
+		feedUsecase: feedUsecase,
+		links:       sitelink.New(siteURL),
+		pages:       map[string]*sitemapPage{},
+	}
+	g.GET("sitemap.xml", handler.SitemapHandler)
+	g.GET("sitemaps/:file", handler.SitemapPageHandler)
+}
+
+// SitemapHandler serves the whole sitemap, or its index when it does not fit in one.
+func (h *sitemapHandler) SitemapHandler(ctx *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	pages, err := h.refresh(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	total := 0
+	parts := []interface{}{"sitemap"}
+	for _, p := range pages {
+		total += len(p.urls)
+		parts = append(parts, p.etag)
+	}
+	tag := etag.Strong(parts...)
+
+	if h.whole == nil || h.whole.etag != tag {
+		whole := &sitemapPage{etag: tag}
+		if total <= sitemap.MaxURLs {
+			for _, p := range pages {
+				whole.urls = append(whole.urls, p.urls...)
+				whole.lastMod = latest(whole.lastMod, p.lastMod)
+			}
+			whole.doc, err = sitemap.Encode(whole.urls)
+			// the pages keep the urls
+			whole.urls = nil
+		} else {
+			index := make([]sitemap.URL, 0, len(pages))
+			for _, p := range pages {
+				index = append(index, sitemap.URL{
+					Loc:     h.links.URL(fmt.Sprintf("/sitemaps/%s-%d.xml", p.version.Kind, p.version.Number)),
+					LastMod: p.lastMod,
+				})
+				whole.lastMod = latest(whole.lastMod, p.lastMod)
+			}
+			whole.doc, err = sitemap.EncodeIndex(index)
+		}
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		whole.modified = whole.lastMod
+		if h.whole != nil {
+			whole.modified = time.Now()
+		}
+		h.whole = whole
+	}
+
+	h.write(ctx, h.whole)
+}
+
+// SitemapPageHandler serves a page of the sitemap index.
+func (h *sitemapHandler) SitemapPageHandler(ctx *gin.Context) {
+	match := sitemapFile.FindStringSubmatch(ctx.Param("file"))
+	if match == nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.refresh(ctx); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	page, ok := h.pages[match[1]+"-"+match[2]]
+	if !ok {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	h.write(ctx, page)
+}
+
+// refresh renders the pages whose resources changed since they were last rendered, drops
+// the ones that became empty, and returns every page in order. The caller holds mu.
+func (h *sitemapHandler) refresh(ctx *gin.Context) ([]*sitemapPage, error) {
+	versions, err := h.feedUsecase.GetSitemapPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]*sitemapPage, 0, len(versions))
+	current := make(map[string]*sitemapPage, len(versions))
+	for _, v := range versions {
+		key := v.Kind + "-" + strconv.FormatInt(v.Number, 10)
+		page, ok := h.pages[key]
+		if !ok || page.version != v {
+			if page, err = h.render(ctx, v); err != nil {
+				return nil, err
+			}
+			if ok {
+				page.modified = time.Now()
+			}
+		}
+		pages = append(pages, page)
+		current[key] = page
+	}
+	h.pages = current
+	return pages, nil
+}
+
+// render lists the resources of a page of the sitemap.
+func (h *sitemapHandler) render(ctx *gin.Context, version dto.SitemapPage) (*sitemapPage, error) {
+	entries, err := h.feedUsecase.GetSitemapEntries(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &sitemapPage{version: version, urls: make([]sitemap.URL, 0, len(entries))}
+	for _, e := range entries {
+		var loc string
+		switch version.Kind {
+		case dto.SitemapPosts:
+			loc = h.links.Post(e.ID)
+		case dto.SitemapTags:
+			loc = h.links.Tag(e.Name)
+		default:
+			loc = h.links.Author(e.ID)
+		}
+		page.urls = append(page.urls, sitemap.URL{Loc: loc, LastMod: e.UpdatedAt})
+		page.lastMod = latest(page.lastMod, e.UpdatedAt)
+	}
+	if page.doc, err = sitemap.Encode(page.urls); err != nil {
+		return nil, err
+	}
+	page.etag = etag.Strong("sitemap", version.Kind, version.Number, version.Count, version.Versions, version.MaxID)
+	page.modified = page.lastMod
+	return page, nil
+}
+
+func (h *sitemapHandler) write(ctx *gin.Context, page *sitemapPage) {
+	if httputil.NotModified(ctx.Writer, ctx.Request, page.etag, page.modified) {
+		return
+	}
+	ctx.Data(http.StatusOK, sitemap.ContentType, page.doc)
+}
@@ -0,0 +1,166 @@
+package feedhandler_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/delivery/feedhandler"
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+// sitemapUsecase serves pages of made up resources and records the pages it lists.
+type sitemapUsecase struct {
+	interfaces.FeedUsecase
+	pages    []dto.SitemapPage
+	rendered []string
+}
+
+func (uc *sitemapUsecase) GetSitemapPages(ctx *gin.Context) ([]dto.SitemapPage, error) {
+	return append([]dto.SitemapPage(nil), uc.pages...), nil
+}
+
+func (uc *sitemapUsecase) GetSitemapEntries(ctx *gin.Context, page dto.SitemapPage) ([]dto.SitemapEntry, error) {
+	uc.rendered = append(uc.rendered, page.Kind+"-"+strconv.FormatInt(page.Number, 10))
+	entries := make([]dto.SitemapEntry, page.Count)
+	for i := range entries {
+		entries[i] = dto.SitemapEntry{
+			ID:        (page.Number-1)*dto.SitemapPageSize + int64(i) + 1,
+			Name:      "tag " + strconv.Itoa(i),
+			UpdatedAt: time.Date(2026, 10, 19, 0, 0, i, 0, time.UTC),
+		}
+	}
+	return entries, nil
+}
+
+// document is a sitemap or a sitemap index.
+type document struct {
+	XMLName  xml.Name
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+type sitemapServer struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+func (s sitemapServer) get(path string, header http.Header) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (s sitemapServer) document(path string) (document, string) {
+	s.t.Helper()
+	w := s.get(path, nil)
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("GET %s: status %d", path, w.Code)
+	}
+	var doc document
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		s.t.Fatalf("GET %s: %v", path, err)
+	}
+	return doc, w.Header().Get("ETag")
+}
+
+func newSitemapServer(t *testing.T, uc interfaces.FeedUsecase) sitemapServer {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	feedhandler.NewSitemapHandler(r.Group(""), uc, "https://blog.example.com")
+	return sitemapServer{t: t, handler: r}
+}
+
+func TestSitemap(t *testing.T) {
+	uc := &sitemapUsecase{pages: []dto.SitemapPage{
+		{Kind: dto.SitemapPosts, Number: 1, Count: 2, Versions: 2, MaxID: 2},
+		{Kind: dto.SitemapTags, Number: 1, Count: 1, Versions: 1, MaxID: 1},
+		{Kind: dto.SitemapUsers, Number: 1, Count: 1, Versions: 1, MaxID: 1},
+	}}
+	s := newSitemapServer(t, uc)
+
+	doc, tag := s.document("/sitemap.xml")
+	want := []string{
+		"https://blog.example.com/posts/1",
+		"https://blog.example.com/posts/2",
+		"https://blog.example.com/tag/tag%200",
+		"https://blog.example.com/user/1",
+	}
+	if doc.XMLName.Local != "urlset" || !reflect.DeepEqual(doc.URLs, want) {
+		t.Fatalf("sitemap %+v, want the URLs %v", doc, want)
+	}
+	if w := s.get("/sitemap.xml", http.Header{"If-None-Match": {tag}}); w.Code != http.StatusNotModified {
+		t.Errorf("revalidation: status %d", w.Code)
+	}
+
+	page, _ := s.document("/sitemaps/posts-1.xml")
+	if !reflect.DeepEqual(page.URLs, want[:2]) {
+		t.Errorf("posts-1 %v", page.URLs)
+	}
+	for _, path := range []string{"/sitemaps/posts-2.xml", "/sitemaps/posts-01.xml", "/sitemaps/comments-1.xml", "/sitemaps/posts-1.txt"} {
+		if w := s.get(path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, w.Code)
+		}
+	}
+	// the pages are rendered once while their resources do not change
+	if want := []string{"posts-1", "tags-1", "users-1"}; !reflect.DeepEqual(uc.rendered, want) {
+		t.Errorf("rendered %v, want %v", uc.rendered, want)
+	}
+
+	uc.pages[2].Versions++
+	if _, changed := s.document("/sitemap.xml"); changed == tag {
+		t.Error("the ETag did not change with a resource")
+	}
+	if want := []string{"posts-1", "tags-1", "users-1", "users-1"}; !reflect.DeepEqual(uc.rendered, want) {
+		t.Errorf("rendered %v, want %v", uc.rendered, want)
+	}
+}
+
+func TestSitemapIndex(t *testing.T) {
+	uc := &sitemapUsecase{pages: []dto.SitemapPage{
+		{Kind: dto.SitemapPosts, Number: 1, Count: dto.SitemapPageSize, Versions: dto.SitemapPageSize, MaxID: dto.SitemapPageSize},
+		{Kind: dto.SitemapPosts, Number: 2, Count: 1, Versions: 1, MaxID: dto.SitemapPageSize + 1},
+		{Kind: dto.SitemapUsers, Number: 1, Count: 1, Versions: 1, MaxID: 1},
+	}}
+	s := newSitemapServer(t, uc)
+
+	// past the URLs a sitemap may list, it becomes an index of the pages
+	doc, _ := s.document("/sitemap.xml")
+	want := []string{
+		"https://blog.example.com/sitemaps/posts-1.xml",
+		"https://blog.example.com/sitemaps/posts-2.xml",
+		"https://blog.example.com/sitemaps/users-1.xml",
+	}
+	if doc.XMLName.Local != "sitemapindex" || !reflect.DeepEqual(doc.Sitemaps, want) {
+		t.Fatalf("index %s %v, want %v", doc.XMLName.Local, doc.Sitemaps, want)
+	}
+	first, _ := s.document("/sitemaps/posts-1.xml")
+	second, _ := s.document("/sitemaps/posts-2.xml")
+	if len(first.URLs) != dto.SitemapPageSize || len(second.URLs) != 1 || second.URLs[0] != "https://blog.example.com/posts/50001" {
+		t.Errorf("pages of %d and %v URLs", len(first.URLs), second.URLs)
+	}
+
+	// back under the limit, the sitemap lists the URLs again
+	uc.pages = []dto.SitemapPage{
+		{Kind: dto.SitemapPosts, Number: 1, Count: dto.SitemapPageSize - 1, Versions: dto.SitemapPageSize - 1, MaxID: dto.SitemapPageSize},
+		{Kind: dto.SitemapUsers, Number: 1, Count: 1, Versions: 1, MaxID: 1},
+	}
+	if doc, _ = s.document("/sitemap.xml"); doc.XMLName.Local != "urlset" || len(doc.URLs) != dto.SitemapPageSize {
+		t.Errorf("sitemap %s of %d URLs", doc.XMLName.Local, len(doc.URLs))
+	}
+	if w := s.get("/sitemaps/posts-2.xml", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET of a page that became empty: status %d", w.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package graphqlhandler
+
+import (
+	"math"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize is the number of items assumed for a list field without a limit, such as
+// the comments of a post.
+const defaultListSize = 10
+
+// complexity estimates the cost of the operation of doc: one per field, plus the cost of the
+// selections of the field, multiplied for list fields by the number of items they may return.
+// The estimate stops once it exceeds max and then returns max+1, so deeply nested lists
+// neither overflow nor take long to reject. It expects a validated document and returns 0
+// when the operation is not found, leaving the error to the executor.
+func complexity(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, max int) int {
+	if max >= math.MaxInt {
+		max = math.MaxInt - 1
+	}
+	c := &costs{schema: schema, variables: variables, fragments: map[string]*ast.FragmentDefinition{}, max: max}
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return 0
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case ast.OperationTypeQuery:
+		root = schema.QueryType()
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = schema.SubscriptionType()
+	}
+	return c.selections(root, operation.SelectionSet)
+}
+
+type costs struct {
+	schema    graphql.Schema
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	// max is the highest cost worth counting, the sums and products saturate above it.
+	max int
+}
+
+// add returns a+b, or max+1 when it exceeds max.
+func (c *costs) add(a, b int) int {
+	if a > c.max-b {
+		return c.max + 1
+	}
+	return a + b
+}
+
+// multiply returns a*b, or max+1 when it exceeds max.
+func (c *costs) multiply(a, b int) int {
+	if a != 0 && b > c.max/a {
+		return c.max + 1
+	}
+	return a * b
+}
+
+// selections sums the costs of the selections of set on parent, nil when the type is unknown
+// such as in introspection queries, whose fields all count as scalars.
+func (c *costs) selections(parent graphql.Type, set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+
+	cost := 0
+	for _, selection := range set.Selections {
+		if cost > c.max {
+			break
+		}
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost = c.add(cost, c.field(parent, s))
+		case *ast.InlineFragment:
+			t := parent
+			if s.TypeCondition != nil {
+				t = c.schema.Type(s.TypeCondition.Name.Value)
+			}
+			cost = c.add(cost, c.selections(t, s.SelectionSet))
+		case *ast.FragmentSpread:
+			if def, ok := c.fragments[s.Name.Value]; ok {
+				cost = c.add(cost, c.selections(c.schema.Type(def.TypeCondition.Name.Value), def.SelectionSet))
+			}
+		}
+	}
+	return cost
+}
+
+func (c *costs) field(parent graphql.Type, f *ast.Field) int {
+	var def *graphql.FieldDefinition
+	switch t := parent.(type) {
+	case *graphql.Object:
+		def = t.Fields()[f.Name.Value]
+	case *graphql.Interface:
+		def = t.Fields()[f.Name.Value]
+	}
+	if def == nil {
+		return c.add(1, c.selections(nil, f.SelectionSet))
+	}
+
+	t, list := unwrap(def.Type)
+	size := c.listSize(f, def, list)
+	if size == 0 {
+		// an empty list resolves none of its selections
+		return 1
+	}
+	return c.add(1, c.multiply(size, c.selections(t, f.SelectionSet)))
+}
+
+// listSize is the number of items the field may return: 1 for other fields, the limit argument
+// of the field when it has one, defaultListSize otherwise.
+func (c *costs) listSize(f *ast.Field, def *graphql.FieldDefinition, list bool) int {
+	if !list {
+		return 1
+	}
+
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, ok := graphql.Int.ParseLiteral(v).(int); ok {
+				return nonNegative(n)
+			}
+		case *ast.Variable:
+			switch n := c.variables[v.Name.Value].(type) {
+			case float64:
+				return nonNegative(int(n))
+			case int:
+				return nonNegative(n)
+			}
+		}
+	}
+	for _, arg := range def.Args {
+		if n, ok := arg.DefaultValue.(int); ok && arg.Name() == "limit" {
+			return n
+		}
+	}
+	return defaultListSize
+}
+
+// nonNegative keeps negative limits, rejected by the resolvers, from lowering the cost of the
+// rest of the operation.
+func nonNegative(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// unwrap returns the named type of t and whether it is a list.
+func unwrap(t graphql.Type) (graphql.Type, bool) {
+	list := false
+	for {
+		switch w := t.(type) {
+		case *graphql.NonNull:
+			t = w.OfType
+		case *graphql.List:
+			list = true
+			t = w.OfType
+		default:
+			return t, list
+		}
+	}
+}
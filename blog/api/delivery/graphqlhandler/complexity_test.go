@@ -0,0 +1,105 @@
+package graphqlhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// nested is the posts of user 1 followed through their authors depth times.
+func nested(depth int) string {
+	return `{ user(id: 1) { posts { ` + strings.Repeat(`author { posts { `, depth) + `title` + strings.Repeat(` } }`, depth) + ` } } }`
+}
+
+func parse(t *testing.T, query string) *ast.Document {
+	t.Helper()
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestComplexity(t *testing.T) {
+	schema, err := NewSchema(nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fragment Fn spreads F(n-1) twice, walking it would visit 2^40 fields
+	var fragments strings.Builder
+	fragments.WriteString(`fragment F0 on User { name } `)
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&fragments, `fragment F%d on User { ...F%d ...F%d } `, i, i-1, i-1)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		max       int
+		want      int
+	}{
+		{name: "scalar fields", query: `{ user(id: 1) { id name } }`, max: 1000, want: 3},
+		{name: "default limit", query: `{ users { name } }`, max: 1000, want: 1 + maxListLimit},
+		{name: "limit argument", query: `{ users(limit: 5) { posts { title } } }`, max: 1000, want: 1 + 5*(1+defaultListSize)},
+		{name: "limit variable", query: `query($n: Int) { posts(limit: $n) { title } }`, variables: map[string]interface{}{"n": float64(3)}, max: 1000, want: 4},
+		{name: "negative limit", query: `{ posts(limit: -5) { title comments { body } } }`, max: 1000, want: 1},
+		{name: "empty list", query: `{ users(limit: 0) { posts { title } } }`, max: 1000, want: 1},
+		{name: "fragments", query: `{ user(id: 1) { ...F1 ... on User { id } } } fragment F1 on User { name posts { title } }`, max: 1000, want: 1 + 2 + defaultListSize + 1},
+		{name: "named operation", query: `query A { users { name } } query B { user(id: 1) { name } }`, operation: "B", max: 1000, want: 2},
+		{name: "unknown operation", query: `query A { users { name } }`, operation: "B", max: 1000, want: 0},
+		{name: "within the limit", query: `{ user(id: 1) { id name } }`, max: 3, want: 3},
+		{name: "over the limit", query: `{ user(id: 1) { id name } }`, max: 2, want: 3},
+		{name: "deep nesting", query: nested(22), max: 1000, want: 1001},
+		{name: "deep nesting without limit", query: nested(22), max: math.MaxInt, want: math.MaxInt},
+		{name: "exponential fragments", query: fragments.String() + `{ user(id: 1) { ...F40 } }`, max: 1000, want: 1001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := complexity(schema.schema, parse(t, tt.query), tt.operation, tt.variables, tt.max)
+			if got != tt.want {
+				t.Errorf("complexity = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandlerRejectsComplexOperations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	schema, err := NewSchema(nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	NewGraphQLHandler(r.Group(""), schema, 1000)
+
+	body, _ := json.Marshal(request{Query: nested(22)})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+	var resp struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data != nil || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "COMPLEXITY_LIMIT_EXCEEDED" || resp.Errors[0].Extensions["limit"] != float64(1000) {
+		t.Errorf("response %s", w.Body)
+	}
+}
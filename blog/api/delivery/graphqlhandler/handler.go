@@ -0,0 +1,75 @@
+package graphqlhandler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type graphqlHandler struct {
+	schema        *Schema
+	maxComplexity int
+}
+
+// request is a GraphQL request sent over HTTP.
+type request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewGraphQLHandler serves the schema at POST graphql. Operations whose complexity exceeds
+// maxComplexity are rejected before running.
+func NewGraphQLHandler(g *gin.RouterGroup, schema *Schema, maxComplexity int) {
+	handler := &graphqlHandler{schema: schema, maxComplexity: maxComplexity}
+	g.POST("graphql", handler.GraphQLHandler)
+}
+
+// GraphQLHandler runs the operation of the request. Requests that cannot run, malformed, invalid
+// or too complex, get 400 and the errors only; the others get 200 with the data and the errors
+// of the fields that failed.
+func (h *graphqlHandler) GraphQLHandler(ctx *gin.Context) {
+	var req request
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		reject(ctx, gqlerrors.NewFormattedError(err.Error()))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		reject(ctx, gqlerrors.FormatErrors(err)...)
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema.schema, doc, nil)
+	if !validation.IsValid {
+		reject(ctx, validation.Errors...)
+		return
+	}
+
+	if complexity(h.schema.schema, doc, req.OperationName, req.Variables, h.maxComplexity) > h.maxComplexity {
+		tooComplex := gqlerrors.NewFormattedError(fmt.Sprintf("query complexity exceeds the limit of %d", h.maxComplexity))
+		tooComplex.Extensions = map[string]interface{}{"code": "COMPLEXITY_LIMIT_EXCEEDED", "limit": h.maxComplexity}
+		reject(ctx, tooComplex)
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, h.schema.resolver.newLoaders(ctx)),
+	})
+	ctx.JSON(http.StatusOK, result)
+}
+
+// reject answers a request that did not run, without data.
+func reject(ctx *gin.Context, errs ...gqlerrors.FormattedError) {
+	ctx.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+}
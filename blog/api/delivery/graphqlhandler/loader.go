@@ -0,0 +1,143 @@
+package graphqlhandler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+// loader batches the loads of a request. The executor resolves the fields of a level of the
+// query before the thunks they return, so every key asked for at that level is collected
+// before the first thunk runs and fetched with a single call.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, queued: map[K]bool{}, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// load queues key and returns the thunk resolving to its value, nil when it was not found.
+func (l *loader[K, V]) load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+			values, err := l.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+				} else if v, ok := values[k]; ok {
+					l.values[k] = v
+				}
+			}
+		}
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		if v, ok := l.values[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+// loaders are the loaders of a request, shared by its resolvers through the context.
+type loaders struct {
+	ctx            *gin.Context
+	users          *loader[int64, dto.User]
+	posts          *loader[int64, dto.Post]
+	postsByAuthor  *loader[int64, []dto.Post]
+	tagsByPost     *loader[int64, []dto.Tag]
+	commentsByPost *loader[int64, []dto.Comment]
+}
+
+type loadersKey struct{}
+
+func (r *resolver) newLoaders(ctx *gin.Context) *loaders {
+	return &loaders{
+		ctx: ctx,
+		users: newLoader(func(ids []int64) (map[int64]dto.User, error) {
+			users, err := r.users.GetUsersByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]dto.User, len(users))
+			for _, u := range users {
+				byID[u.ID] = u
+			}
+			return byID, nil
+		}),
+		posts: newLoader(func(ids []int64) (map[int64]dto.Post, error) {
+			posts, err := r.posts.GetPostsByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int64]dto.Post, len(posts))
+			for _, p := range posts {
+				byID[p.ID] = p
+			}
+			return byID, nil
+		}),
+		postsByAuthor: newLoader(func(ids []int64) (map[int64][]dto.Post, error) {
+			posts, err := r.posts.GetPostsByAuthorIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			return groupBy(ids, posts, func(p dto.Post) int64 { return p.AuthorID }), nil
+		}),
+		tagsByPost: newLoader(func(ids []int64) (map[int64][]dto.Tag, error) {
+			tags, err := r.tags.GetTagsByPostIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			return groupBy(ids, tags, func(t dto.Tag) int64 { return t.PostID }), nil
+		}),
+		commentsByPost: newLoader(func(ids []int64) (map[int64][]dto.Comment, error) {
+			comments, err := r.comments.GetCommentsByPostIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			return groupBy(ids, comments, func(c dto.Comment) int64 { return c.PostID }), nil
+		}),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// groupBy groups values by key, every one of keys getting a list even when empty.
+func groupBy[V any](keys []int64, values []V, key func(V) int64) map[int64][]V {
+	groups := make(map[int64][]V, len(keys))
+	for _, k := range keys {
+		groups[k] = []V{}
+	}
+	for _, v := range values {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
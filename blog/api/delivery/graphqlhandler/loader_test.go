@@ -0,0 +1,74 @@
+package graphqlhandler
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	var batches [][]int64
+	l := newLoader(func(keys []int64) (map[int64]string, error) {
+		batches = append(batches, keys)
+		values := map[int64]string{}
+		for _, k := range keys {
+			if k != 3 {
+				values[k] = string(rune('a' + k))
+			}
+		}
+		return values, nil
+	})
+
+	// the keys of a level are queued before the first thunk runs
+	thunks := []func() (interface{}, error){l.load(1), l.load(2), l.load(1), l.load(3)}
+	var got []interface{}
+	for _, thunk := range thunks {
+		v, err := thunk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if want := []interface{}{"b", "c", "b", nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values %v, want %v", got, want)
+	}
+
+	// the next level makes its own batch, without the keys already loaded
+	next := []func() (interface{}, error){l.load(2), l.load(4)}
+	for _, thunk := range next {
+		if _, err := thunk(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := [][]int64{{1, 2, 3}, {4}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches %v, want %v", batches, want)
+	}
+}
+
+func TestLoaderError(t *testing.T) {
+	failure := errors.New("database is locked")
+	calls := 0
+	l := newLoader(func(keys []int64) (map[int64]int, error) {
+		calls++
+		return nil, failure
+	})
+
+	first, second := l.load(1), l.load(2)
+	for _, thunk := range []func() (interface{}, error){first, second} {
+		if v, err := thunk(); err != failure || v != nil {
+			t.Errorf("thunk = %v, %v, want the error of the batch", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%d fetches, want 1", calls)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	type post struct{ ID, AuthorID int64 }
+	got := groupBy([]int64{1, 2}, []post{{10, 1}, {11, 1}}, func(p post) int64 { return p.AuthorID })
+	want := map[int64][]post{1: {{10, 1}, {11, 1}}, 2: {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupBy = %v, want %v", got, want)
+	}
+}
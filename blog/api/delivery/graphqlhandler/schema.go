@@ -0,0 +1,561 @@
+package graphqlhandler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/httputil"
+	"blog/utils/validation"
+)
+
+// maxListLimit caps the users and posts listed by a query, as on the REST endpoints.
+const maxListLimit = 100
+
+var (
+	requiredID     = graphql.NewNonNull(graphql.ID)
+	requiredString = graphql.NewNonNull(graphql.String)
+)
+
+// Schema is the GraphQL schema of the blog together with the usecases resolving it.
+type Schema struct {
+	schema   graphql.Schema
+	resolver *resolver
+}
+
+type resolver struct {
+	users    interfaces.UserUsecase
+	posts    interfaces.PostUsecase
+	tags     interfaces.TagsUsecase
+	comments interfaces.CommentsUsecase
+}
+
+// NewSchema builds the schema of the users, posts, tags and comments, whose queries and
+// mutations delegate to the given usecases.
+func NewSchema(users interfaces.UserUsecase, posts interfaces.PostUsecase, tags interfaces.TagsUsecase, comments interfaces.CommentsUsecase) (*Schema, error) {
+	r := &resolver{users: users, posts: posts, tags: tags, comments: comments}
+
+	var userType, postType, tagType, commentType *graphql.Object
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        {Type: requiredID, Resolve: fieldOf(func(u dto.User) interface{} { return u.ID })},
+				"name":      {Type: requiredString, Resolve: fieldOf(func(u dto.User) interface{} { return u.Name })},
+				"createdAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(u dto.User) interface{} { return u.CreatedAt })},
+				"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(u dto.User) interface{} { return u.UpdatedAt })},
+				"version":   {Type: graphql.NewNonNull(graphql.Int), Resolve: fieldOf(func(u dto.User) interface{} { return u.Version })},
+				"posts": {
+					Type: listOf(postType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).postsByAuthor.load(p.Source.(dto.User).ID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":            {Type: requiredID, Resolve: fieldOf(func(p dto.Post) interface{} { return p.ID })},
+				"title":         {Type: requiredString, Resolve: fieldOf(func(p dto.Post) interface{} { return p.Title })},
+				"content":       {Type: requiredString, Resolve: fieldOf(func(p dto.Post) interface{} { return p.Content })},
+				"authorId":      {Type: requiredID, Resolve: fieldOf(func(p dto.Post) interface{} { return p.AuthorID })},
+				"commentsCount": {Type: graphql.NewNonNull(graphql.Int), Resolve: fieldOf(func(p dto.Post) interface{} { return p.CommentsCount })},
+				"createdAt":     {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(p dto.Post) interface{} { return p.CreatedAt })},
+				"updatedAt":     {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(p dto.Post) interface{} { return p.UpdatedAt })},
+				"version":       {Type: graphql.NewNonNull(graphql.Int), Resolve: fieldOf(func(p dto.Post) interface{} { return p.Version })},
+				"author": {
+					Type:        userType,
+					Description: "The author, null once deleted.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						post := p.Source.(dto.Post)
+						// the post reads load the authors along with the posts
+						if post.Author.ID != 0 {
+							return post.Author, nil
+						}
+						return loadersFrom(p.Context).users.load(post.AuthorID), nil
+					},
+				},
+				"tags": {
+					Type:        listOf(tagType),
+					Description: "The tags created on the post.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).tagsByPost.load(p.Source.(dto.Post).ID), nil
+					},
+				},
+				"comments": {
+					Type: listOf(commentType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).commentsByPost.load(p.Source.(dto.Post).ID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	tagType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        {Type: requiredID, Resolve: fieldOf(func(t dto.Tag) interface{} { return t.ID })},
+				"name":      {Type: requiredString, Resolve: fieldOf(func(t dto.Tag) interface{} { return t.Name })},
+				"postId":    {Type: requiredID, Resolve: fieldOf(func(t dto.Tag) interface{} { return t.PostID })},
+				"createdAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(t dto.Tag) interface{} { return t.CreatedAt })},
+				"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(t dto.Tag) interface{} { return t.UpdatedAt })},
+				"version":   {Type: graphql.NewNonNull(graphql.Int), Resolve: fieldOf(func(t dto.Tag) interface{} { return t.Version })},
+				"post": {
+					Type: postType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).posts.load(p.Source.(dto.Tag).PostID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	commentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        {Type: requiredID, Resolve: fieldOf(func(c dto.Comment) interface{} { return c.ID })},
+				"name":      {Type: requiredString, Resolve: fieldOf(func(c dto.Comment) interface{} { return c.Name })},
+				"body":      {Type: requiredString, Resolve: fieldOf(func(c dto.Comment) interface{} { return c.Body })},
+				"postId":    {Type: requiredID, Resolve: fieldOf(func(c dto.Comment) interface{} { return c.PostID })},
+				"createdAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(c dto.Comment) interface{} { return c.CreatedAt })},
+				"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: fieldOf(func(c dto.Comment) interface{} { return c.UpdatedAt })},
+				"version":   {Type: graphql.NewNonNull(graphql.Int), Resolve: fieldOf(func(c dto.Comment) interface{} { return c.Version })},
+				"post": {
+					Type: postType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).posts.load(p.Source.(dto.Comment).PostID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	page := graphql.FieldConfigArgument{
+		"limit":  {Type: graphql.Int, DefaultValue: maxListLimit},
+		"offset": {Type: graphql.Int, DefaultValue: 0},
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": {
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userID, err := id(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					user, err := r.users.GetUserById(ginContext(p), userID)
+					if err != nil {
+						return nil, err
+					}
+					return *user, nil
+				},
+			},
+			"users": {
+				Type: listOf(userType),
+				Args: page,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, offset, err := pagination(p)
+					if err != nil {
+						return nil, err
+					}
+					users, err := r.users.GetAllUsers(ginContext(p), limit, offset)
+					if err != nil {
+						return nil, err
+					}
+					return *users, nil
+				},
+			},
+			"post": {
+				Type: postType,
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postID, err := id(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(p.Context).posts.load(postID), nil
+				},
+			},
+			"posts": {
+				Type: listOf(postType),
+				Args: page,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, offset, err := pagination(p)
+					if err != nil {
+						return nil, err
+					}
+					posts, err := r.posts.GetAllPosts(ginContext(p), limit, offset)
+					if err != nil {
+						return nil, err
+					}
+					return *posts, nil
+				},
+			},
+			"tag": {
+				Type: tagType,
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tagID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					tag, err := r.tags.GetTagById(ginContext(p), tagID, postID)
+					if err != nil {
+						return nil, err
+					}
+					return *tag, nil
+				},
+			},
+			"comment": {
+				Type: commentType,
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					commentID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					comment, err := r.comments.GetCommentById(ginContext(p), commentID, postID)
+					if err != nil {
+						return nil, err
+					}
+					return *comment, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": {
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{"name": {Type: requiredString}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					request := &dto.User{Name: str(p, "name")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					ctx := ginContext(p)
+					created, err := r.users.CreateUser(ctx, request)
+					if err != nil {
+						return nil, err
+					}
+					user, err := r.users.GetUserById(ctx, created.ID)
+					if err != nil {
+						return nil, err
+					}
+					return *user, nil
+				},
+			},
+			"updateUser": {
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "name": {Type: graphql.String}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userID, err := id(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.UpdateUserBodyRequest{Name: str(p, "name")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					user, err := r.users.UpdateUser(ginContext(p), userID, request)
+					if err != nil {
+						return nil, err
+					}
+					return *user, nil
+				},
+			},
+			"deleteUser": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userID, err := id(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					if err := r.users.DeleteUser(ginContext(p), userID); err != nil {
+						return nil, err
+					}
+					return true, nil
+				},
+			},
+			"createPost": {
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{"authorId": {Type: requiredID}, "title": {Type: requiredString}, "content": {Type: requiredString}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					authorID, err := id(p, "authorId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.PostCreate{Title: str(p, "title"), Content: str(p, "content")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					ctx := ginContext(p)
+					created, err := r.posts.CreatePost(ctx, authorID, request)
+					if err != nil {
+						return nil, err
+					}
+					post, err := r.posts.GetPostById(ctx, created.ID, authorID)
+					if err != nil {
+						return nil, err
+					}
+					return *post, nil
+				},
+			},
+			"updatePost": {
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "authorId": {Type: requiredID}, "title": {Type: graphql.String}, "content": {Type: graphql.String}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postID, authorID, err := idPair(p, "id", "authorId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.UpdatePostBodyRequest{Title: str(p, "title"), Content: str(p, "content")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					post, err := r.posts.UpdatePost(ginContext(p), postID, authorID, request)
+					if err != nil {
+						return nil, err
+					}
+					return *post, nil
+				},
+			},
+			"deletePost": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "authorId": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postID, authorID, err := idPair(p, "id", "authorId")
+					if err != nil {
+						return nil, err
+					}
+					if err := r.posts.DeletePost(ginContext(p), postID, authorID); err != nil {
+						return nil, err
+					}
+					return true, nil
+				},
+			},
+			"createTag": {
+				Type: graphql.NewNonNull(tagType),
+				Args: graphql.FieldConfigArgument{"postId": {Type: requiredID}, "name": {Type: requiredString}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postID, err := id(p, "postId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.Tag{Name: str(p, "name")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					ctx := ginContext(p)
+					created, err := r.tags.CreateTag(ctx, postID, request)
+					if err != nil {
+						return nil, err
+					}
+					tag, err := r.tags.GetTagById(ctx, created.ID, postID)
+					if err != nil {
+						return nil, err
+					}
+					return *tag, nil
+				},
+			},
+			"updateTag": {
+				Type: graphql.NewNonNull(tagType),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}, "name": {Type: graphql.String}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tagID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.UpdateTagsBodyRequest{Name: str(p, "name")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					tag, err := r.tags.UpdateTags(ginContext(p), tagID, postID, request)
+					if err != nil {
+						return nil, err
+					}
+					return *tag, nil
+				},
+			},
+			"deleteTag": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tagID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					if err := r.tags.DeleteTags(ginContext(p), tagID, postID); err != nil {
+						return nil, err
+					}
+					return true, nil
+				},
+			},
+			"createComment": {
+				Type: graphql.NewNonNull(commentType),
+				Args: graphql.FieldConfigArgument{"postId": {Type: requiredID}, "name": {Type: requiredString}, "body": {Type: requiredString}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					postID, err := id(p, "postId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.Comment{Name: str(p, "name"), Body: str(p, "body")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					ctx := ginContext(p)
+					created, err := r.comments.CreateComment(ctx, postID, request)
+					if err != nil {
+						return nil, err
+					}
+					comment, err := r.comments.GetCommentById(ctx, created.ID, postID)
+					if err != nil {
+						return nil, err
+					}
+					return *comment, nil
+				},
+			},
+			"updateComment": {
+				Type: graphql.NewNonNull(commentType),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}, "name": {Type: graphql.String}, "body": {Type: graphql.String}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					commentID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					request := &dto.UpdateCommentsBodyRequest{Name: str(p, "name"), Body: str(p, "body")}
+					if err := validate(request); err != nil {
+						return nil, err
+					}
+					comment, err := r.comments.UpdateComments(ginContext(p), commentID, postID, request)
+					if err != nil {
+						return nil, err
+					}
+					return *comment, nil
+				},
+			},
+			"deleteComment": {
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": {Type: requiredID}, "postId": {Type: requiredID}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					commentID, postID, err := idPair(p, "id", "postId")
+					if err != nil {
+						return nil, err
+					}
+					if err := r.comments.DeleteComments(ginContext(p), commentID, postID); err != nil {
+						return nil, err
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		return nil, errors.Wrap(err, "graphql schema")
+	}
+	return &Schema{schema: schema, resolver: r}, nil
+}
+
+// fieldOf resolves a field from the value of type T it belongs to.
+func fieldOf[T any](field func(T) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return field(p.Source.(T)), nil
+	}
+}
+
+func listOf(t graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// ginContext returns the context of the request the usecases are called for.
+func ginContext(p graphql.ResolveParams) *gin.Context {
+	return loadersFrom(p.Context).ctx
+}
+
+func id(p graphql.ResolveParams, name string) (int64, error) {
+	s, _ := p.Args[name].(string)
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, errors.Errorf("%s must be a positive integer", name)
+	}
+	return v, nil
+}
+
+func idPair(p graphql.ResolveParams, first, second string) (int64, int64, error) {
+	a, err := id(p, first)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := id(p, second)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+func str(p graphql.ResolveParams, name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+func pagination(p graphql.ResolveParams) (int, int, error) {
+	limit, _ := p.Args["limit"].(int)
+	offset, _ := p.Args["offset"].(int)
+	if limit < 1 || limit > maxListLimit {
+		return 0, 0, errors.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+	if offset < 0 {
+		return 0, 0, errors.New("offset must not be negative")
+	}
+	return limit, offset, nil
+}
+
+// validate applies the binding rules of the request DTOs to the arguments they are built from.
+func validate(request interface{}) error {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return inputError{errs: validation.Errors(err)}
+	}
+	return nil
+}
+
+// inputError reports the arguments failing validation, listed in the extensions of the error.
+type inputError struct {
+	errs []httputil.StandardError
+}
+
+func (e inputError) Error() string {
+	details := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		details[i] = err.Detail
+	}
+	return strings.Join(details, "; ")
+}
+
+func (e inputError) Extensions() map[string]interface{} {
+	var fields []string
+	for _, err := range e.errs {
+		fields = append(fields, err.Object.Text...)
+	}
+	return map[string]interface{}{"code": "BAD_USER_INPUT", "fields": fields}
+}
@@ -0,0 +1,101 @@
+package grpchandler
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	blogv1 "blog/proto/blog/v1"
+)
+
+type userServer struct {
+	blogv1.UnimplementedUserServiceServer
+	userUsecase interfaces.UserUsecase
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *blogv1.GetUserRequest) (*blogv1.User, error) {
+	if err := requireID("id", req.Id); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUsecase.GetUserById(usecaseContext(ctx), req.Id)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *blogv1.ListUsersRequest) (*blogv1.ListUsersResponse, error) {
+	limit, offset, err := pagination(req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userUsecase.GetAllUsers(usecaseContext(ctx), limit, offset)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	resp := &blogv1.ListUsersResponse{Users: make([]*blogv1.User, 0, len(*users))}
+	for i := range *users {
+		resp.Users = append(resp.Users, userMessage(&(*users)[i]))
+	}
+	return resp, nil
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *blogv1.CreateUserRequest) (*blogv1.User, error) {
+	request := &dto.User{Name: req.Name}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	c := usecaseContext(ctx)
+	created, err := s.userUsecase.CreateUser(c, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	user, err := s.userUsecase.GetUserById(c, created.ID)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *blogv1.UpdateUserRequest) (*blogv1.User, error) {
+	if err := requireID("id", req.Id); err != nil {
+		return nil, err
+	}
+	request := &dto.UpdateUserBodyRequest{Name: req.Name}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userUsecase.UpdateUser(usecaseContext(ctx), req.Id, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *blogv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := requireID("id", req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.userUsecase.DeleteUser(usecaseContext(ctx), req.Id); err != nil {
+		return nil, usecaseError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func userMessage(user *dto.User) *blogv1.User {
+	return &blogv1.User{
+		Id:        user.ID,
+		Name:      user.Name,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		Version:   user.Version,
+	}
+}
@@ -0,0 +1,105 @@
+package grpchandler
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	blogv1 "blog/proto/blog/v1"
+)
+
+type commentServer struct {
+	blogv1.UnimplementedCommentServiceServer
+	commentsUsecase interfaces.CommentsUsecase
+}
+
+func (s *commentServer) GetComment(ctx context.Context, req *blogv1.GetCommentRequest) (*blogv1.Comment, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentsUsecase.GetCommentById(usecaseContext(ctx), req.Id, req.PostId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return commentMessage(comment), nil
+}
+
+func (s *commentServer) ListComments(ctx context.Context, req *blogv1.ListCommentsRequest) (*blogv1.ListCommentsResponse, error) {
+	if err := requireID("post_id", req.PostId); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentsUsecase.GetCommentsByPostIds(usecaseContext(ctx), []int64{req.PostId})
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	resp := &blogv1.ListCommentsResponse{Comments: make([]*blogv1.Comment, 0, len(comments))}
+	for i := range comments {
+		resp.Comments = append(resp.Comments, commentMessage(&comments[i]))
+	}
+	return resp, nil
+}
+
+func (s *commentServer) CreateComment(ctx context.Context, req *blogv1.CreateCommentRequest) (*blogv1.Comment, error) {
+	if err := requireID("post_id", req.PostId); err != nil {
+		return nil, err
+	}
+	request := &dto.Comment{Name: req.Name, Body: req.Body}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	c := usecaseContext(ctx)
+	created, err := s.commentsUsecase.CreateComment(c, req.PostId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	comment, err := s.commentsUsecase.GetCommentById(c, created.ID, req.PostId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return commentMessage(comment), nil
+}
+
+func (s *commentServer) UpdateComment(ctx context.Context, req *blogv1.UpdateCommentRequest) (*blogv1.Comment, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+	request := &dto.UpdateCommentsBodyRequest{Name: req.Name, Body: req.Body}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentsUsecase.UpdateComments(usecaseContext(ctx), req.Id, req.PostId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return commentMessage(comment), nil
+}
+
+func (s *commentServer) DeleteComment(ctx context.Context, req *blogv1.DeleteCommentRequest) (*emptypb.Empty, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+
+	if err := s.commentsUsecase.DeleteComments(usecaseContext(ctx), req.Id, req.PostId); err != nil {
+		return nil, usecaseError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func commentMessage(comment *dto.Comment) *blogv1.Comment {
+	return &blogv1.Comment{
+		Id:        comment.ID,
+		PostId:    comment.PostID,
+		Name:      comment.Name,
+		Body:      comment.Body,
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		UpdatedAt: timestamppb.New(comment.UpdatedAt),
+		Version:   comment.Version,
+	}
+}
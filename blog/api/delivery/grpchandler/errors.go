@@ -0,0 +1,78 @@
+package grpchandler
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"blog/domain/dto"
+	"blog/utils/validation"
+)
+
+// errorCode maps a usecase error to the status code reported to the client.
+func errorCode(err error) codes.Code {
+	switch dto.KindOf(err) {
+	case dto.KindNotFound:
+		return codes.NotFound
+	case dto.KindInvalid, dto.KindTooLarge, dto.KindUnsupported:
+		return codes.InvalidArgument
+	case dto.KindAlreadyExists:
+		return codes.AlreadyExists
+	case dto.KindFailedPrecondition:
+		return codes.FailedPrecondition
+	case dto.KindStale:
+		return codes.Aborted
+	case dto.KindPermissionDenied:
+		return codes.PermissionDenied
+	}
+	return codes.Internal
+}
+
+// usecaseError converts a usecase error into a status error, reporting validation failures
+// field by field.
+func usecaseError(err error) error {
+	if validation.Failed(err) {
+		return invalidArgument(err)
+	}
+	return status.Error(errorCode(err), err.Error())
+}
+
+// validate checks request against its binding rules, the ones the HTTP API applies to the
+// same bodies.
+func validate(request interface{}) error {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return invalidArgument(err)
+	}
+	return nil
+}
+
+// invalidArgument reports a validation error with a BadRequest detail listing the offending
+// fields.
+func invalidArgument(err error) error {
+	if !validation.Failed(err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	errs := validation.Errors(err)
+	detail := &errdetails.BadRequest{}
+	for _, e := range errs {
+		detail.FieldViolations = append(detail.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       e.Object.Text[0],
+			Description: e.Detail,
+		})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, errs[0].Detail).WithDetails(detail)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
+// requireID fails unless id, the field name of the request, is set.
+func requireID(name string, id int64) error {
+	if id <= 0 {
+		return status.Errorf(codes.InvalidArgument, "%s is required", name)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package grpchandler
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	blogv1 "blog/proto/blog/v1"
+)
+
+type postServer struct {
+	blogv1.UnimplementedPostServiceServer
+	postUsecase interfaces.PostUsecase
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *blogv1.GetPostRequest) (*blogv1.Post, error) {
+	if err := requirePostIDs(req.Id, req.AuthorId); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postUsecase.GetPostById(usecaseContext(ctx), req.Id, req.AuthorId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return postMessage(post), nil
+}
+
+func (s *postServer) ListPosts(ctx context.Context, req *blogv1.ListPostsRequest) (*blogv1.ListPostsResponse, error) {
+	limit, offset, err := pagination(req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.postUsecase.GetAllPosts(usecaseContext(ctx), limit, offset)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	resp := &blogv1.ListPostsResponse{Posts: make([]*blogv1.Post, 0, len(*posts))}
+	for i := range *posts {
+		resp.Posts = append(resp.Posts, postMessage(&(*posts)[i]))
+	}
+	return resp, nil
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *blogv1.CreatePostRequest) (*blogv1.Post, error) {
+	if err := requireID("author_id", req.AuthorId); err != nil {
+		return nil, err
+	}
+	request := &dto.PostCreate{Title: req.Title, Content: req.Content, TagsID: req.TagsId}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	c := usecaseContext(ctx)
+	created, err := s.postUsecase.CreatePost(c, req.AuthorId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	post, err := s.postUsecase.GetPostById(c, created.ID, req.AuthorId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return postMessage(post), nil
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, req *blogv1.UpdatePostRequest) (*blogv1.Post, error) {
+	if err := requirePostIDs(req.Id, req.AuthorId); err != nil {
+		return nil, err
+	}
+	request := &dto.UpdatePostBodyRequest{Title: req.Title, Content: req.Content}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postUsecase.UpdatePost(usecaseContext(ctx), req.Id, req.AuthorId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return postMessage(post), nil
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *blogv1.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := requirePostIDs(req.Id, req.AuthorId); err != nil {
+		return nil, err
+	}
+
+	if err := s.postUsecase.DeletePost(usecaseContext(ctx), req.Id, req.AuthorId); err != nil {
+		return nil, usecaseError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func requirePostIDs(id, authorID int64) error {
+	if err := requireID("id", id); err != nil {
+		return err
+	}
+	return requireID("author_id", authorID)
+}
+
+func postMessage(post *dto.Post) *blogv1.Post {
+	msg := &blogv1.Post{
+		Id:            post.ID,
+		Title:         post.Title,
+		Content:       post.Content,
+		AuthorId:      post.AuthorID,
+		Tags:          make([]*blogv1.Tag, 0, len(post.Tags)),
+		CommentsCount: post.CommentsCount,
+		CreatedAt:     timestamppb.New(post.CreatedAt),
+		UpdatedAt:     timestamppb.New(post.UpdatedAt),
+		Version:       post.Version,
+	}
+	// the preload leaves the author zero once it is deleted
+	if post.Author.ID != 0 {
+		msg.Author = userMessage(&post.Author)
+	}
+	for i := range post.Tags {
+		msg.Tags = append(msg.Tags, tagMessage(&post.Tags[i]))
+	}
+	return msg
+}
@@ -0,0 +1,114 @@
+package grpchandler
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"blog/domain/interfaces"
+	blogv1 "blog/proto/blog/v1"
+	"blog/utils/httputil"
+	"blog/utils/log"
+	"blog/utils/tracing"
+)
+
+// requestIDKey is the metadata key of the request id, the X-Request-ID header of the HTTP API.
+const requestIDKey = "x-request-id"
+
+// validRequestID bounds the ids accepted from clients, as they end up in logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// NewServer returns a gRPC server of the users, posts, tags and comments, implemented by the
+// given usecases, with server reflection for tools such as grpcurl.
+func NewServer(logger *zap.Logger, users interfaces.UserUsecase, posts interfaces.PostUsecase, tags interfaces.TagsUsecase, comments interfaces.CommentsUsecase) *grpc.Server {
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unaryInterceptor(logger)))
+	blogv1.RegisterUserServiceServer(s, &userServer{userUsecase: users})
+	blogv1.RegisterPostServiceServer(s, &postServer{postUsecase: posts})
+	blogv1.RegisterTagServiceServer(s, &tagServer{tagsUsecase: tags})
+	blogv1.RegisterCommentServiceServer(s, &commentServer{commentsUsecase: comments})
+	reflection.Register(s)
+	return s
+}
+
+// unaryInterceptor gives each call what the HTTP middlewares give a request: an id, a span
+// and a logger in its context. It logs the call once done and turns panics into Internal errors.
+func unaryInterceptor(base *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		start := time.Now()
+
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(requestIDKey); len(ids) > 0 && validRequestID.MatchString(ids[0]) {
+				requestID = ids[0]
+			}
+		}
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+		ctx = httputil.WithRequestID(ctx, requestID)
+
+		ctx, span := tracing.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		logger := base.With(append([]zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", info.FullMethod),
+		}, tracing.LogFields(ctx)...)...)
+		ctx = log.WithContext(ctx, logger)
+
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic", zap.Any("panic", r), zap.Stack("stack"))
+				err = status.Error(grpccodes.Internal, "internal error")
+			}
+
+			code := status.Code(err)
+			if code != grpccodes.OK {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			logger.Info("grpc call", zap.String("code", code.String()), zap.Duration("latency", time.Since(start)))
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// baseContext is copied into the gin context of each call. Its engine makes the copies
+// delegate Deadline, Done and Value to their request.
+var baseContext = func() *gin.Context {
+	c, engine := gin.CreateTestContext(nil)
+	engine.ContextWithFallback = true
+	return c
+}()
+
+// usecaseContext adapts the context of a call to the usecases, which take the gin context of
+// an HTTP request. The request carries ctx, so its deadline, span and logger reach them.
+func usecaseContext(ctx context.Context) *gin.Context {
+	c := baseContext.Copy()
+	c.Request = (&http.Request{Header: http.Header{}}).WithContext(ctx)
+	return c
+}
+
+// pagination applies the default and the cap of the list endpoints of the HTTP API to limit.
+func pagination(limit, offset int32) (int, int, error) {
+	if limit < 0 || offset < 0 {
+		return 0, 0, status.Error(grpccodes.InvalidArgument, "limit and offset must not be negative")
+	}
+	if limit == 0 || limit > 100 {
+		limit = 100
+	}
+	return int(limit), int(offset), nil
+}
@@ -0,0 +1,243 @@
+package grpchandler_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"blog/api/delivery/grpchandler"
+	"blog/api/usecase"
+	"blog/domain/dto"
+	blogv1 "blog/proto/blog/v1"
+	"blog/utils/validation"
+)
+
+// newClient serves the API on a fresh database over an in-memory listener and returns a
+// connection to it.
+func newClient(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "blog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{})
+
+	server := grpchandler.NewServer(zap.NewNop(),
+		usecase.NewUserUsecase(db, dto.CascadeDelete, nil, nil),
+		usecase.NewPostUsecase(db, dto.CascadeDelete, nil),
+		usecase.NewTagsUsecase(db, nil),
+		usecase.NewCommentsUsecase(db, nil))
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// wantCode fails unless err has the status code want.
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("code %s, want %s: %v", got, want, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	conn := newClient(t)
+	ctx := context.Background()
+	users := blogv1.NewUserServiceClient(conn)
+	posts := blogv1.NewPostServiceClient(conn)
+	tags := blogv1.NewTagServiceClient(conn)
+	comments := blogv1.NewCommentServiceClient(conn)
+
+	// users
+	user, err := users.CreateUser(ctx, &blogv1.CreateUserRequest{Name: "ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Id == 0 || user.Version != 1 || user.CreatedAt == nil {
+		t.Fatalf("created user %v", user)
+	}
+	_, err = users.CreateUser(ctx, &blogv1.CreateUserRequest{Name: " "})
+	wantCode(t, err, codes.InvalidArgument)
+	details := status.Convert(err).Details()
+	if len(details) != 1 || details[0].(*errdetails.BadRequest).FieldViolations[0].Field != "name" {
+		t.Fatalf("details %v, want a violation of name", details)
+	}
+	user, err = users.UpdateUser(ctx, &blogv1.UpdateUserRequest{Id: user.Id, Name: "ada lovelace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "ada lovelace" {
+		t.Fatalf("updated user %v", user)
+	}
+	list, err := users.ListUsers(ctx, &blogv1.ListUsersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Users) != 1 {
+		t.Fatalf("listed %d users, want 1", len(list.Users))
+	}
+	_, err = users.GetUser(ctx, &blogv1.GetUserRequest{})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = users.UpdateUser(ctx, &blogv1.UpdateUserRequest{Id: 9, Name: "nobody"})
+	wantCode(t, err, codes.NotFound)
+	_, err = users.CreateUser(ctx, &blogv1.CreateUserRequest{Name: "Ghost"})
+	wantCode(t, err, codes.AlreadyExists)
+	_, err = users.UpdateUser(ctx, &blogv1.UpdateUserRequest{Id: user.Id, Name: "ghost"})
+	wantCode(t, err, codes.AlreadyExists)
+
+	// posts
+	post, err := posts.CreatePost(ctx, &blogv1.CreatePostRequest{AuthorId: user.Id, Title: "notes", Content: "on the analytical engine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Author.GetName() != "ada lovelace" {
+		t.Fatalf("post author %v", post.Author)
+	}
+	post, err = posts.UpdatePost(ctx, &blogv1.UpdatePostRequest{Id: post.Id, AuthorId: user.Id, Content: "on the difference engine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Title != "notes" || post.Content != "on the difference engine" {
+		t.Fatalf("updated post %v", post)
+	}
+	postList, err := posts.ListPosts(ctx, &blogv1.ListPostsRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postList.Posts) != 1 {
+		t.Fatalf("listed %d posts, want 1", len(postList.Posts))
+	}
+
+	// tags and comments
+	tag, err := tags.CreateTag(ctx, &blogv1.CreateTagRequest{PostId: post.Id, Name: "history"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tags.UpdateTag(ctx, &blogv1.UpdateTagRequest{Id: tag.Id, PostId: post.Id, Name: "computing"}); err != nil {
+		t.Fatal(err)
+	}
+	tagList, err := tags.ListTags(ctx, &blogv1.ListTagsRequest{PostId: post.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagList.Tags) != 1 || tagList.Tags[0].Name != "computing" {
+		t.Fatalf("listed tags %v", tagList.Tags)
+	}
+	comment, err := comments.CreateComment(ctx, &blogv1.CreateCommentRequest{PostId: post.Id, Name: "babbage", Body: "splendid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.GetComment(ctx, &blogv1.GetCommentRequest{Id: comment.Id, PostId: post.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.DeleteComment(ctx, &blogv1.DeleteCommentRequest{Id: comment.Id, PostId: post.Id}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = comments.GetComment(ctx, &blogv1.GetCommentRequest{Id: comment.Id, PostId: post.Id})
+	wantCode(t, err, codes.NotFound)
+	commentList, err := comments.ListComments(ctx, &blogv1.ListCommentsRequest{PostId: post.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commentList.Comments) != 0 {
+		t.Fatalf("listed %d comments after the deletion, want 0", len(commentList.Comments))
+	}
+
+	// deletion
+	if _, err := tags.DeleteTag(ctx, &blogv1.DeleteTagRequest{Id: tag.Id, PostId: post.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.DeleteUser(ctx, &blogv1.DeleteUserRequest{Id: user.Id}); err != nil {
+		t.Fatal(err)
+	}
+	postList, err = posts.ListPosts(ctx, &blogv1.ListPostsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postList.Posts) != 0 {
+		t.Fatalf("listed %d posts after the deletion of their author, want 0", len(postList.Posts))
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	users := blogv1.NewUserServiceClient(newClient(t))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "abc-123")
+	if _, err := users.ListUsers(ctx, &blogv1.ListUsersRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "abc-123" {
+		t.Fatalf("request id %v, want abc-123", got)
+	}
+
+	header = nil
+	if _, err := users.ListUsers(context.Background(), &blogv1.ListUsersRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] == "" {
+		t.Fatalf("request id %v, want a generated one", got)
+	}
+}
+
+func TestReflection(t *testing.T) {
+	client := reflectionpb.NewServerReflectionClient(newClient(t))
+	stream, err := client.ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.Name)
+	}
+	sort.Strings(services)
+	want := []string{"blog.v1.CommentService", "blog.v1.PostService", "blog.v1.TagService", "blog.v1.UserService", "grpc.reflection.v1alpha.ServerReflection"}
+	if len(services) != len(want) {
+		t.Fatalf("services %v, want %v", services, want)
+	}
+	for i := range want {
+		if services[i] != want[i] {
+			t.Fatalf("services %v, want %v", services, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package grpchandler
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	blogv1 "blog/proto/blog/v1"
+)
+
+type tagServer struct {
+	blogv1.UnimplementedTagServiceServer
+	tagsUsecase interfaces.TagsUsecase
+}
+
+func (s *tagServer) GetTag(ctx context.Context, req *blogv1.GetTagRequest) (*blogv1.Tag, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagsUsecase.GetTagById(usecaseContext(ctx), req.Id, req.PostId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return tagMessage(tag), nil
+}
+
+func (s *tagServer) ListTags(ctx context.Context, req *blogv1.ListTagsRequest) (*blogv1.ListTagsResponse, error) {
+	if err := requireID("post_id", req.PostId); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagsUsecase.GetTagsByPostIds(usecaseContext(ctx), []int64{req.PostId})
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	resp := &blogv1.ListTagsResponse{Tags: make([]*blogv1.Tag, 0, len(tags))}
+	for i := range tags {
+		resp.Tags = append(resp.Tags, tagMessage(&tags[i]))
+	}
+	return resp, nil
+}
+
+func (s *tagServer) CreateTag(ctx context.Context, req *blogv1.CreateTagRequest) (*blogv1.Tag, error) {
+	if err := requireID("post_id", req.PostId); err != nil {
+		return nil, err
+	}
+	request := &dto.Tag{Name: req.Name}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	c := usecaseContext(ctx)
+	created, err := s.tagsUsecase.CreateTag(c, req.PostId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	tag, err := s.tagsUsecase.GetTagById(c, created.ID, req.PostId)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return tagMessage(tag), nil
+}
+
+func (s *tagServer) UpdateTag(ctx context.Context, req *blogv1.UpdateTagRequest) (*blogv1.Tag, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+	request := &dto.UpdateTagsBodyRequest{Name: req.Name}
+	if err := validate(request); err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagsUsecase.UpdateTags(usecaseContext(ctx), req.Id, req.PostId, request)
+	if err != nil {
+		return nil, usecaseError(err)
+	}
+	return tagMessage(tag), nil
+}
+
+func (s *tagServer) DeleteTag(ctx context.Context, req *blogv1.DeleteTagRequest) (*emptypb.Empty, error) {
+	if err := requireChildIDs(req.Id, req.PostId); err != nil {
+		return nil, err
+	}
+
+	if err := s.tagsUsecase.DeleteTags(usecaseContext(ctx), req.Id, req.PostId); err != nil {
+		return nil, usecaseError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// requireChildIDs checks the ids of a tag or comment and of its post.
+func requireChildIDs(id, postID int64) error {
+	if err := requireID("id", id); err != nil {
+		return err
+	}
+	return requireID("post_id", postID)
+}
+
+func tagMessage(tag *dto.Tag) *blogv1.Tag {
+	return &blogv1.Tag{
+		Id:        tag.ID,
+		PostId:    tag.PostID,
+		Name:      tag.Name,
+		CreatedAt: timestamppb.New(tag.CreatedAt),
+		UpdatedAt: timestamppb.New(tag.UpdatedAt),
+		Version:   tag.Version,
+	}
+}
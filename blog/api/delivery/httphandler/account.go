@@ -0,0 +1,280 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
+)
+
+type accountHandler struct {
+	accountUsecase interfaces.AccountUsecase
+}
+
+func NewAccountHandler(g *gin.RouterGroup, a interfaces.AccountUsecase) {
+	register(g, &accountHandler{accountUsecase: a}, accountRoutes)
+}
+
+var accountRoutes = []route[*accountHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "user/:user_id/send-verification", ID: "sendVerification", Tag: "accounts",
+			Summary:     "Email a link verifying the email of a user",
+			Description: "The link is sent on registration and when the email changes already; this sends another one. The links of the previous emails keep working until they expire.",
+			Params:      dto.SendVerificationRequest{},
+			Status:      http.StatusAccepted,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		handle: (*accountHandler).SendVerificationHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "verify-email", ID: "verifyEmail", Tag: "accounts",
+			Summary:     "Verify the email of a user with the token of a verification link",
+			Description: "The token works once, before it expires, and only while the user keeps the email it was sent to.",
+			Body:        dto.VerifyEmailRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.User{},
+			Errors:      []int{http.StatusBadRequest},
+		},
+		handle: (*accountHandler).VerifyEmailHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "password-reset", ID: "requestPasswordReset", Tag: "accounts",
+			Summary:     "Email a password reset link",
+			Description: "Accepted whether a user has the email or not, which is not told.",
+			Body:        dto.PasswordResetRequest{},
+			Status:      http.StatusAccepted,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		handle: (*accountHandler).RequestPasswordResetHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "password-reset/confirm", ID: "resetPassword", Tag: "accounts",
+			Summary:     "Choose a new password with the token of a reset link",
+			Description: "The token works once, before it expires. The other reset links of the user stop working.",
+			Body:        dto.ConfirmPasswordResetRequest{},
+			Status:      http.StatusNoContent,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		handle: (*accountHandler).ResetPasswordHandler,
+	},
+}
+
+func (s *accountHandler) SendVerificationHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.SendVerificationRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	err := s.accountUsecase.SendVerification(ctx, req.UserID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusAccepted),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   0,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusAccepted)
+	return
+}
+
+func (s *accountHandler) VerifyEmailHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.VerifyEmailRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	user, err := s.accountUsecase.VerifyEmail(ctx, req.Token)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: user,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *accountHandler) RequestPasswordResetHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.PasswordResetRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	err := s.accountUsecase.RequestPasswordReset(ctx, req.Email)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusAccepted),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   0,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusAccepted)
+	return
+}
+
+func (s *accountHandler) ResetPasswordHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.ConfirmPasswordResetRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	err := s.accountUsecase.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusNoContent),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   0,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
+	return
+}
@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,19 +10,103 @@ import (
 	"blog/domain/dto"
 	"blog/domain/interfaces"
 	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
 )
 
 type userHandler struct {
 	userUsecase interfaces.UserUsecase
 }
 
-func NewUserHandler(e *gin.Engine, a interfaces.UserUsecase) {
-	handler := userHandler{userUsecase: a}
-	e.GET("api/user/:user_id", handler.GetUserByIdHandler)
-	e.GET("api/users", handler.GetUsersHandler)
-	e.POST("api/create-user", handler.CreateUserHandler)
-	e.PUT("api/user/:user_id", handler.UpdateUserHandler)
-	e.DELETE("api/user/:user_id", handler.DeleteUserHandler)
+func NewUserHandler(g *gin.RouterGroup, a interfaces.UserUsecase) {
+	register(g, &userHandler{userUsecase: a}, userRoutes)
+}
+
+var userRoutes = []route[*userHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "user/:user_id", ID: "getUserById", Tag: "users",
+			Summary:     "Find a user by ID",
+			Params:      dto.GetUserByIDRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.User{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*userHandler).GetUserByIdHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "users", ID: "getUsers", Tag: "users",
+			Summary:     "List the first 100 users",
+			Status:      http.StatusOK,
+			Data:        []dto.User{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		handle: (*userHandler).GetUsersHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "create-user", ID: "createUser", Tag: "users",
+			Summary: "Create a user",
+			Body:    dto.CreateUserBodyRequest{},
+			Status:  http.StatusCreated,
+			Data:    dto.CreateUserResponse{},
+			Errors:  []int{http.StatusBadRequest, http.StatusConflict},
+		},
+		handle: (*userHandler).CreateUserHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPut, Path: "user/:user_id", ID: "updateUser", Tag: "users",
+			Summary:     "Update a user",
+			Description: "Sets the fields of the body that are not empty. Changing the email resets its verification and takes the current password of users who have one.",
+			Params:      dto.UpdateUserRequest{},
+			Body:        dto.UpdateUserBodyRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.User{},
+			Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+		},
+		handle: (*userHandler).UpdateUserHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPatch, Path: "user/:user_id", ID: "patchUser", Tag: "users",
+			Summary:         "Apply a JSON merge patch to a user",
+			Params:          dto.UpdateUserRequest{},
+			Headers:         []openapi.Header{openapi.IfMatch},
+			Body:            openapi.MergePatch(dto.UserPatch{}),
+			Status:          http.StatusOK,
+			Data:            dto.User{},
+			ResponseHeaders: []openapi.Header{openapi.ETag},
+			Errors:          []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType},
+		},
+		handle: (*userHandler).PatchUserHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodDelete, Path: "user/:user_id", ID: "deleteUser", Tag: "users",
+			Summary:     "Delete a user",
+			Description: "Soft deletes the user. Its posts and comments are deleted, reassigned to the ghost user or block the deletion depending on the cascade mode.",
+			Params:      dto.DeleteUserRequest{},
+			Status:      http.StatusNoContent,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		handle: (*userHandler).DeleteUserHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "user/:user_id/profile", ID: "getUserProfile", Tag: "users",
+			Summary:     "Get the public profile of a user",
+			Description: "Returns the profile of the user without their email, their stats and a page of their posts, newest first.",
+			Params:      dto.GetProfileRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.Profile{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*userHandler).GetProfileHandler,
+	},
 }
 
 func (s *userHandler) GetUserByIdHandler(ctx *gin.Context) {
@@ -41,25 +124,26 @@ func (s *userHandler) GetUserByIdHandler(ctx *gin.Context) {
 	req := new(dto.GetUserByIDRequest)
 
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	user, err := s.userUsecase.GetUserById(ctx, req.UserID)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	if httputil.NotModified(ctx.Writer, ctx.Request, user.ETag(), user.UpdatedAt) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: user,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -68,6 +152,7 @@ func (s *userHandler) GetUserByIdHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -78,7 +163,7 @@ func (s *userHandler) GetUserByIdHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -96,11 +181,7 @@ func (s *userHandler) GetUsersHandler(ctx *gin.Context) {
 
 	req := new(dto.GetUsers)
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 	if req.LastIdx == 0 {
@@ -129,7 +210,12 @@ func (s *userHandler) GetUsersHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	tag, lastModified := usersValidators(*users)
+	if httputil.NotModified(ctx.Writer, ctx.Request, tag, lastModified) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: users,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -138,6 +224,7 @@ func (s *userHandler) GetUsersHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -149,7 +236,7 @@ func (s *userHandler) GetUsersHandler(ctx *gin.Context) {
 		return
 	}
 
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -165,17 +252,46 @@ func (s *userHandler) CreateUserHandler(ctx *gin.Context) {
 		}
 	}()
 
-	req := new(dto.User)
+	req := new(dto.CreateUserBodyRequest)
 	if err := ctx.ShouldBindJSON(&req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	user := &dto.User{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Website:     req.Website,
+		SocialLinks: req.SocialLinks,
+		Password:    req.Password,
+	}
+	if req.Email != "" {
+		user.Email = &req.Email
+	}
+	resp, err := s.userUsecase.CreateUser(ctx, user)
+	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	resp, err := s.userUsecase.CreateUser(ctx, req)
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: resp,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
 	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusInternalServerError),
@@ -184,16 +300,55 @@ func (s *userHandler) CreateUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusCreated)
+	return
+}
+
+func (s *userHandler) UpdateUserHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.UpdateUserRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	reqBody := new(dto.UpdateUserBodyRequest)
+	if err := ctx.ShouldBind(&reqBody); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	resp, err := s.userUsecase.UpdateUser(ctx, req.UserID, reqBody)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
 		},
 		Header: &httputil.StandardHeader{
-			TotalData:   1,
+			TotalData:   0,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -204,11 +359,11 @@ func (s *userHandler) CreateUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusCreated)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
-func (s *userHandler) UpdateUserHandler(ctx *gin.Context) {
+func (s *userHandler) PatchUserHandler(ctx *gin.Context) {
 	var (
 		startTime = time.Now()
 		httpError *httputil.StandardError
@@ -221,17 +376,22 @@ func (s *userHandler) UpdateUserHandler(ctx *gin.Context) {
 	}()
 
 	req := new(dto.UpdateUserRequest)
-	if err := ctx.BindUri(req); err != nil {
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	if !isMergePatch(ctx) {
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
+			Code:   strconv.Itoa(http.StatusUnsupportedMediaType),
+			Title:  http.StatusText(http.StatusUnsupportedMediaType),
+			Detail: "expected an application/merge-patch+json body",
 		}
 		return
 	}
 
-	reqBody := new(dto.UpdateUserBodyRequest)
-	if err := ctx.Bind(&reqBody); err != nil {
+	patch, err := ctx.GetRawData()
+	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusBadRequest),
 			Title:  http.StatusText(http.StatusBadRequest),
@@ -240,25 +400,23 @@ func (s *userHandler) UpdateUserHandler(ctx *gin.Context) {
 		return
 	}
 
-	resp, err := s.userUsecase.UpdateUser(ctx, req.UserID, reqBody)
+	resp, err := s.userUsecase.PatchUser(ctx, req.UserID, patch, ctx.GetHeader("If-Match"))
 	if err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
-			Detail: err.Error(),
-		}
+		status, errs := usecaseErrors(err)
+		httputil.WriteErrorResponse(ctx.Writer, status, errs)
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
 		},
 		Header: &httputil.StandardHeader{
-			TotalData:   0,
+			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -269,7 +427,8 @@ func (s *userHandler) UpdateUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	ctx.Header("ETag", resp.ETag())
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -286,14 +445,31 @@ func (s *userHandler) DeleteUserHandler(ctx *gin.Context) {
 	}()
 	req := new(dto.DeleteUserRequest)
 	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	err := s.userUsecase.DeleteUser(ctx, req.UserID)
+	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
-	err := s.userUsecase.DeleteUser(ctx, req.UserID)
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
 	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusInternalServerError),
@@ -302,8 +478,48 @@ func (s *userHandler) DeleteUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
+	return
+}
+
+func (s *userHandler) GetProfileHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.GetProfileRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+
+	profile, err := s.userUsecase.GetProfile(ctx, req.UserID, req.Page)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: profile,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
@@ -311,6 +527,7 @@ func (s *userHandler) DeleteUserHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -321,6 +538,6 @@ func (s *userHandler) DeleteUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusNoContent)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
@@ -0,0 +1,71 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/utils/cache"
+	"blog/utils/httputil"
+	"blog/utils/openapi"
+)
+
+type cacheHandler struct {
+	stats *cache.Stats
+}
+
+func NewCacheHandler(g *gin.RouterGroup, stats *cache.Stats) {
+	register(g, &cacheHandler{stats: stats}, cacheRoutes)
+}
+
+var cacheRoutes = []route[*cacheHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "cache/stats", ID: "getCacheStats", Tag: "cache",
+			Summary: "Read cache hits and misses per namespace",
+			Status:  http.StatusOK,
+			Data:    map[string]cache.Counts{},
+		},
+		handle: (*cacheHandler).GetCacheStatsHandler,
+	},
+}
+
+func (s *cacheHandler) GetCacheStatsHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	stats := s.stats.Snapshot()
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: stats,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   len(stats),
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
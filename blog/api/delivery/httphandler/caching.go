@@ -0,0 +1,41 @@
+package httphandler
+
+import (
+	"time"
+
+	"blog/domain/dto"
+	"blog/utils/etag"
+)
+
+// postsValidators derives the ETag and Last-Modified of a page of posts from the
+// posts it contains, including their embedded authors and tags.
+func postsValidators(posts []dto.Post) (string, time.Time) {
+	parts := []interface{}{"posts"}
+	var lastModified time.Time
+	for i := range posts {
+		tag, modified := posts[i].Validators()
+		parts = append(parts, tag)
+		lastModified = latest(lastModified, modified)
+	}
+	return etag.Strong(parts...), lastModified
+}
+
+// usersValidators derives the ETag and Last-Modified of a page of users.
+func usersValidators(users []dto.User) (string, time.Time) {
+	parts := []interface{}{"users"}
+	var lastModified time.Time
+	for i := range users {
+		parts = append(parts, users[i].ETag())
+		lastModified = latest(lastModified, users[i].UpdatedAt)
+	}
+	return etag.Strong(parts...), lastModified
+}
+
+func latest(t time.Time, others ...time.Time) time.Time {
+	for _, o := range others {
+		if o.After(t) {
+			t = o
+		}
+	}
+	return t
+}
@@ -0,0 +1,83 @@
+package httphandler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+
+	"blog/api/delivery/httphandler"
+	"blog/api/usecase"
+	"blog/domain/dto"
+	"blog/utils/cache"
+	"blog/utils/validation"
+)
+
+// TestWritesMatchReads checks that the ETag of a read is the one the writes check their
+// If-Match against, and that a write answers the ETag the next read has.
+func TestWritesMatchReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "blog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.LogMode(false)
+	conn.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{})
+	conn.Create(&dto.User{Name: "ada"})
+	conn.Create(&dto.Post{Title: "notes", Content: "on the analytical engine", AuthorID: 1, TagsID: 1})
+	conn.Create(&dto.Tag{Name: "engines", PostID: 1})
+	conn.Create(&dto.Comment{Name: "charles", Body: "splendid", PostID: 1})
+
+	// reads go through a cache, so a write must drop what it makes stale
+	readCache := usecase.NewReadCache(cache.NewLRU(100), time.Minute, cache.NewStats())
+	r := gin.New()
+	api := r.Group("/api/v1")
+	httphandler.NewPostHandler(api, usecase.NewPostUsecase(conn, dto.CascadeDelete, readCache))
+	httphandler.NewTagsHandler(api, usecase.NewTagsUsecase(conn, readCache))
+	httphandler.NewCommentsHandler(api, usecase.NewCommentsUsecase(conn, readCache))
+	do := func(method, path, header, value, body string, status int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+		}
+		return w
+	}
+
+	for _, tt := range []struct {
+		path, patch, again string
+	}{
+		{"/api/v1/user/1/post/1", `{"title":"sketch"}`, `{"title":"notes"}`},
+		{"/api/v1/post/1/tags/1", `{"name":"machines"}`, `{"name":"engines"}`},
+		{"/api/v1/post/1/comments/1", `{"body":"most splendid"}`, `{"body":"splendid"}`},
+	} {
+		read := do(http.MethodGet, tt.path, "", "", "", http.StatusOK).Header().Get("ETag")
+		do(http.MethodGet, tt.path, "If-None-Match", read, "", http.StatusNotModified)
+
+		written := do(http.MethodPatch, tt.path, "If-Match", read, tt.patch, http.StatusOK).Header().Get("ETag")
+		if written == "" || written == read {
+			t.Errorf("PATCH %s answered the ETag %q, want a new one", tt.path, written)
+		}
+		do(http.MethodGet, tt.path, "If-None-Match", written, "", http.StatusNotModified)
+		do(http.MethodPatch, tt.path, "If-Match", read, tt.again, http.StatusPreconditionFailed)
+		do(http.MethodPatch, tt.path, "If-Match", written, tt.again, http.StatusOK)
+	}
+}
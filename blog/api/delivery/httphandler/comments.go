@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,18 +10,80 @@ import (
 	"blog/domain/dto"
 	"blog/domain/interfaces"
 	"blog/utils/httputil"
+	"blog/utils/metrics"
+	"blog/utils/openapi"
+	"blog/utils/validation"
 )
 
 type commentsHandler struct {
 	commentsUsecase interfaces.CommentsUsecase
 }
 
-func NewCommentsHandler(e *gin.Engine, a interfaces.CommentsUsecase) {
-	handler := commentsHandler{commentsUsecase: a}
-	e.GET("api/post/:post_id/comments/:comment_id", handler.GetCommentByIdHandler)
-	e.POST("api/post/:post_id/add-comment", handler.CreateCommentsHandler)
-	e.PUT("api/post/:post_id/comments/:comment_id", handler.UpdateCommentsHandler)
-	e.DELETE("api/post/:post_id/comments/:comment_id", handler.DeleteCommentsHandler)
+func NewCommentsHandler(g *gin.RouterGroup, a interfaces.CommentsUsecase) {
+	register(g, &commentsHandler{commentsUsecase: a}, commentsRoutes)
+}
+
+var commentsRoutes = []route[*commentsHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "post/:post_id/comments/:comment_id", ID: "getCommentById", Tag: "comments",
+			Summary:     "Find a comment of a post by ID",
+			Params:      dto.GetCommentByIDRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.Comment{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*commentsHandler).GetCommentByIdHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "post/:post_id/add-comment", ID: "createComment", Tag: "comments",
+			Summary: "Comment a post",
+			Params:  dto.CreateCommentsRequest{},
+			Body:    dto.Comment{},
+			Status:  http.StatusCreated,
+			Data:    dto.CreateCommentsResponse{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*commentsHandler).CreateCommentsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPut, Path: "post/:post_id/comments/:comment_id", ID: "updateComment", Tag: "comments",
+			Summary: "Update a comment",
+			Params:  dto.UpdateCommentsRequest{},
+			Body:    dto.UpdateCommentsBodyRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Comment{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*commentsHandler).UpdateCommentsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPatch, Path: "post/:post_id/comments/:comment_id", ID: "patchComment", Tag: "comments",
+			Summary:         "Apply a JSON merge patch to a comment",
+			Params:          dto.UpdateCommentsRequest{},
+			Headers:         []openapi.Header{openapi.IfMatch},
+			Body:            openapi.MergePatch(dto.CommentPatch{}),
+			Status:          http.StatusOK,
+			Data:            dto.Comment{},
+			ResponseHeaders: []openapi.Header{openapi.ETag},
+			Errors:          []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType},
+		},
+		handle: (*commentsHandler).PatchCommentsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodDelete, Path: "post/:post_id/comments/:comment_id", ID: "deleteComment", Tag: "comments",
+			Summary: "Delete a comment",
+			Params:  dto.DeleteCommentRequest{},
+			Status:  http.StatusNoContent,
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*commentsHandler).DeleteCommentsHandler,
+	},
 }
 
 func (s *commentsHandler) GetCommentByIdHandler(ctx *gin.Context) {
@@ -40,25 +101,27 @@ func (s *commentsHandler) GetCommentByIdHandler(ctx *gin.Context) {
 	req := new(dto.GetCommentByIDRequest)
 
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	comment, err := s.commentsUsecase.GetCommentById(ctx, req.CommentID, req.PostID)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	tag, lastModified := comment.Validators()
+	if httputil.NotModified(ctx.Writer, ctx.Request, tag, lastModified) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: comment,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -67,6 +130,7 @@ func (s *commentsHandler) GetCommentByIdHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -77,7 +141,7 @@ func (s *commentsHandler) GetCommentByIdHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -94,26 +158,20 @@ func (s *commentsHandler) CreateCommentsHandler(ctx *gin.Context) {
 	}()
 	req := new(dto.CreateCommentsRequest)
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.Comment)
 	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		metrics.CommentRejected(metrics.RejectedInvalid)
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	resp, err := s.commentsUsecase.CreateComment(ctx, req.PostID, reqBody)
 	if err != nil {
+		metrics.CommentRejected(metrics.RejectedError)
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusInternalServerError),
 			Title:  http.StatusText(http.StatusInternalServerError),
@@ -122,7 +180,7 @@ func (s *commentsHandler) CreateCommentsHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -131,6 +189,7 @@ func (s *commentsHandler) CreateCommentsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -141,7 +200,7 @@ func (s *commentsHandler) CreateCommentsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusCreated)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusCreated)
 	return
 }
 
@@ -158,36 +217,29 @@ func (s *commentsHandler) UpdateCommentsHandler(ctx *gin.Context) {
 	}()
 
 	req := new(dto.UpdateCommentsRequest)
-	if err := ctx.BindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.UpdateCommentsBodyRequest)
-	if err := ctx.Bind(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBind(&reqBody); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	resp, err := s.commentsUsecase.UpdateComments(ctx, req.CommentID, req.PostID, reqBody)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -196,6 +248,7 @@ func (s *commentsHandler) UpdateCommentsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   0,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -206,11 +259,11 @@ func (s *commentsHandler) UpdateCommentsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
-func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
+func (s *commentsHandler) PatchCommentsHandler(ctx *gin.Context) {
 	var (
 		startTime = time.Now()
 		httpError *httputil.StandardError
@@ -221,8 +274,24 @@ func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
 			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
 		}
 	}()
-	req := new(dto.DeleteCommentRequest)
+
+	req := new(dto.UpdateCommentsRequest)
 	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	if !isMergePatch(ctx) {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusUnsupportedMediaType),
+			Title:  http.StatusText(http.StatusUnsupportedMediaType),
+			Detail: "expected an application/merge-patch+json body",
+		}
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusBadRequest),
 			Title:  http.StatusText(http.StatusBadRequest),
@@ -230,6 +299,56 @@ func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
 		}
 		return
 	}
+
+	resp, err := s.commentsUsecase.PatchComment(ctx, req.CommentID, req.PostID, patch, ctx.GetHeader("If-Match"))
+	if err != nil {
+		status, errs := usecaseErrors(err)
+		httputil.WriteErrorResponse(ctx.Writer, status, errs)
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: resp,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	tag, _ := resp.Validators()
+	ctx.Header("ETag", tag)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+	req := new(dto.DeleteCommentRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
 	err := s.commentsUsecase.DeleteComments(ctx, req.CommentID, req.PostID)
 	if err != nil {
 		httpError = &httputil.StandardError{
@@ -240,7 +359,7 @@ func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
@@ -248,6 +367,7 @@ func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -258,6 +378,6 @@ func (s *commentsHandler) DeleteCommentsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusNoContent)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
 	return
 }
@@ -0,0 +1,547 @@
+package httphandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+
+	"blog/api/delivery/httphandler"
+	"blog/api/middleware"
+	"blog/api/usecase"
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/cache"
+	"blog/utils/mail"
+	"blog/utils/storage"
+	"blog/utils/validation"
+)
+
+func init() {
+	// merge patches are JSON documents
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.RegisteredBodyDecoder("application/json"))
+}
+
+// maxMediaSize is the size limit of the uploads of the contract.
+const maxMediaSize = 1024
+
+// maxMediaPixels lets testPNG through and nothing larger.
+const maxMediaPixels = 32 * 24
+
+// contract serves the API on a fresh database and checks every exchange against the OpenAPI
+// description of the handlers.
+type contract struct {
+	t       *testing.T
+	handler http.Handler
+	doc     *openapi3.T
+	router  routers.Router
+	covered map[string]bool
+	// media processes the uploaded images when the test asks for it
+	media interfaces.MediaProcessor
+	// mailDir receives the emails sent to the users
+	mailDir string
+}
+
+func newContract(t *testing.T) *contract {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "blog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{}, &dto.Media{}, &dto.MediaVariant{}, &dto.AccountToken{})
+	mediaStorage, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailDir := t.TempDir()
+	mailer, err := mail.NewFile(mailDir, "Blog <blog@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := usecase.NewAccountUsecase(conn, mailer, dto.AccountSettings{
+		SiteTitle: "Blog",
+		VerifyURL: "http://blog.test/verify-email",
+		ResetURL:  "http://blog.test/reset-password",
+		VerifyTTL: time.Hour,
+		ResetTTL:  time.Hour,
+	}, nil)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.ContentNegotiation("/api/"))
+	g := r.Group(httphandler.BasePath)
+	httphandler.NewCacheHandler(g, cache.NewStats())
+	httphandler.NewUserHandler(g, usecase.NewUserUsecase(conn, dto.CascadeDelete, nil, accounts))
+	httphandler.NewAccountHandler(g, accounts)
+	httphandler.NewTagsHandler(g, usecase.NewTagsUsecase(conn, nil))
+	httphandler.NewPostHandler(g, usecase.NewPostUsecase(conn, dto.CascadeDelete, nil))
+	httphandler.NewCommentsHandler(g, usecase.NewCommentsUsecase(conn, nil))
+	httphandler.NewTrashHandler(g, usecase.NewTrashUsecase(conn, nil))
+	media := usecase.NewMediaProcessor(conn, mediaStorage, dto.ImageVariants{
+		Widths:        []int{8, 16, 64},
+		ThumbnailSize: 4,
+		Formats:       []string{"webp", "jpeg"},
+		Quality:       80,
+	}, 1)
+	httphandler.NewMediaHandler(g, usecase.NewMediaUsecase(conn, mediaStorage, dto.MediaLimits{
+		MaxSize:      maxMediaSize,
+		AllowedTypes: []string{"image/png"},
+		MaxPixels:    maxMediaPixels,
+	}, media), maxMediaSize)
+
+	doc := loadSpec(t)
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &contract{t: t, handler: r, doc: doc, router: router, covered: map[string]bool{}, media: media, mailDir: mailDir}
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	generated, err := httphandler.OpenAPI(httphandler.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := generated.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+// do sends a request, checks it and its response against the description and fails unless
+// the response has the expected status.
+func (c *contract) do(method, path string, header http.Header, body string, status int) *httptest.ResponseRecorder {
+	c.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	route, params, err := c.router.FindRoute(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: not described: %v", method, path, err)
+	}
+	c.covered[route.Operation.OperationID] = true
+
+	ctx := context.Background()
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	input := &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route, Options: options}
+	// bodies the handlers reject on purpose are left to them
+	if status < http.StatusBadRequest {
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			c.t.Fatalf("%s %s: request does not match the description: %v", method, path, err)
+		}
+	}
+	// ValidateRequest consumed the body
+	req.Body = io.NopCloser(strings.NewReader(body))
+
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, req)
+	if w.Code != status {
+		c.t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+	}
+
+	options.IncludeResponseStatus = true
+	// the other encodings share the schemas of the JSON one, only their media type is checked
+	options.ExcludeResponseBody = w.Body.Len() > 0 && !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	if err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options:                options,
+	}); err != nil {
+		c.t.Fatalf("%s %s: response does not match the description: %v\n%s", method, path, err, w.Body)
+	}
+	return w
+}
+
+func TestContract(t *testing.T) {
+	c := newContract(t)
+	const v1 = httphandler.BasePath
+	h := func(kv ...string) http.Header {
+		header := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			header.Set(kv[i], kv[i+1])
+		}
+		return header
+	}
+
+	// users
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":"ada"}`, http.StatusCreated)
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":" "}`, http.StatusBadRequest)
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":"Ghost"}`, http.StatusConflict)
+	user := c.do(http.MethodGet, v1+"/user/1", nil, "", http.StatusOK)
+	c.do(http.MethodGet, v1+"/user/1", h("If-None-Match", user.Header().Get("ETag")), "", http.StatusNotModified)
+	c.do(http.MethodGet, v1+"/user/x", nil, "", http.StatusBadRequest)
+	c.do(http.MethodGet, v1+"/user/9", nil, "", http.StatusNotFound)
+	c.do(http.MethodGet, v1+"/users", nil, "", http.StatusOK)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"name":"ada lovelace"}`, http.StatusOK)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"name":"ghost"}`, http.StatusConflict)
+	c.do(http.MethodPut, v1+"/user/9", nil, `{"name":"nobody"}`, http.StatusNotFound)
+	c.do(http.MethodPatch, v1+"/user/1", h("Content-Type", "application/merge-patch+json", "If-Match", user.Header().Get("ETag")), `{"name":"lovelace"}`, http.StatusPreconditionFailed)
+	c.do(http.MethodPatch, v1+"/user/1", h("Content-Type", "text/plain"), `name`, http.StatusUnsupportedMediaType)
+	c.do(http.MethodPatch, v1+"/user/1", h("Content-Type", "application/merge-patch+json"), `{"name":"lovelace"}`, http.StatusOK)
+	c.do(http.MethodPatch, v1+"/user/9", h("Content-Type", "application/merge-patch+json"), `{"name":"nobody"}`, http.StatusNotFound)
+
+	// posts
+	c.do(http.MethodPost, v1+"/user/1/create-post", nil, `{"title":"notes","content":"on the analytical engine","tags":["math"]}`, http.StatusCreated)
+	post := c.do(http.MethodGet, v1+"/user/1/post/1", nil, "", http.StatusOK)
+	c.do(http.MethodGet, v1+"/user/1/post/1", h("If-None-Match", post.Header().Get("ETag")), "", http.StatusNotModified)
+	c.do(http.MethodGet, v1+"/user/1/post/9", nil, "", http.StatusNotFound)
+	c.do(http.MethodGet, v1+"/posts", nil, "", http.StatusOK)
+	c.do(http.MethodPut, v1+"/user/1/post/1", nil, `{"content":"on the difference engine"}`, http.StatusOK)
+	c.do(http.MethodPut, v1+"/user/1/post/9", nil, `{"content":"on nothing"}`, http.StatusNotFound)
+	c.do(http.MethodPatch, v1+"/user/1/post/1", h("Content-Type", "application/merge-patch+json"), `{"title":"more notes"}`, http.StatusOK)
+
+	// tags
+	tagPath := v1 + "/post/1/tags/" + createdID(t, c.do(http.MethodPost, v1+"/post/1/create-tag", nil, `{"name":"history"}`, http.StatusCreated))
+	tag := c.do(http.MethodGet, tagPath, nil, "", http.StatusOK)
+	c.do(http.MethodGet, tagPath, h("If-None-Match", tag.Header().Get("ETag")), "", http.StatusNotModified)
+	c.do(http.MethodGet, v1+"/post/1/tags/9", nil, "", http.StatusNotFound)
+	c.do(http.MethodPut, tagPath, nil, `{"name":"computing"}`, http.StatusOK)
+	c.do(http.MethodPut, v1+"/post/1/tags/9", nil, `{"name":"nothing"}`, http.StatusNotFound)
+	c.do(http.MethodPatch, tagPath, h("Content-Type", "application/merge-patch+json"), `{"name":"engines"}`, http.StatusOK)
+
+	// comments
+	c.do(http.MethodPost, v1+"/post/1/add-comment", nil, `{"name":"babbage","body":"splendid"}`, http.StatusCreated)
+	comment := c.do(http.MethodGet, v1+"/post/1/comments/1", nil, "", http.StatusOK)
+	c.do(http.MethodGet, v1+"/post/1/comments/1", h("If-None-Match", comment.Header().Get("ETag")), "", http.StatusNotModified)
+	c.do(http.MethodGet, v1+"/post/1/comments/9", nil, "", http.StatusNotFound)
+	c.do(http.MethodPut, v1+"/post/1/comments/1", nil, `{"body":"most splendid"}`, http.StatusOK)
+	c.do(http.MethodPut, v1+"/post/1/comments/9", nil, `{"body":"nothing"}`, http.StatusNotFound)
+	c.do(http.MethodPatch, v1+"/post/1/comments/1", h("Content-Type", "application/merge-patch+json"), `{"name":"charles"}`, http.StatusOK)
+
+	// media
+	diagram := testPNG(t)
+	c.upload(v1+"/user/1/upload-media", "diagram.png", diagram, "", http.StatusCreated)
+	c.upload(v1+"/user/1/upload-media", "engine.png", diagram, "1", http.StatusCreated)
+	c.upload(v1+"/user/1/upload-media", "notes.png", "plain text", "", http.StatusUnsupportedMediaType)
+	c.upload(v1+"/user/1/upload-media", "broken.png", "\x89PNG\r\n\x1a\n"+strings.Repeat("\x00", 64), "", http.StatusUnsupportedMediaType)
+	c.upload(v1+"/user/1/upload-media", "large.png", diagram+strings.Repeat("\x00", maxMediaSize), "", http.StatusRequestEntityTooLarge)
+	c.upload(v1+"/user/1/upload-media", "huge.png", hugePNG(t), "", http.StatusUnsupportedMediaType)
+	c.upload(v1+"/user/9/upload-media", "diagram.png", diagram, "", http.StatusNotFound)
+	c.do(http.MethodPost, v1+"/user/1/upload-media", h("Content-Type", "multipart/form-data; boundary=x"), "--x--", http.StatusBadRequest)
+	c.do(http.MethodGet, v1+"/media/2", nil, "", http.StatusOK)
+	if err := c.media.Process(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	processed := c.do(http.MethodGet, v1+"/media/2", nil, "", http.StatusOK)
+	var envelope struct {
+		Data dto.Media `json:"data"`
+	}
+	if err := json.Unmarshal(processed.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	// the thumbnail and the 8 and 16 pixels wide variants, in both formats
+	if m := envelope.Data; m.Status != dto.MediaReady || len(m.Variants) != 6 || m.Blurhash == "" || m.DominantColor == "" {
+		t.Errorf("processed media: %+v", m)
+	}
+	c.do(http.MethodGet, v1+"/media/9", nil, "", http.StatusNotFound)
+	c.do(http.MethodGet, v1+"/user/1/media", nil, "", http.StatusOK)
+	c.do(http.MethodGet, v1+"/post/1/media", nil, "", http.StatusOK)
+	c.do(http.MethodDelete, v1+"/user/1/media/1", nil, "", http.StatusNoContent)
+	c.do(http.MethodDelete, v1+"/user/1/media/1", nil, "", http.StatusNotFound)
+
+	// profiles
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":"babbage","email":"Charles@example.com"}`, http.StatusCreated)
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":"charles","email":"charles@example.com"}`, http.StatusConflict)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"display_name":"Ada Lovelace","bio":"Mathematician","website":"https://example.com/ada","social_links":[{"network":"github","url":"https://github.com/ada"}],"avatar_id":2,"email":"Ada@example.com"}`, http.StatusOK)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"website":"javascript:alert(1)"}`, http.StatusBadRequest)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"social_links":[{"network":"github"}]}`, http.StatusBadRequest)
+	c.do(http.MethodPut, v1+"/user/1", nil, `{"email":"CHARLES@example.com"}`, http.StatusConflict)
+	c.do(http.MethodPut, v1+"/user/2", nil, `{"avatar_id":2}`, http.StatusBadRequest)
+	c.do(http.MethodPatch, v1+"/user/1", h("Content-Type", "application/merge-patch+json"), `{"bio":null}`, http.StatusOK)
+	profile := c.do(http.MethodGet, v1+"/user/1/profile", nil, "", http.StatusOK)
+	var profileEnvelope struct {
+		Data dto.Profile `json:"data"`
+	}
+	if err := json.Unmarshal(profile.Body.Bytes(), &profileEnvelope); err != nil {
+		t.Fatal(err)
+	}
+	if p := profileEnvelope.Data; p.DisplayName != "Ada Lovelace" || p.Bio != "" || p.Avatar == nil || len(p.SocialLinks) != 1 ||
+		p.EmailVerified || p.Stats.Posts != 1 || p.Stats.Comments != 1 || p.Stats.LastPostAt == nil || len(p.Posts) != 1 {
+		t.Errorf("profile: %+v", p)
+	}
+	// the emails are only ever received
+	for _, path := range []string{"/user/1/profile", "/user/1", "/users", "/posts", "/user/1/post/1"} {
+		if body := c.do(http.MethodGet, v1+path, nil, "", http.StatusOK).Body.String(); strings.Contains(body, "@example.com") || strings.Contains(body, `"email"`) {
+			t.Errorf("%s shows the email of the user: %s", path, body)
+		}
+	}
+	c.do(http.MethodGet, v1+"/user/1/profile?page=x", nil, "", http.StatusBadRequest)
+	c.do(http.MethodGet, v1+"/user/9/profile", nil, "", http.StatusNotFound)
+	c.do(http.MethodDelete, v1+"/user/1/media/2", nil, "", http.StatusConflict)
+
+	// email verification and password reset
+	verify := fmt.Sprintf(`{"token":%q}`, c.mailedToken("ada@example.com"))
+	c.do(http.MethodPost, v1+"/verify-email", nil, verify, http.StatusOK)
+	c.do(http.MethodPost, v1+"/verify-email", nil, verify, http.StatusBadRequest)
+	c.do(http.MethodPost, v1+"/verify-email", nil, `{"token":"forged"}`, http.StatusBadRequest)
+	c.do(http.MethodPost, v1+"/user/1/send-verification", nil, "", http.StatusConflict)
+	c.do(http.MethodPost, v1+"/user/2/send-verification", nil, "", http.StatusAccepted)
+	c.do(http.MethodPost, v1+"/user/9/send-verification", nil, "", http.StatusNotFound)
+	// resets only go to the verified emails of the users with a password
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"nobody@example.com"}`, http.StatusAccepted)
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"ada@example.com"}`, http.StatusAccepted)
+	c.do(http.MethodPost, v1+"/create-user", nil, `{"name":"grace","email":"grace@example.com","password":"flow-matic"}`, http.StatusCreated)
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"grace@example.com"}`, http.StatusAccepted)
+	if n := len(c.mails("ada@example.com")); n != 1 {
+		t.Errorf("%d emails to a verified email without a password, want the verification only", n)
+	}
+	if n := len(c.mails("grace@example.com")); n != 1 {
+		t.Errorf("%d emails to an unverified email, want the verification only", n)
+	}
+	c.do(http.MethodPost, v1+"/verify-email", nil, fmt.Sprintf(`{"token":%q}`, c.mailedToken("grace@example.com")), http.StatusOK)
+
+	// nobody but the user can move their email, and with it the reset links, elsewhere
+	c.do(http.MethodPut, v1+"/user/3", nil, `{"email":"mallory@example.com"}`, http.StatusForbidden)
+	c.do(http.MethodPut, v1+"/user/3", nil, `{"email":"mallory@example.com","current_password":"cobol"}`, http.StatusForbidden)
+	c.do(http.MethodPatch, v1+"/user/3", h("Content-Type", "application/merge-patch+json"), `{"email":null}`, http.StatusForbidden)
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"mallory@example.com"}`, http.StatusAccepted)
+	if n := len(c.mails("mallory@example.com")); n != 0 {
+		t.Errorf("%d emails to an email the user never had", n)
+	}
+
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"GRACE@example.com"}`, http.StatusAccepted)
+	reset := c.mailedToken("grace@example.com")
+	c.do(http.MethodPost, v1+"/password-reset/confirm", nil, fmt.Sprintf(`{"token":%q,"password":"short"}`, reset), http.StatusBadRequest)
+	c.do(http.MethodPost, v1+"/password-reset/confirm", nil, fmt.Sprintf(`{"token":%q,"password":"difference engine"}`, reset), http.StatusNoContent)
+	c.do(http.MethodPost, v1+"/password-reset/confirm", nil, fmt.Sprintf(`{"token":%q,"password":"analytical engine"}`, reset), http.StatusBadRequest)
+
+	// the user can, and the new email gets no reset before it is verified
+	c.do(http.MethodPatch, v1+"/user/3", h("Content-Type", "application/merge-patch+json"), `{"email":"grace@navy.example.com","current_password":"difference engine"}`, http.StatusOK)
+	c.do(http.MethodPost, v1+"/password-reset", nil, `{"email":"grace@navy.example.com"}`, http.StatusAccepted)
+	if n := len(c.mails("grace@navy.example.com")); n != 1 {
+		t.Errorf("%d emails to an unverified new email, want the verification only", n)
+	}
+
+	c.do(http.MethodGet, v1+"/cache/stats", nil, "", http.StatusOK)
+
+	// deletion and the trash
+	c.do(http.MethodDelete, v1+"/post/1/comments/1", nil, "", http.StatusNoContent)
+	c.do(http.MethodPost, v1+"/post/1/comments/1/restore", nil, "", http.StatusOK)
+	c.do(http.MethodDelete, tagPath, nil, "", http.StatusNoContent)
+	c.do(http.MethodDelete, v1+"/user/1/post/1", nil, "", http.StatusNoContent)
+	c.do(http.MethodPost, v1+"/user/1/post/1/restore", nil, "", http.StatusOK)
+	c.do(http.MethodDelete, v1+"/user/1", nil, "", http.StatusNoContent)
+	c.do(http.MethodGet, v1+"/trash", nil, "", http.StatusOK)
+	if n := trashed(t, c.do(http.MethodGet, v1+"/trash?to=2", nil, "", http.StatusOK)); n != 2 {
+		t.Errorf("a page of two holds %d rows", n)
+	}
+	c.do(http.MethodPost, v1+"/user/1/restore", nil, "", http.StatusOK)
+	c.do(http.MethodPost, v1+"/user/1/restore", nil, "", http.StatusNotFound)
+
+	// any encoding the API negotiates is described
+	for _, accept := range []string{"application/xml", "application/x-yaml", "application/msgpack"} {
+		c.do(http.MethodGet, v1+"/users", h("Accept", accept), "", http.StatusOK)
+	}
+	c.do(http.MethodGet, v1+"/users", h("Accept", "image/png"), "", http.StatusNotAcceptable)
+
+	var missing []string
+	for _, item := range c.doc.Paths {
+		for _, op := range item.Operations() {
+			if !c.covered[op.OperationID] {
+				missing = append(missing, op.OperationID)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("operations not exercised: %s", strings.Join(missing, ", "))
+	}
+}
+
+// upload posts a file in the multipart form of an upload, attached to postID unless it is empty.
+func (c *contract) upload(path, filename, content, postID string, status int) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	_, _ = io.WriteString(file, content)
+	if postID != "" {
+		// typed, as the validator decodes the untyped parts as strings
+		field, err := form.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="post_id"`},
+			"Content-Type":        {"application/json"},
+		})
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		_, _ = io.WriteString(field, postID)
+	}
+	if err := form.Close(); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.do(http.MethodPost, path, http.Header{"Content-Type": {form.FormDataContentType()}}, body.String(), status)
+}
+
+// mailedToken reads the token of the link of the last email sent to the address.
+func (c *contract) mailedToken(to string) string {
+	c.t.Helper()
+	mails := c.mails(to)
+	for i := len(mails) - 1; i >= 0; i-- {
+		if m := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`).FindSubmatch(mails[i]); m != nil {
+			return string(m[1])
+		}
+	}
+	c.t.Fatalf("no email with a token was sent to %s", to)
+	return ""
+}
+
+// mails returns the bodies of the emails sent to the address, in the order they were sent.
+func (c *contract) mails(to string) [][]byte {
+	c.t.Helper()
+	files, err := os.ReadDir(c.mailDir)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var mails [][]byte
+	// the names of the emails sort in the order they were sent
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(c.mailDir, file.Name()))
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		msg, err := netmail.ReadMessage(f)
+		if err != nil {
+			f.Close()
+			c.t.Fatal(err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		f.Close()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if strings.Contains(msg.Header.Get("To"), to) {
+			mails = append(mails, body)
+		}
+	}
+	return mails
+}
+
+// testPNG encodes a small image of two colors.
+func testPNG(t *testing.T) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.NRGBA{R: 0x30, G: 0x60, B: 0x90, A: 0xff}
+			if x < 8 {
+				c = color.NRGBA{R: 0xf0, G: 0xe0, B: 0x20, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// hugePNG encodes an image of a million pixels in a few hundred bytes.
+func hugePNG(t *testing.T) string {
+	t.Helper()
+	img := image.NewPaletted(image.Rect(0, 0, 1000, 1000), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > maxMediaSize {
+		t.Fatalf("the huge image takes %d bytes, more than the size limit", buf.Len())
+	}
+	return buf.String()
+}
+
+// createdID reads the id of the row created by a request.
+func createdID(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var envelope struct {
+		Data struct {
+			ID json.Number `json:"createdId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	return envelope.Data.ID.String()
+}
+
+// trashed counts the rows listed by a trash page.
+func trashed(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var envelope struct {
+		Data dto.Trash `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	return len(envelope.Data.Users) + len(envelope.Data.Posts) + len(envelope.Data.Comments)
+}
+
+// TestSpecUpToDate fails when the committed description differs from the one generated from
+// the route tables.
+func TestSpecUpToDate(t *testing.T) {
+	generated, err := httphandler.OpenAPI(httphandler.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generated.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "..", "docs", "swagger.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs/swagger.yaml is out of date, run make openapi")
+	}
+}
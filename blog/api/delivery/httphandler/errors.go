@@ -0,0 +1,58 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/utils/httputil"
+	"blog/utils/validation"
+)
+
+// errorStatus maps a usecase error to the HTTP status reported to the client.
+func errorStatus(err error) int {
+	switch dto.KindOf(err) {
+	case dto.KindNotFound:
+		return http.StatusNotFound
+	case dto.KindInvalid:
+		return http.StatusBadRequest
+	case dto.KindAlreadyExists, dto.KindFailedPrecondition:
+		return http.StatusConflict
+	case dto.KindStale:
+		return http.StatusPreconditionFailed
+	case dto.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case dto.KindUnsupported:
+		return http.StatusUnsupportedMediaType
+	case dto.KindPermissionDenied:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// usecaseErrors converts a usecase error into the response status and errors,
+// reporting validation failures field by field.
+func usecaseErrors(err error) (int, []httputil.StandardError) {
+	if validation.Failed(err) {
+		return http.StatusBadRequest, validation.Errors(err)
+	}
+
+	status := errorStatus(err)
+	return status, []httputil.StandardError{{
+		Code:   strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}}
+}
+
+// isMergePatch reports whether the request body is a JSON merge patch.
+// Plain JSON is accepted as well for clients that cannot set the media type.
+func isMergePatch(ctx *gin.Context) bool {
+	switch ctx.ContentType() {
+	case "application/merge-patch+json", "application/json":
+		return true
+	}
+	return false
+}
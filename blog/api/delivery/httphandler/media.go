@@ -0,0 +1,385 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
+)
+
+// multipartOverhead is the room left for the boundaries and the other fields of an upload
+// on top of the file.
+const multipartOverhead = 1 << 20
+
+type mediaHandler struct {
+	mediaUsecase interfaces.MediaUsecase
+	maxSize      int64
+}
+
+// NewMediaHandler registers the media endpoints. maxSize is the largest file accepted, in
+// bytes; larger request bodies are refused before being read.
+func NewMediaHandler(g *gin.RouterGroup, m interfaces.MediaUsecase, maxSize int64) {
+	register(g, &mediaHandler{mediaUsecase: m, maxSize: maxSize}, mediaRoutes)
+}
+
+var mediaRoutes = []route[*mediaHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "user/:user_id/upload-media", ID: "uploadMedia", Tag: "media",
+			Summary:     "Upload a file",
+			Description: "Stores the file of the multipart form, attached to a post of the user when post_id is set. The type of the file is sniffed from its content and must be one of the allowed ones. The metadata of the images is removed, and their variants and placeholders are rendered in the background: they are listed once the status is ready.",
+			Params:      dto.UploadMediaRequest{},
+			Body:        openapi.Multipart(dto.MediaUpload{}),
+			Status:      http.StatusCreated,
+			Data:        dto.Media{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+		},
+		handle: (*mediaHandler).UploadMediaHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "media/:media_id", ID: "getMediaById", Tag: "media",
+			Summary: "Find a file by ID",
+			Params:  dto.GetMediaByIDRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Media{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*mediaHandler).GetMediaByIdHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "user/:user_id/media", ID: "getAuthorMedia", Tag: "media",
+			Summary: "List the files of a user, the latest first",
+			Params:  dto.GetAuthorMediaRequest{},
+			Status:  http.StatusOK,
+			Data:    []dto.Media{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*mediaHandler).GetAuthorMediaHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "post/:post_id/media", ID: "getPostMedia", Tag: "media",
+			Summary: "List the files attached to a post",
+			Params:  dto.GetPostMediaRequest{},
+			Status:  http.StatusOK,
+			Data:    []dto.Media{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*mediaHandler).GetPostMediaHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodDelete, Path: "user/:user_id/media/:media_id", ID: "deleteMedia", Tag: "media",
+			Summary:     "Delete a file",
+			Description: "Deletes the record and the stored file, with its variants. The avatar of the author cannot be deleted until another one replaces it.",
+			Params:      dto.DeleteMediaRequest{},
+			Status:      http.StatusNoContent,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		handle: (*mediaHandler).DeleteMediaHandler,
+	},
+}
+
+func (s *mediaHandler) UploadMediaHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.UploadMediaRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	if ctx.Request.ContentLength > s.maxSize+multipartOverhead {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusRequestEntityTooLarge),
+			Title:  http.StatusText(http.StatusRequestEntityTooLarge),
+			Detail: "the file is too large, the limit is " + strconv.FormatInt(s.maxSize, 10) + " bytes",
+		}
+		return
+	}
+	// bodies of unknown length are cut at the same size
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, s.maxSize+multipartOverhead)
+
+	reqBody := new(dto.MediaUpload)
+	if err := ctx.ShouldBind(reqBody); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	file, err := reqBody.File.Open()
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	defer file.Close()
+
+	media, err := s.mediaUsecase.UploadMedia(ctx, req.AuthorID, reqBody.PostID, &dto.MediaFile{
+		Name: reqBody.File.Filename,
+		Size: reqBody.File.Size,
+		Body: file,
+	})
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: media,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusCreated)
+	return
+}
+
+func (s *mediaHandler) GetMediaByIdHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.GetMediaByIDRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	media, err := s.mediaUsecase.GetMediaById(ctx, req.MediaID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: media,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *mediaHandler) GetAuthorMediaHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.GetAuthorMediaRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	media, err := s.mediaUsecase.GetAuthorMedia(ctx, req.AuthorID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: media,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   len(media),
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *mediaHandler) GetPostMediaHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.GetPostMediaRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	media, err := s.mediaUsecase.GetPostMedia(ctx, req.PostID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: media,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   len(media),
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *mediaHandler) DeleteMediaHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.DeleteMediaRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	err := s.mediaUsecase.DeleteMedia(ctx, req.MediaID, req.AuthorID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
+	return
+}
@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,19 +10,91 @@ import (
 	"blog/domain/dto"
 	"blog/domain/interfaces"
 	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
 )
 
 type postHandler struct {
 	postUsecase interfaces.PostUsecase
 }
 
-func NewPostHandler(e *gin.Engine, p interfaces.PostUsecase) {
-	handler := postHandler{postUsecase: p}
-	e.GET("api/user/:user_id/post/:post_id", handler.GetPostByIdHandler)
-	e.GET("api/posts", handler.GetPostsHandler)
-	e.POST("api/user/:user_id/create-post", handler.CreatePostHandler)
-	e.PUT("api/user/:user_id/post/:post_id", handler.UpdatePostHandler)
-	e.DELETE("api/user/:user_id/post/:post_id", handler.DeletePostHandler)
+func NewPostHandler(g *gin.RouterGroup, p interfaces.PostUsecase) {
+	register(g, &postHandler{postUsecase: p}, postRoutes)
+}
+
+var postRoutes = []route[*postHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "user/:user_id/post/:post_id", ID: "getPostById", Tag: "posts",
+			Summary:     "Find a post by ID",
+			Params:      dto.GetPostByIDRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.Post{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*postHandler).GetPostByIdHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "posts", ID: "getPosts", Tag: "posts",
+			Summary:     "List the first 100 posts",
+			Status:      http.StatusOK,
+			Data:        []dto.Post{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		handle: (*postHandler).GetPostsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "user/:user_id/create-post", ID: "createPost", Tag: "posts",
+			Summary: "Create a post",
+			Params:  dto.CreatePostRequest{},
+			Body:    dto.PostCreate{},
+			Status:  http.StatusCreated,
+			Data:    dto.CreatePostResponse{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*postHandler).CreatePostHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPut, Path: "user/:user_id/post/:post_id", ID: "updatePost", Tag: "posts",
+			Summary: "Update a post",
+			Params:  dto.UpdatePostRequest{},
+			Body:    dto.UpdatePostBodyRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Post{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*postHandler).UpdatePostHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPatch, Path: "user/:user_id/post/:post_id", ID: "patchPost", Tag: "posts",
+			Summary:         "Apply a JSON merge patch to a post",
+			Params:          dto.UpdatePostRequest{},
+			Headers:         []openapi.Header{openapi.IfMatch},
+			Body:            openapi.MergePatch(dto.PostPatch{}),
+			Status:          http.StatusOK,
+			Data:            dto.Post{},
+			ResponseHeaders: []openapi.Header{openapi.ETag},
+			Errors:          []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType},
+		},
+		handle: (*postHandler).PatchPostHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodDelete, Path: "user/:user_id/post/:post_id", ID: "deletePost", Tag: "posts",
+			Summary:     "Delete a post",
+			Description: "Soft deletes the post. Its comments are deleted with it or block the deletion depending on the cascade mode.",
+			Params:      dto.DeletePostRequest{},
+			Status:      http.StatusNoContent,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		},
+		handle: (*postHandler).DeletePostHandler,
+	},
 }
 
 func (s *postHandler) GetPostByIdHandler(ctx *gin.Context) {
@@ -41,25 +112,27 @@ func (s *postHandler) GetPostByIdHandler(ctx *gin.Context) {
 	req := new(dto.GetPostByIDRequest)
 
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	post, err := s.postUsecase.GetPostById(ctx, req.PostID, req.AuthorID)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	tag, lastModified := post.Validators()
+	if httputil.NotModified(ctx.Writer, ctx.Request, tag, lastModified) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: post,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -68,6 +141,7 @@ func (s *postHandler) GetPostByIdHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -78,7 +152,7 @@ func (s *postHandler) GetPostByIdHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -96,11 +170,7 @@ func (s *postHandler) GetPostsHandler(ctx *gin.Context) {
 
 	req := new(dto.GetPosts)
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 	if req.LastIdx == 0 {
@@ -129,7 +199,12 @@ func (s *postHandler) GetPostsHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	tag, lastModified := postsValidators(*studies)
+	if httputil.NotModified(ctx.Writer, ctx.Request, tag, lastModified) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: studies,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -138,6 +213,7 @@ func (s *postHandler) GetPostsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -149,7 +225,7 @@ func (s *postHandler) GetPostsHandler(ctx *gin.Context) {
 		return
 	}
 
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -166,21 +242,13 @@ func (s *postHandler) CreatePostHandler(ctx *gin.Context) {
 	}()
 	req := new(dto.CreatePostRequest)
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.PostCreate)
 	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
@@ -194,7 +262,7 @@ func (s *postHandler) CreatePostHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -203,6 +271,7 @@ func (s *postHandler) CreatePostHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -213,7 +282,7 @@ func (s *postHandler) CreatePostHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusCreated)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusCreated)
 	return
 }
 
@@ -230,36 +299,29 @@ func (s *postHandler) UpdatePostHandler(ctx *gin.Context) {
 	}()
 
 	req := new(dto.UpdatePostRequest)
-	if err := ctx.BindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.UpdatePostBodyRequest)
-	if err := ctx.Bind(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBind(&reqBody); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	resp, err := s.postUsecase.UpdatePost(ctx, req.PostID, req.AuthorID, reqBody)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -268,6 +330,7 @@ func (s *postHandler) UpdatePostHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   0,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -278,11 +341,11 @@ func (s *postHandler) UpdatePostHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
-func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
+func (s *postHandler) PatchPostHandler(ctx *gin.Context) {
 	var (
 		startTime = time.Now()
 		httpError *httputil.StandardError
@@ -293,8 +356,24 @@ func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
 			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
 		}
 	}()
-	req := new(dto.DeletePostRequest)
+
+	req := new(dto.UpdatePostRequest)
 	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	if !isMergePatch(ctx) {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusUnsupportedMediaType),
+			Title:  http.StatusText(http.StatusUnsupportedMediaType),
+			Detail: "expected an application/merge-patch+json body",
+		}
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusBadRequest),
 			Title:  http.StatusText(http.StatusBadRequest),
@@ -302,7 +381,26 @@ func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	err := s.postUsecase.DeletePost(ctx, req.PostID, req.AuthorID)
+
+	resp, err := s.postUsecase.PatchPost(ctx, req.PostID, req.AuthorID, patch, ctx.GetHeader("If-Match"))
+	if err != nil {
+		status, errs := usecaseErrors(err)
+		httputil.WriteErrorResponse(ctx.Writer, status, errs)
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: resp,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
 	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusInternalServerError),
@@ -311,8 +409,40 @@ func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
 		}
 		return
 	}
+	tag, _ := resp.Validators()
+	ctx.Header("ETag", tag)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+	req := new(dto.DeletePostRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	err := s.postUsecase.DeletePost(ctx, req.PostID, req.AuthorID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
@@ -320,6 +450,7 @@ func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -330,6 +461,6 @@ func (s *postHandler) DeletePostHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusNoContent)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
 	return
 }
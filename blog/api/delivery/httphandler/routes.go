@@ -0,0 +1,72 @@
+package httphandler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"blog/utils/httputil"
+	"blog/utils/openapi"
+)
+
+// BasePath is the path the current version of the API is served under.
+const BasePath = "/api/v1"
+
+// route is an endpoint of a handler of type H: registered on gin by the handler constructor
+// and described in the OpenAPI document from the same table, so that the two cannot drift.
+type route[H any] struct {
+	openapi.Route
+	handle func(H, *gin.Context)
+}
+
+// register adds routes to g, served by h.
+func register[H any](g *gin.RouterGroup, h H, routes []route[H]) {
+	for _, r := range routes {
+		handle := r.handle
+		g.Handle(r.Method, r.Path, func(ctx *gin.Context) { handle(h, ctx) })
+	}
+}
+
+func describe[H any](routes []route[H]) []openapi.Route {
+	described := make([]openapi.Route, len(routes))
+	for i, r := range routes {
+		described[i] = r.Route
+	}
+	return described
+}
+
+// Routes describes the routes registered by the handler constructors.
+func Routes() []openapi.Route {
+	var routes []openapi.Route
+	routes = append(routes, describe(userRoutes)...)
+	routes = append(routes, describe(accountRoutes)...)
+	routes = append(routes, describe(postRoutes)...)
+	routes = append(routes, describe(tagsRoutes)...)
+	routes = append(routes, describe(commentsRoutes)...)
+	routes = append(routes, describe(trashRoutes)...)
+	routes = append(routes, describe(mediaRoutes)...)
+	routes = append(routes, describe(cacheRoutes)...)
+	return routes
+}
+
+var spec = openapi.Spec{
+	Info: openapi.Info{
+		Title:       "blog-platform",
+		Description: "API of the blog platform: users, their posts, and the tags and comments of the posts.",
+		Version:     "1.0.0",
+	},
+	Tags: []openapi.Tag{
+		{Name: "users", Description: "Everything about users"},
+		{Name: "posts", Description: "Operations about posts"},
+		{Name: "tags", Description: "Everything about tags"},
+		{Name: "comments", Description: "Everything about comments"},
+		{Name: "media", Description: "Files uploaded by the users"},
+		{Name: "trash", Description: "Soft-deleted rows and their restoration"},
+		{Name: "cache", Description: "Read cache statistics"},
+	},
+	MediaTypes: httputil.SupportedMediaTypes(),
+	Envelope:   httputil.StandardEnvelope{},
+}
+
+// OpenAPI describes the API served by the handler constructors under basePath.
+func OpenAPI(basePath string) (*openapi.Document, error) {
+	return spec.Build(basePath, Routes())
+}
@@ -1,7 +1,6 @@
 package httphandler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,18 +10,79 @@ import (
 	"blog/domain/dto"
 	"blog/domain/interfaces"
 	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
 )
 
 type tagsHandler struct {
 	tagsUsecase interfaces.TagsUsecase
 }
 
-func NewTagsHandler(e *gin.Engine, a interfaces.TagsUsecase) {
-	handler := tagsHandler{tagsUsecase: a}
-	e.GET("api/post/:post_id/tags/:tag_id", handler.GetTagByIdHandler)
-	e.POST("api/post/:post_id/create-tag", handler.CreateTagsHandler)
-	e.PUT("api/post/:post_id/tags/:tag_id", handler.UpdateTagsHandler)
-	e.DELETE("api/post/:post_id/tags/:tag_id", handler.DeleteTagsHandler)
+func NewTagsHandler(g *gin.RouterGroup, a interfaces.TagsUsecase) {
+	register(g, &tagsHandler{tagsUsecase: a}, tagsRoutes)
+}
+
+var tagsRoutes = []route[*tagsHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "post/:post_id/tags/:tag_id", ID: "getTagById", Tag: "tags",
+			Summary:     "Find a tag of a post by ID",
+			Params:      dto.GetTagByIDRequest{},
+			Status:      http.StatusOK,
+			Data:        dto.Tag{},
+			Conditional: true,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*tagsHandler).GetTagByIdHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "post/:post_id/create-tag", ID: "createTag", Tag: "tags",
+			Summary: "Tag a post",
+			Params:  dto.CreateTagsRequest{},
+			Body:    dto.Tag{},
+			Status:  http.StatusCreated,
+			Data:    dto.CreateTagsResponse{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*tagsHandler).CreateTagsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPut, Path: "post/:post_id/tags/:tag_id", ID: "updateTag", Tag: "tags",
+			Summary: "Update a tag",
+			Params:  dto.UpdateTagsRequest{},
+			Body:    dto.UpdateTagsBodyRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Tag{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*tagsHandler).UpdateTagsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPatch, Path: "post/:post_id/tags/:tag_id", ID: "patchTag", Tag: "tags",
+			Summary:         "Apply a JSON merge patch to a tag",
+			Params:          dto.UpdateTagsRequest{},
+			Headers:         []openapi.Header{openapi.IfMatch},
+			Body:            openapi.MergePatch(dto.TagPatch{}),
+			Status:          http.StatusOK,
+			Data:            dto.Tag{},
+			ResponseHeaders: []openapi.Header{openapi.ETag},
+			Errors:          []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType},
+		},
+		handle: (*tagsHandler).PatchTagsHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodDelete, Path: "post/:post_id/tags/:tag_id", ID: "deleteTag", Tag: "tags",
+			Summary: "Delete a tag",
+			Params:  dto.DeleteTagsRequest{},
+			Status:  http.StatusNoContent,
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*tagsHandler).DeleteTagsHandler,
+	},
 }
 
 func (s *tagsHandler) GetTagByIdHandler(ctx *gin.Context) {
@@ -40,25 +100,27 @@ func (s *tagsHandler) GetTagByIdHandler(ctx *gin.Context) {
 	req := new(dto.GetTagByIDRequest)
 
 	if err := ctx.ShouldBindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	tag, err := s.tagsUsecase.GetTagById(ctx, req.TagID, req.PostID)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	etag, lastModified := tag.Validators()
+	if httputil.NotModified(ctx.Writer, ctx.Request, etag, lastModified) {
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: tag,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -67,6 +129,7 @@ func (s *tagsHandler) GetTagByIdHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -77,7 +140,7 @@ func (s *tagsHandler) GetTagByIdHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
@@ -94,21 +157,13 @@ func (s *tagsHandler) CreateTagsHandler(ctx *gin.Context) {
 	}()
 	req := new(dto.CreateTagsRequest)
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.Tag)
 	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
@@ -122,7 +177,7 @@ func (s *tagsHandler) CreateTagsHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -131,6 +186,7 @@ func (s *tagsHandler) CreateTagsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -141,7 +197,7 @@ func (s *tagsHandler) CreateTagsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusCreated)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusCreated)
 	return
 }
 
@@ -158,36 +214,29 @@ func (s *tagsHandler) UpdateTagsHandler(ctx *gin.Context) {
 	}()
 
 	req := new(dto.UpdateTagsRequest)
-	if err := ctx.BindUri(req); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	reqBody := new(dto.UpdateTagsBodyRequest)
-	if err := ctx.Bind(&reqBody); err != nil {
-		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusBadRequest),
-			Title:  http.StatusText(http.StatusBadRequest),
-			Detail: err.Error(),
-		}
+	if err := ctx.ShouldBind(&reqBody); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
 		return
 	}
 
 	resp, err := s.tagsUsecase.UpdateTags(ctx, req.TagID, req.PostID, reqBody)
 	if err != nil {
+		status := errorStatus(err)
 		httpError = &httputil.StandardError{
-			Code:   strconv.Itoa(http.StatusInternalServerError),
-			Title:  http.StatusText(http.StatusInternalServerError),
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
 			Detail: err.Error(),
 		}
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Data: resp,
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
@@ -196,6 +245,7 @@ func (s *tagsHandler) UpdateTagsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   0,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -206,11 +256,11 @@ func (s *tagsHandler) UpdateTagsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusOK)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
 	return
 }
 
-func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
+func (s *tagsHandler) PatchTagsHandler(ctx *gin.Context) {
 	var (
 		startTime = time.Now()
 		httpError *httputil.StandardError
@@ -221,8 +271,24 @@ func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
 			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
 		}
 	}()
-	req := new(dto.DeleteTagsRequest)
+
+	req := new(dto.UpdateTagsRequest)
 	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	if !isMergePatch(ctx) {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusUnsupportedMediaType),
+			Title:  http.StatusText(http.StatusUnsupportedMediaType),
+			Detail: "expected an application/merge-patch+json body",
+		}
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
 		httpError = &httputil.StandardError{
 			Code:   strconv.Itoa(http.StatusBadRequest),
 			Title:  http.StatusText(http.StatusBadRequest),
@@ -230,6 +296,56 @@ func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
 		}
 		return
 	}
+
+	resp, err := s.tagsUsecase.PatchTag(ctx, req.TagID, req.PostID, patch, ctx.GetHeader("If-Match"))
+	if err != nil {
+		status, errs := usecaseErrors(err)
+		httputil.WriteErrorResponse(ctx.Writer, status, errs)
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: resp,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	tag, _ := resp.Validators()
+	ctx.Header("ETag", tag)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+	req := new(dto.DeleteTagsRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
 	err := s.tagsUsecase.DeleteTags(ctx, req.TagID, req.PostID)
 	if err != nil {
 		httpError = &httputil.StandardError{
@@ -240,7 +356,7 @@ func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
 		return
 	}
 
-	data, err := json.Marshal(httputil.StandardEnvelope{
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
 		Status: &httputil.StandardStatus{
 			Message:   http.StatusText(http.StatusOK),
 			ErrorCode: 0,
@@ -248,6 +364,7 @@ func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
 		Header: &httputil.StandardHeader{
 			TotalData:   1,
 			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
 		},
 	})
 	if err != nil {
@@ -258,6 +375,6 @@ func (s *tagsHandler) DeleteTagsHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	_, _ = httputil.WriteJSONResponse(ctx.Writer, data, http.StatusNoContent)
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusNoContent)
 	return
 }
@@ -0,0 +1,297 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/httputil"
+	"blog/utils/openapi"
+	"blog/utils/validation"
+)
+
+type trashHandler struct {
+	trashUsecase interfaces.TrashUsecase
+}
+
+func NewTrashHandler(g *gin.RouterGroup, t interfaces.TrashUsecase) {
+	register(g, &trashHandler{trashUsecase: t}, trashRoutes)
+}
+
+var trashRoutes = []route[*trashHandler]{
+	{
+		Route: openapi.Route{
+			Method: http.MethodGet, Path: "trash", ID: "getTrash", Tag: "trash",
+			Summary: "List the soft-deleted users, posts and comments",
+			Params:  dto.GetTrash{},
+			Status:  http.StatusOK,
+			Data:    dto.Trash{},
+			Errors:  []int{http.StatusBadRequest},
+		},
+		handle: (*trashHandler).GetTrashHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "user/:user_id/restore", ID: "restoreUser", Tag: "trash",
+			Summary: "Restore a soft-deleted user",
+			Params:  dto.RestoreUserRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.User{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*trashHandler).RestoreUserHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "user/:user_id/post/:post_id/restore", ID: "restorePost", Tag: "trash",
+			Summary: "Restore a soft-deleted post",
+			Params:  dto.RestorePostRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Post{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*trashHandler).RestorePostHandler,
+	},
+	{
+		Route: openapi.Route{
+			Method: http.MethodPost, Path: "post/:post_id/comments/:comment_id/restore", ID: "restoreComment", Tag: "trash",
+			Summary: "Restore a soft-deleted comment",
+			Params:  dto.RestoreCommentRequest{},
+			Status:  http.StatusOK,
+			Data:    dto.Comment{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+		handle: (*trashHandler).RestoreCommentHandler,
+	},
+}
+
+func (s *trashHandler) GetTrashHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.GetTrash)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+	if req.LastIdx == 0 {
+		req.LastIdx = 100
+	}
+	offset := req.Offset
+	limit := req.LastIdx - offset
+	if limit <= 0 {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusBadRequest),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: "'to' is less than 'from'",
+		}
+		return
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	trash, err := s.trashUsecase.GetTrash(ctx, limit, offset)
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: trash,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   len(trash.Users) + len(trash.Posts) + len(trash.Comments),
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *trashHandler) RestoreUserHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.RestoreUserRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	user, err := s.trashUsecase.RestoreUser(ctx, req.UserID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: user,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *trashHandler) RestorePostHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.RestorePostRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	post, err := s.trashUsecase.RestorePost(ctx, req.PostID, req.AuthorID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: post,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
+
+func (s *trashHandler) RestoreCommentHandler(ctx *gin.Context) {
+	var (
+		startTime = time.Now()
+		httpError *httputil.StandardError
+	)
+	defer func() {
+		if httpError != nil {
+			errCode, _ := strconv.Atoi(httpError.Code)
+			httputil.WriteErrorResponse(ctx.Writer, errCode, []httputil.StandardError{*httpError})
+		}
+	}()
+
+	req := new(dto.RestoreCommentRequest)
+	if err := ctx.ShouldBindUri(req); err != nil {
+		httputil.WriteErrorResponse(ctx.Writer, http.StatusBadRequest, validation.Errors(err))
+		return
+	}
+
+	comment, err := s.trashUsecase.RestoreComment(ctx, req.CommentID, req.PostID)
+	if err != nil {
+		status := errorStatus(err)
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(status),
+			Title:  http.StatusText(status),
+			Detail: err.Error(),
+		}
+		return
+	}
+
+	data, err := httputil.Encode(ctx.Writer, httputil.StandardEnvelope{
+		Data: comment,
+		Status: &httputil.StandardStatus{
+			Message:   http.StatusText(http.StatusOK),
+			ErrorCode: 0,
+		},
+		Header: &httputil.StandardHeader{
+			TotalData:   1,
+			ProcessTime: time.Since(startTime).Seconds(),
+			Meta:        httputil.Meta(ctx.Request.Context()),
+		},
+	})
+	if err != nil {
+		httpError = &httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusInternalServerError),
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+		}
+		return
+	}
+	_, _ = httputil.WriteResponse(ctx.Writer, data, http.StatusOK)
+	return
+}
@@ -0,0 +1,115 @@
+package webhandler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/sitelink"
+	"blog/utils/validation"
+)
+
+type accountHandler struct {
+	*siteHandler
+	accountUsecase interfaces.AccountUsecase
+}
+
+// NewAccountHandler serves the pages the links of the account emails open by default:
+// verify-email, verifying the email the token was sent to, and reset-password, choosing a new
+// password. The pages only use the token when their form is posted, as the mail scanners
+// opening the links would use it otherwise.
+func NewAccountHandler(g *gin.RouterGroup, accountUsecase interfaces.AccountUsecase, theme *Theme, siteURL, siteTitle string) {
+	handler := &accountHandler{
+		siteHandler: &siteHandler{
+			theme:     theme,
+			links:     sitelink.New(siteURL),
+			siteTitle: siteTitle,
+		},
+		accountUsecase: accountUsecase,
+	}
+	// the pages hold the tokens, which must neither be cached nor sent to other sites
+	g = g.Group("", func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Header("Referrer-Policy", "no-referrer")
+	})
+	g.GET("verify-email", handler.VerifyEmailPageHandler)
+	g.POST("verify-email", handler.VerifyEmailHandler)
+	g.GET("reset-password", handler.ResetPasswordPageHandler)
+	g.POST("reset-password", handler.ResetPasswordHandler)
+}
+
+// VerifyEmailPageHandler renders the form verifying the email of the token.
+func (h *accountHandler) VerifyEmailPageHandler(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	h.renderAccount(ctx, http.StatusOK, "verify-email", "Verify your email", &view{Token: req.Token})
+}
+
+// VerifyEmailHandler verifies the email of the posted token.
+func (h *accountHandler) VerifyEmailHandler(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := h.accountUsecase.VerifyEmail(ctx, req.Token); err != nil {
+		h.accountError(ctx, "verify-email", "Verify your email", err, &view{})
+		return
+	}
+	h.renderAccount(ctx, http.StatusOK, "verify-email", "Verify your email", &view{Done: true})
+}
+
+// ResetPasswordPageHandler renders the form choosing a new password.
+func (h *accountHandler) ResetPasswordPageHandler(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	h.renderAccount(ctx, http.StatusOK, "reset-password", "Reset your password", &view{Token: req.Token})
+}
+
+// ResetPasswordHandler sets the posted password. A password the rules refuse renders the form
+// again, with the same token.
+func (h *accountHandler) ResetPasswordHandler(ctx *gin.Context) {
+	var req dto.ConfirmPasswordResetRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		if req.Token == "" || !validation.Failed(err) {
+			h.renderError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		h.renderAccount(ctx, http.StatusBadRequest, "reset-password", "Reset your password", &view{
+			Token:   req.Token,
+			Message: validation.Errors(err)[0].Detail,
+		})
+		return
+	}
+
+	if err := h.accountUsecase.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		h.accountError(ctx, "reset-password", "Reset your password", err, &view{})
+		return
+	}
+	h.renderAccount(ctx, http.StatusOK, "reset-password", "Reset your password", &view{Done: true})
+}
+
+// accountError tells the user their link is no longer valid, and renders the error page for
+// the other errors.
+func (h *accountHandler) accountError(ctx *gin.Context, name, title string, err error, v *view) {
+	if dto.KindOf(err) != dto.KindInvalid {
+		h.renderError(ctx, errorStatus(err), err)
+		return
+	}
+	v.Message = "This link is invalid or has expired."
+	h.renderAccount(ctx, http.StatusBadRequest, name, title, v)
+}
+
+func (h *accountHandler) renderAccount(ctx *gin.Context, status int, name, title string, v *view) {
+	v.Meta = meta{Title: title + " - " + h.siteTitle, NoIndex: true}
+	h.render(ctx, status, name, v)
+}
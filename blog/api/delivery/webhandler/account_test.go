@@ -0,0 +1,97 @@
+package webhandler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"blog/api/delivery/webhandler"
+	"blog/domain/dto"
+)
+
+// accounts accepts the token "valid" once.
+type accounts struct {
+	used     bool
+	password string
+}
+
+func (a *accounts) use(token string) error {
+	if token != "valid" || a.used {
+		return errors.Wrap(dto.ErrInvalidToken, token)
+	}
+	a.used = true
+	return nil
+}
+
+func (a *accounts) SendVerification(*gin.Context, int64) error { return nil }
+
+func (a *accounts) VerifyEmail(_ *gin.Context, token string) (*dto.User, error) {
+	if err := a.use(token); err != nil {
+		return nil, err
+	}
+	return &dto.User{ID: 1}, nil
+}
+
+func (a *accounts) RequestPasswordReset(*gin.Context, string) error { return nil }
+
+func (a *accounts) ResetPassword(_ *gin.Context, token, password string) error {
+	if err := a.use(token); err != nil {
+		return err
+	}
+	a.password = password
+	return nil
+}
+
+func TestAccountPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	theme, err := webhandler.LoadTheme(webhandler.ThemeConfig{Dir: t.TempDir(), Name: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &accounts{}
+	r := gin.New()
+	webhandler.NewAccountHandler(r.Group(""), a, theme, "https://blog.example.com", "Notes")
+
+	do := func(method, path string, form url.Values, status int, want string) {
+		t.Helper()
+		var req *http.Request
+		if form == nil {
+			req = httptest.NewRequest(method, path, nil)
+		} else {
+			req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status || !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s %s: status %d, want %d and %q: %s", method, path, w.Code, status, want, w.Body)
+		}
+		if w.Header().Get("Cache-Control") != "no-store" || w.Header().Get("Referrer-Policy") != "no-referrer" {
+			t.Errorf("%s %s: Cache-Control %q, Referrer-Policy %q", method, path, w.Header().Get("Cache-Control"), w.Header().Get("Referrer-Policy"))
+		}
+	}
+
+	// opening the link does not use the token
+	do(http.MethodGet, "/verify-email?token=valid", nil, http.StatusOK, `name="token" value="valid"`)
+	do(http.MethodGet, "/verify-email", nil, http.StatusBadRequest, "400")
+	if a.used {
+		t.Fatal("opening the link used the token")
+	}
+	do(http.MethodPost, "/verify-email", url.Values{"token": {"valid"}}, http.StatusOK, "Your email is verified")
+	do(http.MethodPost, "/verify-email", url.Values{"token": {"valid"}}, http.StatusBadRequest, "This link is invalid or has expired.")
+
+	a.used = false
+	do(http.MethodGet, "/reset-password?token=valid", nil, http.StatusOK, `type="password"`)
+	// a refused password keeps the form and its token
+	do(http.MethodPost, "/reset-password", url.Values{"token": {"valid"}, "password": {"short"}}, http.StatusBadRequest, `name="token" value="valid"`)
+	do(http.MethodPost, "/reset-password", url.Values{"token": {"valid"}, "password": {"difference engine"}}, http.StatusOK, "Your password is changed")
+	if a.password != "difference engine" {
+		t.Errorf("password %q", a.password)
+	}
+	do(http.MethodPost, "/reset-password", url.Values{"token": {"valid"}, "password": {"analytical engine"}}, http.StatusBadRequest, "This link is invalid or has expired.")
+}
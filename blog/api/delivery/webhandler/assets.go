@@ -0,0 +1,50 @@
+package webhandler
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"blog/utils/log"
+)
+
+// themeAssetsPath is where the assets of the theme are served.
+const themeAssetsPath = "/theme/"
+
+type themeHandler struct {
+	theme *Theme
+}
+
+// NewThemeHandler serves the assets of the theme under /theme/, g being the root of the site.
+func NewThemeHandler(g *gin.RouterGroup, theme *Theme) {
+	handler := &themeHandler{theme: theme}
+	g.GET("theme/*path", handler.AssetHandler)
+}
+
+// AssetHandler serves an asset of the theme. The versioned links of the templates are
+// cached for good, the others revalidated with the ETag of the asset.
+func (h *themeHandler) AssetHandler(ctx *gin.Context) {
+	name := strings.TrimPrefix(ctx.Param("path"), "/")
+	asset, err := h.theme.asset(name)
+	if err != nil {
+		log.FromContext(ctx.Request.Context()).Error("serve theme asset", zap.String("asset", name), zap.Error(err))
+		ctx.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if asset == nil {
+		ctx.String(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	if ctx.Query("v") == asset.version {
+		ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		ctx.Header("Cache-Control", "no-cache")
+	}
+	ctx.Header("ETag", asset.etag)
+	http.ServeContent(ctx.Writer, ctx.Request, name, time.Time{}, bytes.NewReader(asset.data))
+}
@@ -0,0 +1,59 @@
+package webhandler
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/log"
+)
+
+type mediaFileHandler struct {
+	mediaUsecase interfaces.MediaUsecase
+}
+
+// NewMediaFileHandler serves the uploaded files under /media/, g being the root of the site.
+func NewMediaFileHandler(g *gin.RouterGroup, mediaUsecase interfaces.MediaUsecase) {
+	handler := &mediaFileHandler{mediaUsecase: mediaUsecase}
+	g.GET(strings.TrimPrefix(dto.MediaPath, "/")+"*key", handler.MediaFileHandler)
+}
+
+// MediaFileHandler serves a file with the type sniffed at upload. The file of a key never
+// changes, so it is cached for good; ranges are served for the videos and large files.
+func (h *mediaFileHandler) MediaFileHandler(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	media, file, err := h.mediaUsecase.OpenMedia(ctx, key)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(errors.Cause(err)) || errors.Is(err, fs.ErrNotExist) {
+			ctx.String(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		log.FromContext(ctx.Request.Context()).Error("serve media", zap.String("key", key), zap.Error(err))
+		ctx.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	defer file.Close()
+
+	// only the images and videos are shown in the page, the rest is downloaded, and
+	// nothing uploaded runs as part of the site
+	disposition := "attachment"
+	if strings.HasPrefix(media.ContentType, "image/") || strings.HasPrefix(media.ContentType, "video/") {
+		disposition = "inline"
+	}
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", media.ContentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": media.Filename}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("ETag", `"`+media.Checksum+`"`)
+	http.ServeContent(ctx.Writer, ctx.Request, media.Filename, media.CreatedAt, file)
+}
@@ -0,0 +1,321 @@
+package webhandler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/feed"
+	"blog/utils/httputil"
+	"blog/utils/log"
+	"blog/utils/sitelink"
+	"blog/utils/validation"
+)
+
+// summaryLength is the length of the summaries of the posts in the listings and the
+// descriptions of their pages, in characters.
+const summaryLength = 200
+
+type siteHandler struct {
+	siteUsecase interfaces.SiteUsecase
+	theme       *Theme
+	links       sitelink.Links
+	siteTitle   string
+}
+
+// view is the data the templates are rendered with.
+type view struct {
+	Site  site
+	Meta  meta
+	Links sitelink.Links
+	// Theme holds the settings of the theme.
+	Theme map[string]interface{}
+	// List is the listing of the home, author and tag pages.
+	List *dto.PostList
+	// Post is the post of a post page.
+	Post *dto.PostView
+	// Status and Message describe the error of an error page, Message also what went wrong
+	// with the form of an account page.
+	Status  int
+	Message string
+	// Token is the token an account page posts, Done tells it was used.
+	Token string
+	Done  bool
+}
+
+type site struct {
+	Title string
+	Home  string
+}
+
+// meta is the head of a page: its title, description and links for the search engines,
+// the social networks and the feed readers.
+type meta struct {
+	Title       string
+	Description string
+	Canonical   string
+	// Type is the OpenGraph type of the page, website or article.
+	Type      string
+	Published time.Time
+	Modified  time.Time
+	Author    string
+	Tags      []string
+	Prev      string
+	Next      string
+	Feeds     []alternate
+	// NoIndex keeps the search engines away from error pages.
+	NoIndex bool
+}
+
+// alternate is a feed of a page.
+type alternate struct {
+	Type  string
+	Title string
+	Href  string
+}
+
+// NewSiteHandler serves the HTML pages of the blog: the home page at the root of g, the
+// posts, the authors and the tags, rendered with the theme. siteURL is the absolute URL
+// of the site, for the canonical links.
+func NewSiteHandler(g *gin.RouterGroup, siteUsecase interfaces.SiteUsecase, theme *Theme, siteURL, siteTitle string) {
+	handler := &siteHandler{
+		siteUsecase: siteUsecase,
+		theme:       theme,
+		links:       sitelink.New(siteURL),
+		siteTitle:   siteTitle,
+	}
+	g.GET("", handler.HomePageHandler)
+	g.GET("posts/:post_id", handler.PostPageHandler)
+	g.GET("user/:user_id", handler.AuthorPageHandler)
+	g.GET("tag/:name", handler.TagPageHandler)
+}
+
+// HomePageHandler renders a page of the latest posts.
+func (h *siteHandler) HomePageHandler(ctx *gin.Context) {
+	var req dto.GetSitePageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.siteUsecase.GetHomePage(ctx, pageNumber(req.Page))
+	if err != nil {
+		h.renderError(ctx, errorStatus(err), err)
+		return
+	}
+	h.renderList(ctx, "home", list, h.links.Home(), meta{
+		Title:       h.siteTitle,
+		Description: "Latest posts of " + h.siteTitle,
+		Type:        "website",
+		Feeds:       h.feeds("", h.siteTitle),
+	})
+}
+
+// AuthorPageHandler renders a page of the posts of an author.
+func (h *siteHandler) AuthorPageHandler(ctx *gin.Context) {
+	var uri dto.GetAuthorPageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req dto.GetSitePageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.siteUsecase.GetAuthorPage(ctx, uri.AuthorID, pageNumber(req.Page))
+	if err != nil {
+		h.renderError(ctx, errorStatus(err), err)
+		return
+	}
+	h.renderList(ctx, "author", list, h.links.Author(uri.AuthorID), meta{
+		Title:       list.Author.Name + " - " + h.siteTitle,
+		Description: fmt.Sprintf("Posts of %s on %s", list.Author.Name, h.siteTitle),
+		Type:        "profile",
+		Feeds:       h.feeds(fmt.Sprintf("/user/%d", uri.AuthorID), list.Author.Name),
+	})
+}
+
+// TagPageHandler renders a page of the posts carrying a tag.
+func (h *siteHandler) TagPageHandler(ctx *gin.Context) {
+	var uri dto.GetTagPageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req dto.GetSitePageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.siteUsecase.GetTagPage(ctx, uri.Name, pageNumber(req.Page))
+	if err != nil {
+		h.renderError(ctx, errorStatus(err), err)
+		return
+	}
+	h.renderList(ctx, "tag", list, h.links.Tag(list.Tag.Name), meta{
+		Title:       list.Tag.Name + " - " + h.siteTitle,
+		Description: fmt.Sprintf("Posts tagged %s on %s", list.Tag.Name, h.siteTitle),
+		Type:        "website",
+		Feeds:       h.feeds("/tag/"+url.PathEscape(list.Tag.Name), list.Tag.Name),
+	})
+}
+
+// PostPageHandler renders a post with its tags and comments.
+func (h *siteHandler) PostPageHandler(ctx *gin.Context) {
+	var uri dto.GetPostPageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.renderError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	post, err := h.siteUsecase.GetPostPage(ctx, uri.PostID)
+	if err != nil {
+		h.renderError(ctx, errorStatus(err), err)
+		return
+	}
+
+	m := meta{
+		Title:       post.Post.Title + " - " + h.siteTitle,
+		Description: feed.Summarize(post.Post.Content, summaryLength),
+		Canonical:   h.links.Post(post.Post.ID),
+		Type:        "article",
+		Published:   post.Post.CreatedAt,
+		Modified:    post.Post.UpdatedAt,
+		Author:      post.Post.Author.Name,
+		Feeds:       h.feeds("", h.siteTitle),
+	}
+	for _, t := range post.Tags {
+		m.Tags = append(m.Tags, t.Name)
+	}
+	h.render(ctx, http.StatusOK, "post", &view{Meta: m, Post: post})
+}
+
+// NewNotFoundHandler returns the handler of the requests no route matched, answered with a
+// JSON error under the API and with the not found page elsewhere.
+func NewNotFoundHandler(theme *Theme, siteURL, siteTitle string) gin.HandlerFunc {
+	handler := &siteHandler{
+		theme:     theme,
+		links:     sitelink.New(siteURL),
+		siteTitle: siteTitle,
+	}
+	return handler.NotFoundHandler
+}
+
+// NotFoundHandler answers the requests no route matched.
+func (h *siteHandler) NotFoundHandler(ctx *gin.Context) {
+	if apiNotFound(ctx) {
+		return
+	}
+	h.renderError(ctx, http.StatusNotFound, errors.New("page not found"))
+}
+
+// NewAppHandler answers the requests no route matched with index, the page of a single-page
+// app routing them in the browser.
+func NewAppHandler(index string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if apiNotFound(ctx) {
+			return
+		}
+		ctx.File(index)
+	}
+}
+
+// apiNotFound answers the unknown API routes with a JSON 404 and reports whether the route
+// was one.
+func apiNotFound(ctx *gin.Context) bool {
+	if !strings.HasPrefix(ctx.Request.URL.Path, "/api/") {
+		return false
+	}
+	httputil.WriteErrorResponse(ctx.Writer, http.StatusNotFound, []httputil.StandardError{{
+		Code:   "404",
+		Title:  http.StatusText(http.StatusNotFound),
+		Detail: "no route " + ctx.Request.URL.Path,
+	}})
+	return true
+}
+
+// renderList renders a listing, linking its neighbour pages. Pages past the last one do
+// not exist, except for the first page of an empty listing.
+func (h *siteHandler) renderList(ctx *gin.Context, name string, list *dto.PostList, link string, m meta) {
+	if list.Page > list.Pages() {
+		h.renderError(ctx, http.StatusNotFound, errors.Errorf("page %d of %d", list.Page, list.Pages()))
+		return
+	}
+
+	m.Canonical = h.links.Page(link, list.Page)
+	if list.Page > 1 {
+		m.Title = fmt.Sprintf("%s (page %d)", m.Title, list.Page)
+		m.Prev = h.links.Page(link, list.Page-1)
+	}
+	if list.Page < list.Pages() {
+		m.Next = h.links.Page(link, list.Page+1)
+	}
+	h.render(ctx, http.StatusOK, name, &view{Meta: m, List: list})
+}
+
+// feeds lists the feeds of the listing at path, "" for the whole blog.
+func (h *siteHandler) feeds(path, title string) []alternate {
+	return []alternate{
+		{Type: "application/atom+xml", Title: title + " (Atom)", Href: h.links.URL(path + "/feed.atom")},
+		{Type: "application/rss+xml", Title: title + " (RSS)", Href: h.links.URL(path + "/feed.rss")},
+		{Type: "application/feed+json", Title: title + " (JSON Feed)", Href: h.links.URL(path + "/feed.json")},
+	}
+}
+
+// renderError renders the error page. Server errors are logged and not shown to the reader,
+// validation errors are.
+func (h *siteHandler) renderError(ctx *gin.Context, status int, err error) {
+	message := http.StatusText(status)
+	switch {
+	case status >= http.StatusInternalServerError:
+		log.FromContext(ctx.Request.Context()).Error("render page", zap.Error(err))
+	case validation.Failed(err):
+		message = validation.Errors(err)[0].Detail
+	}
+	h.render(ctx, status, "error", &view{
+		Meta:    meta{Title: message + " - " + h.siteTitle, NoIndex: true},
+		Status:  status,
+		Message: message,
+	})
+}
+
+func (h *siteHandler) render(ctx *gin.Context, status int, name string, v *view) {
+	v.Site = site{Title: h.siteTitle, Home: h.links.Home()}
+	v.Links = h.links
+
+	var page bytes.Buffer
+	if err := h.theme.render(&page, name, v); err != nil {
+		log.FromContext(ctx.Request.Context()).Error("render template", zap.String("page", name), zap.Error(err))
+		ctx.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	ctx.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// errorStatus maps a usecase error to the status of the error page.
+func errorStatus(err error) int {
+	if dto.KindOf(err) == dto.KindNotFound {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// pageNumber defaults the page query parameter to the first page.
+func pageNumber(page int) int {
+	if page < 1 {
+		return 1
+	}
+	return page
+}
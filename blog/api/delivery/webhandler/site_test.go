@@ -0,0 +1,39 @@
+package webhandler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/delivery/webhandler"
+)
+
+func TestAppHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	index := filepath.Join(t.TempDir(), "index.html")
+	if err := os.WriteFile(index, []byte("<div id=app></div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.NoRoute(webhandler.NewAppHandler(index))
+
+	for _, tt := range []struct {
+		path, contentType, body string
+		status                  int
+	}{
+		{"/posts/1", "text/html", "<div id=app></div>", http.StatusOK},
+		{"/settings/profile", "text/html", "<div id=app></div>", http.StatusOK},
+		{"/api/v1/unknown", "application/json", `"code":"404"`, http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("GET %s: status %d, %s: %s", tt.path, w.Code, w.Header().Get("Content-Type"), w.Body)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package webhandler
+
+import (
+	"html/template"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"blog/utils/feed"
+)
+
+// pageNames are the pages of the site, each rendered by the template file of the same name.
+var pageNames = []string{"home", "post", "author", "tag", "error", "verify-email", "reset-password"}
+
+// parseTemplates parses the pages of a theme from its layers, the root theme first. A layer
+// holds layout.html, the skeleton of every page, the partials under partials/, and one file
+// per page defining the "content" block of the layout. Each layer is parsed over the ones
+// before it, so a theme replaces the files of its parent or only the blocks it defines again.
+// themeFuncs are the functions depending on the theme, added to funcs.
+func parseTemplates(layers []fs.FS, themeFuncs template.FuncMap) (map[string]*template.Template, error) {
+	base := template.New("layout.html").Funcs(funcs).Funcs(themeFuncs)
+	for _, layer := range layers {
+		for _, pattern := range []string{"layout.html", "partials/*.html"} {
+			files, err := fs.Glob(layer, pattern)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				continue
+			}
+			if _, err := base.ParseFS(layer, files...); err != nil {
+				return nil, errors.Wrapf(err, "parse %s", pattern)
+			}
+		}
+	}
+	if base.Lookup("layout") == nil {
+		return nil, errors.New("no layout template")
+	}
+
+	pages := make(map[string]*template.Template, len(pageNames))
+	for _, name := range pageNames {
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		file := name + ".html"
+		for _, layer := range layers {
+			if _, err := fs.Stat(layer, file); errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if _, err := page.ParseFS(layer, file); err != nil {
+				return nil, errors.Wrapf(err, "parse the %s page", name)
+			}
+			pages[name] = page
+		}
+		if pages[name] == nil {
+			return nil, errors.Errorf("no %s page", name)
+		}
+	}
+	return pages, nil
+}
+
+var funcs = template.FuncMap{
+	// date formats t for the readers
+	"date": func(t time.Time) string {
+		return t.Format("January 2, 2006")
+	},
+	// iso formats t for the machines, as in datetime attributes and meta tags
+	"iso": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	// paragraphs splits plain text into its paragraphs
+	"paragraphs": func(text string) []string {
+		var paragraphs []string
+		for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				paragraphs = append(paragraphs, p)
+			}
+		}
+		return paragraphs
+	},
+	"summary": func(text string) string {
+		return feed.Summarize(text, summaryLength)
+	},
+}
@@ -0,0 +1,378 @@
+package webhandler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"blog/themes"
+	"blog/utils/etag"
+)
+
+// themeManifestFile describes a theme, and marks its directory as one.
+const themeManifestFile = "theme.json"
+
+// ThemeConfig selects the theme of the site.
+type ThemeConfig struct {
+	// Dir holds the themes, a directory per theme. The built-in default theme is used when
+	// it holds none named "default".
+	Dir  string
+	Name string
+	// SettingsFile is a JSON object of values of the settings of the theme, its defaults
+	// apply when empty.
+	SettingsFile string
+	// Reload parses the theme again when its files change, for development.
+	Reload bool
+}
+
+// Theme renders the pages of the site and serves their assets. A theme is a directory of
+// templates, with its stylesheets, scripts and images under assets/ and a theme.json
+// declaring its settings and the theme it extends, if any.
+type Theme struct {
+	config ThemeConfig
+
+	mu     sync.RWMutex
+	loaded *loadedTheme
+	// stamp fingerprints the files loaded was read from, when reloading.
+	stamp string
+}
+
+// loadedTheme is a parsed theme, with the files of the themes it extends.
+type loadedTheme struct {
+	pages    map[string]*template.Template
+	assets   map[string]*themeAsset
+	settings map[string]interface{}
+}
+
+type themeAsset struct {
+	data []byte
+	etag string
+	// version changes with the content of the asset, to tell the clients when their copy
+	// is stale.
+	version string
+}
+
+// themeManifest is the theme.json file at the root of a theme.
+type themeManifest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Extends names the theme this one inherits its templates, assets and settings from.
+	Extends  string                  `json:"extends"`
+	Settings map[string]themeSetting `json:"settings"`
+}
+
+// themeSetting declares a setting of a theme, available to its templates as .Theme.<key>.
+type themeSetting struct {
+	// Type is string, bool or number.
+	Type    string      `json:"type"`
+	Default interface{} `json:"default"`
+	// Options restricts the setting to a few values.
+	Options     []interface{} `json:"options"`
+	Description string        `json:"description"`
+}
+
+// LoadTheme parses the theme selected by config, failing on a template or setting error.
+func LoadTheme(config ThemeConfig) (*Theme, error) {
+	loaded, err := loadTheme(config)
+	if err != nil {
+		return nil, err
+	}
+	t := &Theme{config: config, loaded: loaded}
+	if config.Reload {
+		if t.stamp, err = t.fingerprint(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// render writes the page name rendered with v and the settings of the theme.
+func (t *Theme) render(w io.Writer, name string, v *view) error {
+	loaded, err := t.current()
+	if err != nil {
+		return err
+	}
+	page, ok := loaded.pages[name]
+	if !ok {
+		return errors.Errorf("no %s page", name)
+	}
+	v.Theme = loaded.settings
+	return page.ExecuteTemplate(w, "layout", v)
+}
+
+// asset returns the asset at name, nil when the theme has none.
+func (t *Theme) asset(name string) (*themeAsset, error) {
+	loaded, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	return loaded.assets[name], nil
+}
+
+// current returns the loaded theme, parsed again first when reloading and its files changed.
+// A theme failing to parse is retried on the next call.
+func (t *Theme) current() (*loadedTheme, error) {
+	if !t.config.Reload {
+		return t.loaded, nil
+	}
+
+	stamp, err := t.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	loaded, fresh := t.loaded, stamp == t.stamp
+	t.mu.RUnlock()
+	if fresh {
+		return loaded, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if stamp != t.stamp {
+		if loaded, err = loadTheme(t.config); err != nil {
+			return nil, errors.Wrap(err, "reload the theme")
+		}
+		t.loaded, t.stamp = loaded, stamp
+	}
+	return t.loaded, nil
+}
+
+// fingerprint hashes the names, sizes and modification times of the files of the themes
+// directory and of the settings file.
+func (t *Theme) fingerprint() (string, error) {
+	h := sha1.New()
+	stamp := func(path string, info fs.FileInfo) {
+		_, _ = fmt.Fprintf(h, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	err := filepath.WalkDir(t.config.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == t.config.Dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stamp(path, info)
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "scan the themes")
+	}
+	if t.config.SettingsFile != "" {
+		info, err := os.Stat(t.config.SettingsFile)
+		if err != nil {
+			return "", errors.Wrap(err, "scan the theme settings")
+		}
+		stamp(t.config.SettingsFile, info)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadTheme(config ThemeConfig) (*loadedTheme, error) {
+	layers, manifests, err := themeLayers(config.Dir, config.Name)
+	if err != nil {
+		return nil, err
+	}
+	values, err := readThemeSettings(config.SettingsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &loadedTheme{}
+	if loaded.settings, err = resolveSettings(manifests, values); err != nil {
+		return nil, errors.Wrapf(err, "theme %s", config.Name)
+	}
+	if loaded.assets, err = loadAssets(layers); err != nil {
+		return nil, errors.Wrapf(err, "theme %s", config.Name)
+	}
+	if loaded.pages, err = parseTemplates(layers, template.FuncMap{"asset": loaded.assetURL}); err != nil {
+		return nil, errors.Wrapf(err, "theme %s", config.Name)
+	}
+	return loaded, nil
+}
+
+// assetURL is the versioned link of an asset, which clients may keep until it changes.
+func (t *loadedTheme) assetURL(name string) (string, error) {
+	a, ok := t.assets[name]
+	if !ok {
+		return "", errors.Errorf("no asset %s", name)
+	}
+	return themeAssetsPath + name + "?v=" + a.version, nil
+}
+
+// themeLayers resolves the theme name and the themes it extends, the root theme first.
+func themeLayers(dir, name string) ([]fs.FS, []themeManifest, error) {
+	var (
+		layers    []fs.FS
+		manifests []themeManifest
+	)
+	seen := make(map[string]bool)
+	for name != "" {
+		if seen[name] {
+			return nil, nil, errors.Errorf("theme %s extends itself", name)
+		}
+		seen[name] = true
+
+		files, err := openTheme(dir, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		var manifest themeManifest
+		data, err := fs.ReadFile(files, themeManifestFile)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "theme %s", name)
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, nil, errors.Wrapf(err, "theme %s: parse %s", name, themeManifestFile)
+		}
+
+		layers = append([]fs.FS{files}, layers...)
+		manifests = append([]themeManifest{manifest}, manifests...)
+		name = manifest.Extends
+	}
+	return layers, manifests, nil
+}
+
+// openTheme returns the files of the theme name, from its directory under dir or, for the
+// default theme, from the binary.
+func openTheme(dir, name string) (fs.FS, error) {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, errors.Errorf("invalid theme name %q", name)
+	}
+
+	root := filepath.Join(dir, name)
+	_, err := os.Stat(filepath.Join(root, themeManifestFile))
+	switch {
+	case err == nil:
+		return os.DirFS(root), nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, errors.Wrapf(err, "theme %s", name)
+	case name == themes.DefaultName:
+		return themes.Default(), nil
+	}
+	return nil, errors.Errorf("no theme %s in %s", name, dir)
+}
+
+// readThemeSettings reads the values of the settings file, none when there is no file.
+func readThemeSettings(file string) (map[string]interface{}, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "read the theme settings")
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, errors.Wrapf(err, "parse the theme settings %s", file)
+	}
+	return values, nil
+}
+
+// resolveSettings checks values against the settings declared by the themes, the root theme
+// first, and fills in the defaults of the others. A theme may declare again a setting of
+// the theme it extends to change its default.
+func resolveSettings(manifests []themeManifest, values map[string]interface{}) (map[string]interface{}, error) {
+	schema := make(map[string]themeSetting)
+	for _, manifest := range manifests {
+		for key, setting := range manifest.Settings {
+			schema[key] = setting
+		}
+	}
+
+	settings := make(map[string]interface{}, len(schema))
+	for key, setting := range schema {
+		if err := setting.check(setting.Default); err != nil {
+			return nil, errors.Wrapf(err, "default of setting %s", key)
+		}
+		settings[key] = setting.Default
+	}
+	for key, value := range values {
+		setting, ok := schema[key]
+		if !ok {
+			return nil, errors.Errorf("unknown setting %s", key)
+		}
+		if err := setting.check(value); err != nil {
+			return nil, errors.Wrapf(err, "setting %s", key)
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// check reports whether v, decoded from JSON, is a valid value of the setting.
+func (s themeSetting) check(v interface{}) error {
+	var ok bool
+	switch s.Type {
+	case "string":
+		_, ok = v.(string)
+	case "bool":
+		_, ok = v.(bool)
+	case "number":
+		_, ok = v.(float64)
+	default:
+		return errors.Errorf("unknown type %q", s.Type)
+	}
+	if !ok {
+		return errors.Errorf("got %v, want a %s", v, s.Type)
+	}
+
+	if len(s.Options) == 0 {
+		return nil
+	}
+	for _, option := range s.Options {
+		if option == v {
+			return nil
+		}
+	}
+	return errors.Errorf("got %v, want one of %v", v, s.Options)
+}
+
+// loadAssets reads the assets of the layers, an asset of a theme replacing the asset of the
+// same name of the theme it extends.
+func loadAssets(layers []fs.FS) (map[string]*themeAsset, error) {
+	assets := make(map[string]*themeAsset)
+	for _, layer := range layers {
+		err := fs.WalkDir(layer, "assets", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if name == "assets" && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			data, err := fs.ReadFile(layer, name)
+			if err != nil {
+				return err
+			}
+			tag := etag.Strong(string(data))
+			assets[strings.TrimPrefix(name, "assets/")] = &themeAsset{
+				data:    data,
+				etag:    tag,
+				version: tag[1:13],
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "read the assets")
+		}
+	}
+	return assets, nil
+}
@@ -0,0 +1,236 @@
+package webhandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/utils/sitelink"
+)
+
+// writeFiles writes files, by path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// renderError renders the error page of theme.
+func renderError(t *testing.T, theme *Theme) string {
+	t.Helper()
+	var b strings.Builder
+	err := theme.render(&b, "error", &view{
+		Site:    site{Title: "Notes", Home: "https://blog.example.com/"},
+		Meta:    meta{Title: "Not Found - Notes", NoIndex: true},
+		Links:   sitelink.New("https://blog.example.com"),
+		Status:  http.StatusNotFound,
+		Message: "page not found",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// darkTheme extends the default theme, changing a setting, the footer and the stylesheet.
+var darkTheme = map[string]string{
+	"dark/theme.json": `{
+		"name": "Dark",
+		"extends": "default",
+		"settings": {
+			"accent_color": {"type": "string", "default": "#ffb000"},
+			"contrast": {"type": "string", "default": "normal", "options": ["normal", "high"]}
+		}
+	}`,
+	"dark/partials/footer.html": `{{define "footer"}}<footer class="{{.Theme.contrast}}">dark</footer>{{end}}`,
+	"dark/assets/style.css":     `body { background: black; }`,
+	"dark/assets/dark.js":       `// nothing yet`,
+}
+
+func TestDefaultTheme(t *testing.T) {
+	// without a default directory, the built-in theme is used
+	theme, err := LoadTheme(ThemeConfig{Dir: filepath.Join(t.TempDir(), "themes"), Name: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := renderError(t, theme)
+	for _, want := range []string{"<title>Not Found - Notes</title>", "404 page not found", "--accent: #0645ad", `<body class="serif">`, `<meta name="robots" content="noindex">`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("the page lacks %q:\n%s", want, page)
+		}
+	}
+	if asset, err := theme.asset("style.css"); err != nil || asset == nil {
+		t.Errorf("style.css: %v, %v", asset, err)
+	}
+}
+
+func TestThemeInheritance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, darkTheme)
+	base, err := LoadTheme(ThemeConfig{Dir: dir, Name: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme, err := LoadTheme(ThemeConfig{Dir: dir, Name: "dark"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := renderError(t, theme)
+	// the blocks of the child replace those of the parent layout, the others are kept
+	for _, want := range []string{`<footer class="normal">dark</footer>`, "--accent: #ffb000", `<body class="serif">`, "404 page not found"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("the page lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<footer>") {
+		t.Errorf("the footer of the parent is kept:\n%s", page)
+	}
+
+	style, _ := theme.asset("style.css")
+	baseStyle, _ := base.asset("style.css")
+	if style == nil || string(style.data) != darkTheme["dark/assets/style.css"] || style.version == baseStyle.version {
+		t.Errorf("style.css of the child %+v", style)
+	}
+	if !strings.Contains(page, `href="/theme/style.css?v=`+style.version+`"`) {
+		t.Errorf("the page does not link the stylesheet of the child:\n%s", page)
+	}
+	if js, _ := theme.asset("dark.js"); js == nil {
+		t.Error("no asset of the child")
+	}
+}
+
+func TestThemeSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, darkTheme)
+	writeFiles(t, dir, map[string]string{
+		"loop/theme.json":  `{"extends": "loop2"}`,
+		"loop2/theme.json": `{"extends": "loop"}`,
+		"broken/theme.json": `{"extends": "default", "settings": {
+			"columns": {"type": "number", "default": "two"}
+		}}`,
+		"settings.json": `{"contrast": "high", "show_comments": false, "accent_color": "#123456"}`,
+	})
+	theme, err := LoadTheme(ThemeConfig{Dir: dir, Name: "dark", SettingsFile: filepath.Join(dir, "settings.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := renderError(t, theme); !strings.Contains(page, `<footer class="high">`) || !strings.Contains(page, "--accent: #123456") {
+		t.Errorf("the settings are not applied:\n%s", page)
+	}
+
+	for _, tt := range []struct {
+		name, theme, settings string
+	}{
+		{"unknown setting", "dark", `{"columns": 2}`},
+		{"wrong type", "dark", `{"show_comments": "no"}`},
+		{"not an option", "dark", `{"contrast": "low"}`},
+		{"not an object", "dark", `["high"]`},
+		{"invalid default", "broken", `{}`},
+		{"unknown theme", "light", `{}`},
+		{"invalid name", "../dark", `{}`},
+		{"cycle", "loop", `{}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "settings.json")
+			writeFiles(t, filepath.Dir(file), map[string]string{"settings.json": tt.settings})
+			if _, err := LoadTheme(ThemeConfig{Dir: dir, Name: tt.theme, SettingsFile: file}); err == nil {
+				t.Error("the theme loaded")
+			}
+		})
+	}
+}
+
+func TestThemeReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, darkTheme)
+	theme, err := LoadTheme(ThemeConfig{Dir: dir, Name: "dark", Reload: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := renderError(t, theme); !strings.Contains(page, ">dark</footer>") {
+		t.Fatalf("page:\n%s", page)
+	}
+
+	// change writes a partial of the theme, with a modification time telling it apart
+	change := func(content string, mtime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, "dark", "partials", "footer.html")
+		writeFiles(t, dir, map[string]string{"dark/partials/footer.html": content})
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+
+	change(`{{define "footer"}}<footer>night</footer>{{end}}`, now.Add(time.Minute))
+	if page := renderError(t, theme); !strings.Contains(page, "<footer>night</footer>") {
+		t.Errorf("the change is not picked up:\n%s", page)
+	}
+
+	// a broken template fails the pages until it is fixed
+	change(`{{define "footer"}}<footer>{{.Nope</footer>{{end}}`, now.Add(2*time.Minute))
+	if err := theme.render(&strings.Builder{}, "error", &view{}); err == nil {
+		t.Error("a broken template rendered")
+	}
+	change(`{{define "footer"}}<footer>dawn</footer>{{end}}`, now.Add(3*time.Minute))
+	if page := renderError(t, theme); !strings.Contains(page, "<footer>dawn</footer>") {
+		t.Errorf("the fix is not picked up:\n%s", page)
+	}
+}
+
+func TestAssetHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	writeFiles(t, dir, darkTheme)
+	theme, err := LoadTheme(ThemeConfig{Dir: dir, Name: "dark"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	NewThemeHandler(r.Group(""), theme)
+	style, _ := theme.asset("style.css")
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/theme/style.css?v="+style.version, nil)
+	if w.Code != http.StatusOK || w.Body.String() != darkTheme["dark/assets/style.css"] || w.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("versioned asset: status %d, Cache-Control %q: %s", w.Code, w.Header().Get("Cache-Control"), w.Body)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("Content-Type %q", w.Header().Get("Content-Type"))
+	}
+	// a stale or missing version is revalidated
+	w = get("/theme/style.css?v=stale", nil)
+	if w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("ETag") != style.etag {
+		t.Errorf("unversioned asset: Cache-Control %q, ETag %q", w.Header().Get("Cache-Control"), w.Header().Get("ETag"))
+	}
+	if w = get("/theme/style.css", http.Header{"If-None-Match": {style.etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("revalidation: status %d", w.Code)
+	}
+	// the parent assets are served as well
+	if w = get("/theme/missing.css", nil); w.Code != http.StatusNotFound {
+		t.Errorf("missing asset: status %d", w.Code)
+	}
+}
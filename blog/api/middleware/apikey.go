@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"blog/utils/httputil"
+	"blog/utils/ratelimit"
+)
+
+// APIKeyHeader carries the API key identifying the caller of a request.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey identifies the callers sending one of keys in the X-API-Key header and stores
+// them in the request context. Unknown keys are ignored, their requests stay anonymous.
+func APIKey(keys ratelimit.APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if name, digest, ok := keys.Caller(c.GetHeader(APIKeyHeader)); ok {
+			caller := httputil.Caller{Name: name, Key: digest}
+			c.Request = c.Request.WithContext(httputil.WithCaller(c.Request.Context(), caller))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl sets the Cache-Control policy of the successful GET and HEAD responses of
+// a route group. Error responses override it with no-store.
+func CacheControl(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy != "" && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			c.Writer.Header().Set("Cache-Control", policy)
+		}
+		c.Next()
+	}
+}
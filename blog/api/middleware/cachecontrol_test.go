@@ -0,0 +1,36 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/middleware"
+)
+
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	g := r.Group("", middleware.CacheControl("public, max-age=60"))
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost} {
+		g.Handle(method, "/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	r.GET("/users", middleware.CacheControl(""), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tt := range []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "/posts", "public, max-age=60"},
+		{http.MethodHead, "/posts", "public, max-age=60"},
+		{http.MethodPost, "/posts", ""},
+		{http.MethodGet, "/users", ""},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s %s: Cache-Control %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
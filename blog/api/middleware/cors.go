@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"blog/utils/httputil"
+)
+
+// CORSOptions lists what cross-origin callers are allowed to do.
+type CORSOptions struct {
+	// AllowedOrigins holds exact origins, "*" for any origin, wildcard patterns such as
+	// "https://*.example.com", or regular expressions prefixed with "regex:" matching the
+	// whole origin. "*" cannot be combined with AllowCredentials.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func newOriginMatcher(origins []string) (*originMatcher, error) {
+	m := &originMatcher{exact: map[string]bool{}}
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "":
+		case origin == "*":
+			m.any = true
+		case strings.HasPrefix(origin, "regex:"):
+			re, err := regexp.Compile("^(?:" + strings.TrimPrefix(origin, "regex:") + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid origin pattern %q", origin)
+			}
+			m.patterns = append(m.patterns, re)
+		case strings.Contains(origin, "*"):
+			// a wildcard stands for any run of characters within the host and port
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[^/]+`)
+			m.patterns = append(m.patterns, regexp.MustCompile("^"+pattern+"$"))
+		default:
+			m.exact[strings.ToLower(origin)] = true
+		}
+	}
+	return m, nil
+}
+
+func (m *originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS answers the preflight requests of the allowed origins and decorates their actual
+// requests with httputil.CORSDecorator. Requests of other origins go through without CORS
+// headers, so browsers keep their responses from the calling page, and their preflight
+// requests are refused.
+func CORS(opts CORSOptions) (gin.HandlerFunc, error) {
+	origins, err := newOriginMatcher(opts.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	if origins.any && opts.AllowCredentials {
+		return nil, errors.New("credentials cannot be allowed to any origin")
+	}
+
+	methods := map[string]bool{}
+	for _, method := range opts.AllowedMethods {
+		methods[strings.ToUpper(strings.TrimSpace(method))] = true
+	}
+	anyHeader := false
+	headers := map[string]bool{}
+	for _, header := range opts.AllowedHeaders {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		anyHeader = anyHeader || header == "*"
+		headers[header] = true
+	}
+
+	allowMethods := strings.Join(opts.AllowedMethods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	decorator := func(origin string) *httputil.CORSDecorator {
+		allowed := origin
+		if origins.any {
+			allowed = "*"
+		}
+		return httputil.NewCORSDecorator(allowed, opts.AllowCredentials, opts.ExposedHeaders...)
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		requestedMethod := c.GetHeader("Access-Control-Request-Method")
+		preflight := c.Request.Method == http.MethodOptions && requestedMethod != ""
+		if !preflight {
+			if origins.match(origin) {
+				decorator(origin).Decorate(c.Writer)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		if !origins.match(origin) || !methods[strings.ToUpper(requestedMethod)] || !headersAllowed(c.GetHeader("Access-Control-Request-Headers"), headers, anyHeader) {
+			httputil.WriteErrorResponse(c.Writer, http.StatusForbidden, []httputil.StandardError{{
+				Code:   strconv.Itoa(http.StatusForbidden),
+				Title:  http.StatusText(http.StatusForbidden),
+				Detail: "cross-origin request not allowed",
+			}})
+			c.Abort()
+			return
+		}
+
+		decorator(origin).Decorate(c.Writer)
+		c.Writer.Header().Set("Access-Control-Allow-Methods", allowMethods)
+		if anyHeader {
+			// "*" is not a wildcard for credentialed requests, echo what was asked instead
+			c.Writer.Header().Set("Access-Control-Allow-Headers", c.GetHeader("Access-Control-Request-Headers"))
+		} else {
+			c.Writer.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if opts.MaxAge > 0 {
+			c.Writer.Header().Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}, nil
+}
+
+func headersAllowed(requested string, allowed map[string]bool, anyHeader bool) bool {
+	if anyHeader {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !allowed[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
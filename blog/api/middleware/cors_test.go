@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cors, err := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   []string{"https://blog.example.com", "https://*.example.org", `regex:https://(www\.)?example\.net`},
+		AllowedMethods:   []string{"GET", "PATCH"},
+		AllowedHeaders:   []string{"Content-Type", "If-Match"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(cors)
+	r.Any("/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	do := func(method, origin string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/posts", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tt := range []struct {
+		origin string
+		want   bool
+	}{
+		{"https://blog.example.com", true},
+		{"https://BLOG.example.com", true},
+		{"https://api.example.org", true},
+		{"https://example.net", true},
+		{"https://www.example.net", true},
+		// patterns match whole origins
+		{"https://example.net.evil.com", false},
+		{"https://evil.com/https://example.net", false},
+		{"https://a.b/.example.org", false},
+		{"http://blog.example.com", false},
+	} {
+		w := do(http.MethodGet, tt.origin)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET from %s: status %d", tt.origin, w.Code)
+		}
+		allowed := w.Header().Get("Access-Control-Allow-Origin")
+		if got := allowed == tt.origin; got != tt.want {
+			t.Errorf("GET from %s: allowed origin %q, want allowed %v", tt.origin, allowed, tt.want)
+		}
+		if tt.want && (w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Access-Control-Expose-Headers") != "ETag") {
+			t.Errorf("GET from %s: headers %v", tt.origin, w.Header())
+		}
+	}
+	if w := do(http.MethodGet, ""); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("a same-origin request got CORS headers")
+	}
+
+	// preflight requests
+	w := do(http.MethodOptions, "https://blog.example.com", "Access-Control-Request-Method", "PATCH", "Access-Control-Request-Headers", "if-match, content-type")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, PATCH" || w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("preflight: status %d, headers %v", w.Code, w.Header())
+	}
+	for _, header := range [][]string{
+		{"Access-Control-Request-Method", "DELETE"},
+		{"Access-Control-Request-Method", "PATCH", "Access-Control-Request-Headers", "X-Secret"},
+	} {
+		if w := do(http.MethodOptions, "https://blog.example.com", header...); w.Code != http.StatusForbidden {
+			t.Errorf("preflight %q: status %d, want 403", header, w.Code)
+		}
+	}
+	if w := do(http.MethodOptions, "https://evil.com", "Access-Control-Request-Method", "GET"); w.Code != http.StatusForbidden {
+		t.Errorf("preflight of another origin: status %d, want 403", w.Code)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if _, err := middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Error("credentials were allowed to any origin")
+	}
+	if _, err := middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"regex:("}}); err == nil {
+		t.Error("an invalid pattern was accepted")
+	}
+
+	cors, err := middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(cors)
+	r.GET("/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("headers %v, want any origin without credentials", w.Header())
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation marks the responses of a deprecated route group with the Deprecation (RFC 9745)
+// and, when sunset is set, Sunset (RFC 8594) headers. A successor-version link points each
+// request under prefix to the same path under successor.
+func Deprecation(prefix, successor string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	var sunsetDate string
+	if !sunset.IsZero() {
+		sunsetDate = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		if sunsetDate != "" {
+			header.Set("Sunset", sunsetDate)
+		}
+		path := strings.TrimPrefix(c.Request.URL.EscapedPath(), prefix)
+		header.Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor, path))
+		c.Next()
+	}
+}
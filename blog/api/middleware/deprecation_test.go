@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/middleware"
+)
+
+func TestDeprecation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 19, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	r := gin.New()
+	legacy := r.Group("/api", middleware.Deprecation("/api", "/api/v1", deprecatedAt, sunset))
+	legacy.GET("/posts/:name", func(c *gin.Context) { c.Status(http.StatusOK) })
+	current := r.Group("/api/v2", middleware.Deprecation("/api/v2", "/api/v3", deprecatedAt, time.Time{}))
+	current.GET("/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/v1/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/posts/notes%20on%20engines?page=2", nil))
+	for header, want := range map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Sun, 18 Apr 2027 22:00:00 GMT",
+		"Link":        `</api/v1/posts/notes%20on%20engines>; rel="successor-version"`,
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s: %q, want %q", header, got, want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/posts", nil))
+	if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") != "" {
+		t.Errorf("without a sunset date: headers %v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/posts", nil))
+	if w.Header().Get("Deprecation") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("a current route is deprecated: headers %v", w.Header())
+	}
+}
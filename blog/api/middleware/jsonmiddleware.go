@@ -1,10 +0,0 @@
-package middleware
-
-import "github.com/gin-gonic/gin"
-
-func JSONMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Content-Type", "application/json")
-		c.Next()
-	}
-}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"blog/utils/httputil"
+	"blog/utils/log"
+	"blog/utils/tracing"
+)
+
+// RequestLogger stores in the request context a logger annotated with the request id, the
+// route, the user the route is about and the trace of the request, for the handlers and
+// usecases to log through.
+func RequestLogger(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requestID := httputil.RequestID(c.Request.Context())
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+
+		fields := []zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("route", route),
+		}
+		if userID := c.Param("user_id"); userID != "" {
+			fields = append(fields, zap.String("user_id", userID))
+		}
+		fields = append(fields, tracing.LogFields(c.Request.Context())...)
+
+		c.Request = c.Request.WithContext(log.WithContext(c.Request.Context(), base.With(fields...)))
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/utils/httputil"
+)
+
+// ContentNegotiation picks the encoding of the responses of the routes under prefix from the
+// Accept header: JSON by default, XML, YAML or MessagePack when asked for. Requests accepting
+// none of them get 406 Not Acceptable. Other routes, such as the UI and the docs, keep their
+// own content types.
+func ContentNegotiation(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		// shared caches must keep one copy per encoding
+		c.Writer.Header().Add("Vary", "Accept")
+
+		contentType, ok := httputil.Negotiate(c.Request.Header.Get("Accept"))
+		if !ok {
+			httputil.WriteErrorResponse(c.Writer, http.StatusNotAcceptable, []httputil.StandardError{{
+				Code:   strconv.Itoa(http.StatusNotAcceptable),
+				Title:  http.StatusText(http.StatusNotAcceptable),
+				Detail: "accept one of " + strings.Join(httputil.SupportedMediaTypes(), ", "),
+			}})
+			c.Abort()
+			return
+		}
+
+		c.Writer.Header().Set("Content-Type", contentType)
+		c.Next()
+	}
+}
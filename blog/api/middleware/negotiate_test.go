@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/api/middleware"
+	"blog/utils/httputil"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ContentNegotiation("/api"))
+	write := func(c *gin.Context) {
+		data, err := httputil.Encode(c.Writer, gin.H{"title": "notes"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = httputil.WriteResponse(c.Writer, data, http.StatusOK)
+	}
+	r.GET("/api/posts", write)
+	r.GET("/index.html", func(c *gin.Context) { c.Data(http.StatusOK, "text/html", []byte("<html></html>")) })
+
+	for _, tt := range []struct {
+		path, accept string
+		status       int
+		contentType  string
+	}{
+		{"/api/posts", "", http.StatusOK, httputil.MIMEJSON},
+		{"/api/posts", "application/xml", http.StatusOK, httputil.MIMEXML},
+		{"/api/posts", "text/yaml", http.StatusOK, httputil.MIMEYAML},
+		{"/api/posts", "application/x-msgpack", http.StatusOK, httputil.MIMEMsgPack},
+		{"/api/posts", "text/html", http.StatusNotAcceptable, "application/json"},
+		// other routes keep their own content type
+		{"/index.html", "text/html", http.StatusOK, "text/html"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s accepting %q: status %d, Content-Type %q, want %d, %q", tt.path, tt.accept, w.Code, w.Header().Get("Content-Type"), tt.status, tt.contentType)
+		}
+		if vary := w.Header().Get("Vary"); (vary == "Accept") != (tt.path == "/api/posts") {
+			t.Errorf("GET %s: Vary %q", tt.path, vary)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"blog/domain/interfaces"
+	"blog/utils/httputil"
+	"blog/utils/log"
+	"blog/utils/metrics"
+	"blog/utils/ratelimit"
+)
+
+// RateLimit applies policy to the requests of a route group, answering 429 with a
+// Retry-After header once the bucket of the caller is empty. Requests go through when the
+// store fails, as a broken limiter must not take the API down with it.
+func RateLimit(store interfaces.RateLimitStore, group string, policy ratelimit.Policy) gin.HandlerFunc {
+	if !policy.Enabled() {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := group + ":" + rateLimitKey(c, policy.Key)
+		allowed, retryAfter, err := store.Take(c.Request.Context(), key, policy.Rate, policy.Burst)
+		if err != nil {
+			log.FromContext(c.Request.Context()).Warn("rate limiter unavailable", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			metrics.RateLimited(group)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			httputil.WriteErrorResponse(c.Writer, http.StatusTooManyRequests, []httputil.StandardError{{
+				Code:   strconv.Itoa(http.StatusTooManyRequests),
+				Title:  http.StatusText(http.StatusTooManyRequests),
+				Detail: fmt.Sprintf("rate limit exceeded, retry in %ds", seconds),
+			}})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey names the bucket of the caller. Only the callers identified by the APIKey
+// middleware have a bucket of their own under the user and API key policies, anonymous
+// requests share the bucket of their address.
+func rateLimitKey(c *gin.Context, key ratelimit.Key) string {
+	if caller, ok := httputil.CallerOf(c.Request.Context()); ok {
+		switch key {
+		case ratelimit.KeyUser:
+			return "user:" + caller.Name
+		case ratelimit.KeyAPIKey:
+			return "api_key:" + caller.Key
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,119 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"blog/api/middleware"
+	"blog/utils/httputil"
+	"blog/utils/ratelimit"
+)
+
+// recordingStore refuses nothing and records the buckets taken from.
+type recordingStore struct {
+	keys []string
+	err  error
+}
+
+func (s *recordingStore) Take(_ context.Context, key string, _ float64, _ int) (bool, time.Duration, error) {
+	s.keys = append(s.keys, key)
+	return true, 0, s.err
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := ratelimit.ParseAPIKeys([]string{"ada=first", "ada=second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		key            ratelimit.Key
+		apiKey, userID string
+		want           string
+	}{
+		{ratelimit.KeyIP, "first", "1", "ip:192.0.2.1"},
+		{ratelimit.KeyUser, "first", "1", "user:ada"},
+		{ratelimit.KeyUser, "second", "2", "user:ada"},
+		// the path names no one, and unknown keys are anonymous
+		{ratelimit.KeyUser, "", "1", "ip:192.0.2.1"},
+		{ratelimit.KeyUser, "forged", "1", "ip:192.0.2.1"},
+		{ratelimit.KeyAPIKey, "first", "1", "api_key:" + ratelimit.Digest("first")},
+		{ratelimit.KeyAPIKey, "forged", "1", "ip:192.0.2.1"},
+	} {
+		store := &recordingStore{}
+		r := gin.New()
+		r.Use(middleware.APIKey(keys))
+		r.GET("/user/:user_id", middleware.RateLimit(store, "users", ratelimit.Policy{Rate: 1, Burst: 1, Key: tt.key}),
+			func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/user/"+tt.userID, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if tt.apiKey != "" {
+			req.Header.Set(middleware.APIKeyHeader, tt.apiKey)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if want := "users:" + tt.want; len(store.keys) != 1 || store.keys[0] != want {
+			t.Errorf("%s policy with key %q: buckets %q, want %s", tt.key, tt.apiKey, store.keys, want)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/posts", middleware.RateLimit(ratelimit.NewMemory(), "posts", ratelimit.Policy{Rate: 1.0 / 60, Burst: 2, Key: ratelimit.KeyIP}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get("192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+	}
+	w := get("192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d past the burst, want 429", w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Retry-After %q, want 60", retryAfter)
+	}
+	var body struct {
+		Errors []httputil.StandardError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || len(body.Errors) != 1 || body.Errors[0].Code != "429" {
+		t.Errorf("body %s, want one 429 error: %v", w.Body, err)
+	}
+	if w := get("192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("another address: status %d", w.Code)
+	}
+
+	// a failing store lets requests through, and a disabled policy takes no token
+	store := &recordingStore{err: errors.New("down")}
+	r = gin.New()
+	r.GET("/failing", middleware.RateLimit(store, "posts", ratelimit.Policy{Rate: 1, Burst: 1}), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/off", middleware.RateLimit(store, "tags", ratelimit.Policy{}), func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, path := range []string{"/failing", "/off"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d", path, w.Code)
+		}
+	}
+	if len(store.keys) != 1 {
+		t.Errorf("buckets %q, want only the one of the failing route", store.keys)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"blog/utils/httputil"
+)
+
+// validRequestID bounds the ids accepted from clients, as they end up in headers and logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID reuses the X-Request-ID sent by the client, or generates one, stores it in
+// the request context and echoes it in the response header. The response writers copy
+// it into the meta and errors of the response body.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(httputil.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Writer.Header().Set(httputil.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(httputil.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -7,20 +7,28 @@ import (
 	"github.com/go-openapi/runtime/middleware"
 )
 
-func Middleware() http.Handler {
+// Middleware serves the Redoc page of spec, the YAML OpenAPI description served at
+// /docs/swagger.yaml.
+func Middleware(spec []byte) http.Handler {
 	var r middleware.RedocOpts
 	// Override default path to your swagger.json/swagger.yaml file
 	r.SpecURL = "/docs/swagger.yaml"
-	return middleware.Redoc(r, serverStatic())
+	return middleware.Redoc(r, serverStatic(spec))
 }
 
-func serverStatic() http.Handler {
+func serverStatic(spec []byte) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Shortcut helpers for swagger-ui
 		if r.URL.Path == "/swagger-ui" || r.URL.Path == "/docs/" {
 			http.Redirect(w, r, "/docs", http.StatusFound)
 			return
 		}
+		// the description generated from the handlers
+		if r.URL.Path == "/docs/swagger.yaml" {
+			w.Header().Set("Content-Type", "application/x-yaml")
+			_, _ = w.Write(spec)
+			return
+		}
 		// Serving swagger-ui
 		if strings.Index(r.URL.Path, "/docs") == 0 {
 			http.StripPrefix("/docs", http.FileServer(http.Dir("docs"))).ServeHTTP(w, r)
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+type accountUsecase struct {
+	db       *gorm.DB
+	mailer   interfaces.Mailer
+	settings dto.AccountSettings
+	cache    *ReadCache
+}
+
+// NewAccountUsecase sends the account tokens of settings with mailer.
+func NewAccountUsecase(db *gorm.DB, mailer interfaces.Mailer, settings dto.AccountSettings, cache *ReadCache) interfaces.AccountUsecase {
+	return &accountUsecase{
+		db:       db,
+		mailer:   mailer,
+		settings: settings,
+		cache:    cache,
+	}
+}
+
+func (uc *accountUsecase) SendVerification(ctx *gin.Context, userID int64) error {
+	db, span := instrument(ctx, uc.db, "account", "SendVerification")
+	defer span.End()
+
+	var user dto.User
+	if err := db.Where("id = ?", userID).Take(&user).Error; err != nil {
+		return errors.Wrapf(err, "user %d", userID)
+	}
+	if user.Email == nil {
+		return errors.Wrapf(dto.ErrNoEmail, "user %d", userID)
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.Wrapf(dto.ErrEmailVerified, "user %d", userID)
+	}
+	return uc.send(ctx, db, &user, dto.TokenVerifyEmail)
+}
+
+func (uc *accountUsecase) VerifyEmail(ctx *gin.Context, token string) (*dto.User, error) {
+	db, span := instrument(ctx, uc.db, "account", "VerifyEmail")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	tx := db.Begin()
+	user, err := consumeToken(tx, dto.TokenVerifyEmail, token)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	now := time.Now()
+	err = tx.Model(&dto.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"email_verified_at": now,
+		"updated_at":        now,
+		"version":           gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	var resp dto.User
+	if err := db.Where("id = ?", user.ID).Take(&resp).Error; err != nil {
+		return nil, err
+	}
+	logger(ctx).Info("email verified", zap.Int64("user_id", user.ID))
+	return &resp, nil
+}
+
+func (uc *accountUsecase) RequestPasswordReset(ctx *gin.Context, email string) error {
+	db, span := instrument(ctx, uc.db, "account", "RequestPasswordReset")
+	defer span.End()
+
+	// only a verified email can recover an account, and only one with a password to reset:
+	// anyone can set the email of a user without one
+	var user dto.User
+	err := db.Where("email = ? AND email_verified_at IS NOT NULL AND password_hash <> ''", strings.ToLower(email)).Take(&user).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger(ctx).Info("password reset requested for an unknown, unverified or passwordless email")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return uc.send(ctx, db, &user, dto.TokenResetPassword)
+}
+
+func (uc *accountUsecase) ResetPassword(ctx *gin.Context, token, password string) error {
+	db, span := instrument(ctx, uc.db, "account", "ResetPassword")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	user, err := consumeToken(tx, dto.TokenResetPassword, token)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	now := time.Now()
+	err = tx.Model(&dto.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"password_hash": hash,
+		"updated_at":    now,
+		"version":       gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// the other links sent are void once the password changed
+	err = tx.Model(&dto.AccountToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, dto.TokenResetPassword).
+		UpdateColumn("used_at", now).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	logger(ctx).Info("password reset", zap.Int64("user_id", user.ID))
+	return nil
+}
+
+// send stores a new token for purpose and emails its link to the user.
+func (uc *accountUsecase) send(ctx *gin.Context, db *gorm.DB, user *dto.User, purpose dto.TokenPurpose) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	ttl, page := uc.settings.VerifyTTL, uc.settings.VerifyURL
+	if purpose == dto.TokenResetPassword {
+		ttl, page = uc.settings.ResetTTL, uc.settings.ResetURL
+	}
+	link, err := url.Parse(page)
+	if err != nil {
+		return errors.Wrapf(err, "link of the %s emails", purpose)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	row := dto.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Hash:      tokenHash(token),
+		Email:     *user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := db.Create(&row).Error; err != nil {
+		return err
+	}
+
+	mail := &dto.Mail{To: *user.Email}
+	switch purpose {
+	case dto.TokenVerifyEmail:
+		mail.Subject = "Verify your email on " + uc.settings.SiteTitle
+		mail.Text = fmt.Sprintf("Hello %s,\n\nOpen this link to verify that %s is your email:\n\n%s\n\n"+
+			"The link expires in %s. If you did not register on %s, ignore this email.\n",
+			user.Name, *user.Email, link, readableDuration(ttl), uc.settings.SiteTitle)
+	case dto.TokenResetPassword:
+		mail.Subject = "Reset your password on " + uc.settings.SiteTitle
+		mail.Text = fmt.Sprintf("Hello %s,\n\nOpen this link to choose a new password:\n\n%s\n\n"+
+			"The link expires in %s and works once. If you did not ask for it, ignore this email: "+
+			"your password stays as it is.\n",
+			user.Name, link, readableDuration(ttl))
+	}
+	if err := uc.mailer.Send(requestContext(ctx), mail); err != nil {
+		return errors.Wrapf(err, "send the %s email", purpose)
+	}
+
+	logger(ctx).Info("account email sent", zap.Int64("user_id", user.ID), zap.String("purpose", string(purpose)))
+	return nil
+}
+
+// consumeToken marks the token used and returns its user. It must be unused, unexpired and
+// sent to the email the user still has.
+func consumeToken(tx *gorm.DB, purpose dto.TokenPurpose, token string) (*dto.User, error) {
+	var row dto.AccountToken
+	err := tx.Where("hash = ? AND purpose = ?", tokenHash(token), purpose).Take(&row).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, dto.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := tx.Model(&dto.AccountToken{}).Where("id = ? AND used_at IS NULL AND expires_at > ?", row.ID, now).UpdateColumn("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, errors.Wrap(dto.ErrInvalidToken, "used or expired")
+	}
+
+	var user dto.User
+	err = tx.Where("id = ?", row.UserID).Take(&user).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, errors.Wrap(dto.ErrInvalidToken, "the user is deleted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == nil || *user.Email != row.Email {
+		return nil, errors.Wrap(dto.ErrInvalidToken, "the email of the user changed")
+	}
+	return &user, nil
+}
+
+// newToken returns 256 random bits, encoded for URLs.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// tokenHash is what is stored of a token: enough to recognize it, useless to whoever reads
+// the database.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// readableDuration writes d in the largest whole unit, such as "24 hours".
+func readableDuration(d time.Duration) string {
+	n, unit := int64(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int64(d/time.Hour), "hour"
+	}
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// checkPassword verifies that password is the one of the user, if they have one.
+func checkPassword(user *dto.User, password string) error {
+	if user.PasswordHash == "" {
+		return nil
+	}
+	if password == "" {
+		return errors.Wrap(dto.ErrWrongPassword, "the current password is required")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.Wrapf(dto.ErrWrongPassword, "user %d", user.ID)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.Wrap(err, "hash the password")
+	}
+	return string(hash), nil
+}
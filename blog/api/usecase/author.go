@@ -1,51 +1,130 @@
 package usecase
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/friendsofgo/errors"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
 
 	"blog/domain/dto"
 	"blog/domain/interfaces"
 )
 
 type userUsecase struct {
-	db *gorm.DB
+	db       *gorm.DB
+	cascade  dto.CascadeMode
+	cache    *ReadCache
+	accounts interfaces.AccountUsecase
 }
 
-func NewUserUsecase(db *gorm.DB) interfaces.UserUsecase {
+// NewUserUsecase has accounts verify the emails of the users, unless it is nil.
+func NewUserUsecase(db *gorm.DB, cascade dto.CascadeMode, cache *ReadCache, accounts interfaces.AccountUsecase) interfaces.UserUsecase {
 	return &userUsecase{
-		db: db,
+		db:       db,
+		cascade:  cascade,
+		cache:    cache,
+		accounts: accounts,
 	}
 }
 
 func (uc *userUsecase) GetUserById(ctx *gin.Context, userID int64) (*dto.User, error) {
+	db, span := instrument(ctx, uc.db, "user", "GetUserById")
+	defer span.End()
+	key := fmt.Sprintf("id:%d", userID)
+
 	var user dto.User
-	res := uc.db.Debug().Find(&user, &dto.User{ID: userID})
+	if uc.cache.get(ctx, usersNamespace, key, &user) {
+		return &user, nil
+	}
+
+	res := db.Find(&user, &dto.User{ID: userID})
 	if res.RecordNotFound() {
-		return nil, errors.New("user does not exist")
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "user %d", userID)
 	}
 
+	uc.cache.set(ctx, usersNamespace, key, &user)
 	return &user, nil
 }
 
 func (uc *userUsecase) GetAllUsers(ctx *gin.Context, limit int, offset int) (*[]dto.User, error) {
+	db, span := instrument(ctx, uc.db, "user", "GetAllUsers")
+	defer span.End()
+	key := fmt.Sprintf("list:%d:%d", limit, offset)
+
 	users := []dto.User{}
-	err := uc.db.Model(&dto.User{}).Limit(limit).Find(&users).Error
+	if uc.cache.get(ctx, usersNamespace, key, &users) {
+		return &users, nil
+	}
+
+	err := db.Model(&dto.User{}).Limit(limit).Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
 
+	uc.cache.set(ctx, usersNamespace, key, users)
 	return &users, nil
 }
 
+func (uc *userUsecase) GetUsersByIds(ctx *gin.Context, userIDs []int64) ([]dto.User, error) {
+	db, span := instrument(ctx, uc.db, "user", "GetUsersByIds")
+	defer span.End()
+
+	users := []dto.User{}
+	err := inBatches(userIDs, func(ids []int64) error {
+		var batch []dto.User
+		if err := db.Where("id IN (?)", ids).Find(&batch).Error; err != nil {
+			return err
+		}
+		users = append(users, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (uc *userUsecase) CreateUser(ctx *gin.Context, request *dto.User) (dto.CreateUserResponse, error) {
-	err := uc.db.Debug().Create(&request).Error
+	db, span := instrument(ctx, uc.db, "user", "CreateUser")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace)
+
+	// versions are managed by the server, and a new user has neither verified an email nor
+	// uploaded an image to pick as avatar
+	request.Version = 0
+	if err := checkName("", request.Name); err != nil {
+		return dto.CreateUserResponse{}, err
+	}
+	request.EmailVerifiedAt = nil
+	if request.AvatarID != nil {
+		return dto.CreateUserResponse{}, errors.Wrap(dto.ErrInvalidAvatar, "a new user has no images yet")
+	}
+	if request.Email != nil {
+		email := strings.ToLower(*request.Email)
+		if err := checkEmail(db, 0, email); err != nil {
+			return dto.CreateUserResponse{}, err
+		}
+		request.Email = &email
+	}
+	request.PasswordHash = ""
+	if request.Password != "" {
+		hash, err := hashPassword(request.Password)
+		if err != nil {
+			return dto.CreateUserResponse{}, err
+		}
+		request.PasswordHash, request.Password = hash, ""
+	}
+	err := db.Create(&request).Error
 	if err != nil {
 		return dto.CreateUserResponse{}, err
 	}
+	if request.Email != nil {
+		uc.sendVerification(ctx, request.ID)
+	}
 
 	return dto.CreateUserResponse{
 		ID:        request.ID,
@@ -56,18 +135,57 @@ func (uc *userUsecase) CreateUser(ctx *gin.Context, request *dto.User) (dto.Crea
 }
 
 func (uc *userUsecase) UpdateUser(ctx *gin.Context, authorID int64, request *dto.UpdateUserBodyRequest) (*dto.User, error) {
+	db, span := instrument(ctx, uc.db, "user", "UpdateUser")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
 	user := map[string]interface{}{
 		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
 	}
 
+	var current dto.User
+	if err := db.Where("id = ?", authorID).Take(&current).Error; err != nil {
+		return &dto.User{}, err
+	}
 	if len(request.Name) != 0 {
+		if err := checkName(current.Name, request.Name); err != nil {
+			return &dto.User{}, err
+		}
 		user["name"] = request.Name
 	}
-	res := uc.db.Model(&dto.User{}).Where("id=?", authorID).Take(&dto.User{}).UpdateColumns(user)
+	if len(request.DisplayName) != 0 {
+		user["display_name"] = request.DisplayName
+	}
+	if len(request.Bio) != 0 {
+		user["bio"] = request.Bio
+	}
+	if len(request.Website) != 0 {
+		user["website"] = request.Website
+	}
+	if request.SocialLinks != nil {
+		user["social_links"] = request.SocialLinks
+	}
+	if request.AvatarID != 0 {
+		if err := checkAvatar(db, authorID, request.AvatarID); err != nil {
+			return &dto.User{}, err
+		}
+		user["avatar_id"] = request.AvatarID
+	}
+	if len(request.Email) != 0 {
+		if err := emailColumns(db, &current, request.Email, request.CurrentPassword, user); err != nil {
+			return &dto.User{}, err
+		}
+	}
+	res := db.Model(&dto.User{}).Where("id=?", authorID).Take(&dto.User{}).UpdateColumns(user)
 	if res.Error != nil {
 		return &dto.User{}, res.Error
 	}
 
+	if _, ok := user["email"].(string); ok {
+		uc.sendVerification(ctx, authorID)
+	}
+
 	var resp dto.User
 	err := res.Model(&dto.User{}).Where("id", authorID).Take(&resp).Error
 	if err != nil {
@@ -77,10 +195,268 @@ func (uc *userUsecase) UpdateUser(ctx *gin.Context, authorID int64, request *dto
 	return &resp, nil
 }
 
-func (uc *userUsecase) DeleteUser(ctx *gin.Context, userID int64) error {
-	res := uc.db.Model(&dto.User{}).Where("id = ?", userID).Take(&dto.User{}).Delete(&dto.User{})
+func (uc *userUsecase) PatchUser(ctx *gin.Context, userID int64, patch []byte, ifMatch string) (*dto.User, error) {
+	db, span := instrument(ctx, uc.db, "user", "PatchUser")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	var user dto.User
+	err := db.Model(&dto.User{}).Where("id = ?", userID).Take(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkIfMatch(ifMatch, user.ETag()); err != nil {
+		return nil, err
+	}
+
+	doc := dto.UserPatch{
+		Name:        user.Name,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarID:    user.AvatarID,
+		Website:     user.Website,
+		SocialLinks: user.SocialLinks,
+	}
+	if user.Email != nil {
+		doc.Email = *user.Email
+	}
+	if err := applyMergePatch(&doc, patch); err != nil {
+		return nil, err
+	}
+	if err := checkName(user.Name, doc.Name); err != nil {
+		return nil, err
+	}
+
+	columns := map[string]interface{}{
+		"name":         doc.Name,
+		"display_name": doc.DisplayName,
+		"bio":          doc.Bio,
+		"avatar_id":    doc.AvatarID,
+		"website":      doc.Website,
+		"social_links": doc.SocialLinks,
+		"updated_at":   time.Now(),
+		"version":      gorm.Expr("version + 1"),
+	}
+	if doc.AvatarID != nil && (user.AvatarID == nil || *doc.AvatarID != *user.AvatarID) {
+		if err := checkAvatar(db, userID, *doc.AvatarID); err != nil {
+			return nil, err
+		}
+	}
+	if err := emailColumns(db, &user, doc.Email, doc.CurrentPassword, columns); err != nil {
+		return nil, err
+	}
+
+	res := db.Model(&dto.User{}).Where("id = ? AND version = ?", userID, user.Version).UpdateColumns(columns)
 	if res.Error != nil {
-		return res.Error
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, dto.ErrPreconditionFailed
+	}
+	if _, ok := columns["email"].(string); ok {
+		uc.sendVerification(ctx, userID)
+	}
+
+	var resp dto.User
+	err = db.Model(&dto.User{}).Where("id = ?", userID).Take(&resp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (uc *userUsecase) GetProfile(ctx *gin.Context, userID int64, page int) (*dto.Profile, error) {
+	db, span := instrument(ctx, uc.db, "user", "GetProfile")
+	defer span.End()
+
+	var user dto.User
+	if err := db.Where("id = ?", userID).Take(&user).Error; err != nil {
+		return nil, errors.Wrapf(err, "user %d", userID)
+	}
+
+	list := &dto.PostList{Page: page}
+	if err := listPosts(db.Where("author_id = ?", userID), list); err != nil {
+		return nil, err
+	}
+	profile := &dto.Profile{
+		ID:            user.ID,
+		Name:          user.Name,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Website:       user.Website,
+		SocialLinks:   user.SocialLinks,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		CreatedAt:     user.CreatedAt,
+		Stats:         dto.ProfileStats{Posts: list.Total},
+		Posts:         list.Posts,
+		Page:          list.Page,
+		Pages:         list.Pages(),
+	}
+
+	if user.AvatarID != nil {
+		var avatar dto.Media
+		if err := db.Preload("Variants", orderByID).Take(&avatar, *user.AvatarID).Error; err != nil {
+			return nil, errors.Wrapf(err, "avatar of user %d", userID)
+		}
+		profile.Avatar = withURL(&avatar)
+	}
+
+	posts := db.Model(&dto.Post{}).Where("author_id = ?", userID).Select("id").QueryExpr()
+	if err := db.Model(&dto.Comment{}).Where("post_id IN (?)", posts).Count(&profile.Stats.Comments).Error; err != nil {
+		return nil, err
+	}
+	var last dto.Post
+	err := db.Where("author_id = ?", userID).Order("created_at desc, id desc").Take(&last).Error
+	switch {
+	case err == nil:
+		profile.Stats.LastPostAt = &last.CreatedAt
+	case !gorm.IsRecordNotFoundError(err):
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (uc *userUsecase) DeleteUser(ctx *gin.Context, userID int64) error {
+	db, span := instrument(ctx, uc.db, "user", "DeleteUser")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	var user dto.User
+	err := db.Model(&dto.User{}).Where("id = ?", userID).Take(&user).Error
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	var postIDs []int64
+	err = tx.Model(&dto.Post{}).Where("author_id = ?", userID).Pluck("id", &postIDs).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	deletedAt := time.Now()
+	switch uc.cascade {
+	case dto.CascadeBlock:
+		if len(postIDs) > 0 {
+			tx.Rollback()
+			return errors.Wrapf(dto.ErrDeleteBlocked, "user %d still has %d posts", userID, len(postIDs))
+		}
+	case dto.CascadeReassign:
+		if user.Name == dto.GhostUserName {
+			tx.Rollback()
+			return errors.New("the ghost user cannot be deleted")
+		}
+
+		var ghost dto.User
+		if err := tx.FirstOrCreate(&ghost, &dto.User{Name: dto.GhostUserName}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err := tx.Model(&dto.Post{}).Where("id IN (?)", postIDs).UpdateColumn("author_id", ghost.ID).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	default:
+		if err := softDeletePosts(tx, postIDs, deletedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Model(&dto.User{}).Where("id = ?", userID).UpdateColumn("deleted_at", deletedAt).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	logger(ctx).Info("user deleted", zap.String("cascade", string(uc.cascade)))
+	return nil
+}
+
+// checkName refuses to give the name of the ghost user to anyone but the ghost, whose
+// current name is given.
+func checkName(current, name string) error {
+	if current != dto.GhostUserName && strings.EqualFold(strings.TrimSpace(name), dto.GhostUserName) {
+		return errors.Wrapf(dto.ErrNameReserved, "%q", name)
+	}
+	return nil
+}
+
+// sendVerification emails a verification link to the new email of the user. The change is
+// kept when the email cannot be sent, the user can ask for another one.
+func (uc *userUsecase) sendVerification(ctx *gin.Context, userID int64) {
+	if uc.accounts == nil {
+		return
+	}
+	if err := uc.accounts.SendVerification(ctx, userID); err != nil {
+		logger(ctx).Warn("failed to send the verification email", zap.Int64("user_id", userID), zap.Error(err))
+	}
+}
+
+// checkAvatar verifies that the media is an image the user uploaded.
+func checkAvatar(db *gorm.DB, userID, mediaID int64) error {
+	var media dto.Media
+	err := db.Where("id = ? AND author_id = ?", mediaID, userID).Take(&media).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return errors.Wrapf(dto.ErrInvalidAvatar, "media %d of user %d", mediaID, userID)
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(media.ContentType, "image/") {
+		return errors.Wrapf(dto.ErrInvalidAvatar, "media %d is %s", mediaID, media.ContentType)
+	}
+	return nil
+}
+
+// emailColumns adds the email of user to columns, "" removing it. A new email is not
+// verified yet. The password reset links go to the email, so a user with a password has to
+// give it to change the email: anyone could take over their account otherwise.
+func emailColumns(db *gorm.DB, user *dto.User, email, currentPassword string, columns map[string]interface{}) error {
+	email = strings.ToLower(email)
+	if user.Email != nil && *user.Email == email {
+		return nil
+	}
+	if user.Email == nil && email == "" {
+		return nil
+	}
+	if err := checkPassword(user, currentPassword); err != nil {
+		return err
+	}
+	if email == "" {
+		columns["email"] = nil
+	} else {
+		if err := checkEmail(db, user.ID, email); err != nil {
+			return err
+		}
+		columns["email"] = email
+	}
+	columns["email_verified_at"] = nil
+	return nil
+}
+
+// checkEmail verifies that no user but userID has the email. The deleted users keep theirs,
+// for them to be restored.
+func checkEmail(db *gorm.DB, userID int64, email string) error {
+	var count int
+	if err := db.Unscoped().Model(&dto.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.Wrapf(dto.ErrEmailTaken, "%s", email)
 	}
 	return nil
 }
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"blog/domain/interfaces"
+	"blog/utils/cache"
+)
+
+// Cache namespaces. A write to an entity drops every cached read of the namespaces it shows up in.
+const (
+	usersNamespace = "users"
+	postsNamespace = "posts"
+	tagsNamespace  = "tags"
+)
+
+// ReadCache keeps JSON encoded read results in a Cache. Each namespace has a generation
+// stored alongside the entries; invalidating a namespace moves it to a new generation so
+// that listings cached under any page size are dropped at once.
+// A nil *ReadCache disables caching.
+type ReadCache struct {
+	cache interfaces.Cache
+	ttl   time.Duration
+	stats *cache.Stats
+}
+
+func NewReadCache(c interfaces.Cache, ttl time.Duration, stats *cache.Stats) *ReadCache {
+	return &ReadCache{
+		cache: c,
+		ttl:   ttl,
+		stats: stats,
+	}
+}
+
+// get loads the value cached under key into dst and reports whether it was found.
+// Cache errors are treated as misses, the cache must never fail a read.
+func (c *ReadCache) get(ctx context.Context, namespace, key string, dst interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	data, ok, err := c.cache.Get(ctx, c.key(ctx, namespace, key))
+	if err == nil && ok && json.Unmarshal(data, dst) == nil {
+		c.stats.Hit(namespace)
+		return true
+	}
+
+	c.stats.Miss(namespace)
+	return false
+}
+
+func (c *ReadCache) set(ctx context.Context, namespace, key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	_ = c.cache.Set(ctx, c.key(ctx, namespace, key), data, c.ttl)
+}
+
+// invalidate drops every cached read of the given namespaces.
+func (c *ReadCache) invalidate(ctx context.Context, namespaces ...string) {
+	if c == nil {
+		return
+	}
+
+	for _, ns := range namespaces {
+		_ = c.cache.Set(ctx, generationKey(ns), newGeneration(), 0)
+	}
+}
+
+func (c *ReadCache) key(ctx context.Context, namespace, key string) string {
+	gen, ok, err := c.cache.Get(ctx, generationKey(namespace))
+	if err != nil || !ok {
+		// Starting a fresh generation when it was evicted keeps older entries unreachable.
+		gen = newGeneration()
+		_ = c.cache.Set(ctx, generationKey(namespace), gen, 0)
+	}
+	return namespace + ":" + string(gen) + ":" + key
+}
+
+func generationKey(namespace string) string {
+	return namespace + ":generation"
+}
+
+func newGeneration() []byte {
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+}
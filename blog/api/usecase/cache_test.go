@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"blog/domain/dto"
+	"blog/utils/cache"
+)
+
+func TestReadCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	stats := cache.NewStats()
+	c := NewReadCache(cache.NewRedis(client, "blog:"), time.Minute, stats)
+	ctx := context.Background()
+	get := func(namespace, key string) (string, bool) {
+		var value string
+		ok := c.get(ctx, namespace, key, &value)
+		return value, ok
+	}
+
+	if _, ok := get(postsNamespace, "list:10:0"); ok {
+		t.Fatal("hit in an empty cache")
+	}
+	c.set(ctx, postsNamespace, "list:10:0", "first page")
+	c.set(ctx, postsNamespace, "list:20:0", "longer page")
+	c.set(ctx, usersNamespace, "id:1", "ada")
+	if value, ok := get(postsNamespace, "list:10:0"); !ok || value != "first page" {
+		t.Errorf("get(list:10:0) = %q, %v, want the cached page", value, ok)
+	}
+
+	// a write to the posts drops every page of them, whatever its size, and no user
+	c.invalidate(ctx, postsNamespace)
+	for _, key := range []string{"list:10:0", "list:20:0"} {
+		if _, ok := get(postsNamespace, key); ok {
+			t.Errorf("%s is cached after the posts were invalidated", key)
+		}
+	}
+	if _, ok := get(usersNamespace, "id:1"); !ok {
+		t.Error("invalidating the posts dropped a user")
+	}
+
+	// the entries of an evicted generation are not read back
+	c.set(ctx, usersNamespace, "id:2", "charles")
+	server.Del("blog:" + generationKey(usersNamespace))
+	if _, ok := get(usersNamespace, "id:2"); ok {
+		t.Error("the entry of an evicted generation was read")
+	}
+
+	// a cache that fails is a miss, never an error
+	server.Close()
+	if _, ok := get(usersNamespace, "id:1"); ok {
+		t.Error("hit with the server down")
+	}
+
+	counts := stats.Snapshot()
+	if posts := counts[postsNamespace]; posts.Hits != 1 || posts.Misses != 3 {
+		t.Errorf("posts counts %+v, want 1 hit and 3 misses", posts)
+	}
+	if users := counts[usersNamespace]; users.Hits != 1 || users.Misses != 2 {
+		t.Errorf("users counts %+v, want 1 hit and 2 misses", users)
+	}
+
+	var disabled *ReadCache
+	disabled.set(ctx, usersNamespace, "id:1", "ada")
+	disabled.invalidate(ctx, usersNamespace)
+	var value string
+	if disabled.get(ctx, usersNamespace, "id:1", &value) {
+		t.Error("hit in a nil cache")
+	}
+}
+
+func TestCommentWritesRefreshCachedPosts(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&dto.User{Name: "ada"})
+	db.Create(&dto.Post{Title: "notes", Content: "on the analytical engine", AuthorID: 1})
+
+	readCache := NewReadCache(cache.NewLRU(100), time.Hour, cache.NewStats())
+	posts := NewPostUsecase(db, dto.CascadeDelete, readCache)
+	comments := NewCommentsUsecase(db, readCache)
+	trash := NewTrashUsecase(db, readCache)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	count := func(step string, want int64) {
+		t.Helper()
+		post, err := posts.GetPostById(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := posts.GetAllPosts(ctx, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.CommentsCount != want || len(*list) != 1 || (*list)[0].CommentsCount != want {
+			t.Errorf("%s: post has %d comments and %d in the list, want %d", step, post.CommentsCount, (*list)[0].CommentsCount, want)
+		}
+	}
+
+	count("before", 0)
+	if _, err := comments.CreateComment(ctx, 1, &dto.Comment{Name: "babbage", Body: "splendid"}); err != nil {
+		t.Fatal(err)
+	}
+	count("created", 1)
+	if err := comments.DeleteComments(ctx, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	count("deleted", 0)
+	if _, err := trash.RestoreComment(ctx, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	count("restored", 1)
+}
@@ -9,27 +9,34 @@ import (
 
 	"blog/domain/dto"
 	"blog/domain/interfaces"
+	"blog/utils/metrics"
 )
 
 type commentsUsecase struct {
-	db *gorm.DB
+	db    *gorm.DB
+	cache *ReadCache
 }
 
-func NewCommentsUsecase(db *gorm.DB) interfaces.CommentsUsecase {
+// NewCommentsUsecase drops the cached posts on every comment write, they show how many
+// comments they have.
+func NewCommentsUsecase(db *gorm.DB, cache *ReadCache) interfaces.CommentsUsecase {
 	return &commentsUsecase{
-		db: db,
+		db:    db,
+		cache: cache,
 	}
 }
 
 func (uc *commentsUsecase) GetCommentById(ctx *gin.Context, commentID, postID int64) (*dto.Comment, error) {
+	db, span := instrument(ctx, uc.db, "comments", "GetCommentById")
+	defer span.End()
 	var comment dto.Comment
-	res := uc.db.Debug().Find(&comment, dto.Comment{ID: commentID})
+	res := db.Find(&comment, dto.Comment{ID: commentID})
 	if res.RecordNotFound() {
-		return nil, errors.New("error")
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "comment %d", commentID)
 	}
 
 	if comment.ID != 0 {
-		err := uc.db.Model(&dto.Post{}).Where("id = ?", postID).Take(&comment.Post).Error
+		err := db.Model(&dto.Post{}).Where("id = ?", postID).Take(&comment.Post).Error
 		if err != nil {
 			return &dto.Comment{}, err
 		}
@@ -38,7 +45,30 @@ func (uc *commentsUsecase) GetCommentById(ctx *gin.Context, commentID, postID in
 	return &comment, nil
 }
 
+func (uc *commentsUsecase) GetCommentsByPostIds(ctx *gin.Context, postIDs []int64) ([]dto.Comment, error) {
+	db, span := instrument(ctx, uc.db, "comments", "GetCommentsByPostIds")
+	defer span.End()
+
+	comments := []dto.Comment{}
+	err := inBatches(postIDs, func(ids []int64) error {
+		var batch []dto.Comment
+		if err := db.Where("post_id IN (?)", ids).Order("id").Find(&batch).Error; err != nil {
+			return err
+		}
+		comments = append(comments, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
 func (uc *commentsUsecase) CreateComment(ctx *gin.Context, postID int64, request *dto.Comment) (dto.CreateCommentsResponse, error) {
+	db, span := instrument(ctx, uc.db, "comments", "CreateComment")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace)
+
 	request.PostID = postID
 	comment := &dto.Comment{
 		Name:   request.Name,
@@ -46,10 +76,11 @@ func (uc *commentsUsecase) CreateComment(ctx *gin.Context, postID int64, request
 		PostID: request.PostID,
 	}
 
-	err := uc.db.Debug().Create(&comment).Error
+	err := db.Create(&comment).Error
 	if err != nil {
 		return dto.CreateCommentsResponse{}, err
 	}
+	metrics.CommentSubmitted()
 
 	return dto.CreateCommentsResponse{
 		ID:     comment.ID,
@@ -60,9 +91,13 @@ func (uc *commentsUsecase) CreateComment(ctx *gin.Context, postID int64, request
 }
 
 func (uc *commentsUsecase) UpdateComments(ctx *gin.Context, commentID, postID int64, request *dto.UpdateCommentsBodyRequest) (*dto.Comment, error) {
-	var resp dto.Comment
+	db, span := instrument(ctx, uc.db, "comments", "UpdateComments")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace)
+
 	comment := map[string]interface{}{
 		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
 	}
 
 	if len(request.Name) != 0 {
@@ -73,23 +108,88 @@ func (uc *commentsUsecase) UpdateComments(ctx *gin.Context, commentID, postID in
 		comment["body"] = request.Body
 	}
 
-	res := uc.db.Model(&resp).Where("id=?", commentID).Take(&resp).Updates(comment)
+	res := db.Model(&dto.Comment{}).Where("id = ? AND post_id = ?", commentID, postID).Updates(comment)
 	if res.Error != nil {
 		return &dto.Comment{}, res.Error
 	}
+	if res.RowsAffected == 0 {
+		return &dto.Comment{}, errors.Wrapf(gorm.ErrRecordNotFound, "comment %d of post %d", commentID, postID)
+	}
 
-	if resp.ID != 0 {
-		err := uc.db.Debug().Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
-		if err != nil {
-			return &dto.Comment{}, err
-		}
+	// read back the version the update made
+	var resp dto.Comment
+	err := db.Model(&dto.Comment{}).Where("id = ?", commentID).Take(&resp).Error
+	if err != nil {
+		return &dto.Comment{}, err
+	}
+
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
+	if err != nil {
+		return &dto.Comment{}, err
+	}
+
+	return &resp, nil
+}
+
+func (uc *commentsUsecase) PatchComment(ctx *gin.Context, commentID, postID int64, patch []byte, ifMatch string) (*dto.Comment, error) {
+	db, span := instrument(ctx, uc.db, "comments", "PatchComment")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace)
+
+	var comment dto.Comment
+	err := db.Model(&dto.Comment{}).Where("id = ? AND post_id = ?", commentID, postID).Take(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the client read the comment with its post
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&comment.Post).Error
+	if err != nil {
+		return nil, err
+	}
+	current, _ := comment.Validators()
+	if err := checkIfMatch(ifMatch, current); err != nil {
+		return nil, err
+	}
+
+	doc := dto.CommentPatch{Name: comment.Name, Body: comment.Body}
+	if err := applyMergePatch(&doc, patch); err != nil {
+		return nil, err
+	}
+
+	res := db.Model(&dto.Comment{}).Where("id = ? AND version = ?", commentID, comment.Version).UpdateColumns(map[string]interface{}{
+		"name":       doc.Name,
+		"body":       doc.Body,
+		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, dto.ErrPreconditionFailed
+	}
+
+	var resp dto.Comment
+	err = db.Model(&dto.Comment{}).Where("id = ?", commentID).Take(&resp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
+	if err != nil {
+		return nil, err
 	}
 
 	return &resp, nil
 }
 
 func (uc *commentsUsecase) DeleteComments(ctx *gin.Context, commentID, PostID int64) error {
-	res := uc.db.Model(&dto.Comment{}).Where("id = ? and post_id=?", commentID, PostID).Take(&dto.Comment{}).Delete(&dto.Comment{})
+	db, span := instrument(ctx, uc.db, "comments", "DeleteComments")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace)
+
+	res := db.Model(&dto.Comment{}).Where("id = ? and post_id=?", commentID, PostID).Take(&dto.Comment{}).Delete(&dto.Comment{})
 	if res.Error != nil {
 		return res.Error
 	}
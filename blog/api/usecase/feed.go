@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+type feedUsecase struct {
+	db *gorm.DB
+}
+
+func NewFeedUsecase(db *gorm.DB) interfaces.FeedUsecase {
+	return &feedUsecase{
+		db: db,
+	}
+}
+
+func (uc *feedUsecase) GetFeed(ctx *gin.Context) (*dto.Feed, error) {
+	db, span := instrument(ctx, uc.db, "feed", "GetFeed")
+	defer span.End()
+
+	posts, err := latestPosts(db)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.Feed{Posts: posts}, nil
+}
+
+func (uc *feedUsecase) GetAuthorFeed(ctx *gin.Context, authorID int64) (*dto.Feed, error) {
+	db, span := instrument(ctx, uc.db, "feed", "GetAuthorFeed")
+	defer span.End()
+
+	var author dto.User
+	if err := db.Where("id = ?", authorID).Take(&author).Error; err != nil {
+		return nil, errors.Wrapf(err, "author %d", authorID)
+	}
+
+	posts, err := latestPosts(db.Where("author_id = ?", authorID))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.Feed{Author: &author, Posts: posts}, nil
+}
+
+func (uc *feedUsecase) GetTagFeed(ctx *gin.Context, name string) (*dto.Feed, error) {
+	db, span := instrument(ctx, uc.db, "feed", "GetTagFeed")
+	defer span.End()
+
+	var tag dto.Tag
+	if err := db.Where("name = ?", name).Take(&tag).Error; err != nil {
+		return nil, errors.Wrapf(err, "tag %q", name)
+	}
+
+	posts, err := latestPosts(taggedWith(db, &tag))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.Feed{Tag: &tag, Posts: posts}, nil
+}
+
+// taggedWith selects the posts carrying tag: the post it was created on and the posts created
+// with it.
+func taggedWith(db *gorm.DB, tag *dto.Tag) *gorm.DB {
+	return db.Where("id = ? OR tags_id = ?", tag.PostID, tag.ID)
+}
+
+// latestPosts returns the dto.FeedSize most recently updated posts selected by db, with their
+// relations.
+func latestPosts(db *gorm.DB) ([]dto.Post, error) {
+	posts := []dto.Post{}
+	if err := db.Order("updated_at desc, id desc").Limit(dto.FeedSize).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := loadPostRelations(db.New(), posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// sitemapColumns are the columns of each kind of resource the sitemap lists.
+var sitemapColumns = map[string]string{
+	dto.SitemapPosts: "id, updated_at",
+	dto.SitemapTags:  "id, name, updated_at",
+	dto.SitemapUsers: "id, updated_at",
+}
+
+func (uc *feedUsecase) GetSitemapPages(ctx *gin.Context) ([]dto.SitemapPage, error) {
+	db, span := instrument(ctx, uc.db, "feed", "GetSitemapPages")
+	defer span.End()
+
+	pages := []dto.SitemapPage{}
+	for _, kind := range []string{dto.SitemapPosts, dto.SitemapTags, dto.SitemapUsers} {
+		var found []dto.SitemapPage
+		err := sitemapModel(db, kind).
+			Select("(id - 1) / ? + 1 AS number, count(*) AS count, sum(version) AS versions, max(id) AS max_id", dto.SitemapPageSize).
+			Group("number").
+			Order("number").
+			Scan(&found).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].Kind = kind
+		}
+		pages = append(pages, found...)
+	}
+	return pages, nil
+}
+
+func (uc *feedUsecase) GetSitemapEntries(ctx *gin.Context, page dto.SitemapPage) ([]dto.SitemapEntry, error) {
+	db, span := instrument(ctx, uc.db, "feed", "GetSitemapEntries")
+	defer span.End()
+
+	columns, ok := sitemapColumns[page.Kind]
+	if !ok {
+		return nil, errors.Errorf("unknown sitemap kind %q", page.Kind)
+	}
+	first := (page.Number-1)*dto.SitemapPageSize + 1
+	entries := []dto.SitemapEntry{}
+	err := sitemapModel(db, page.Kind).
+		Select(columns).
+		Where("id BETWEEN ? AND ?", first, first+dto.SitemapPageSize-1).
+		Order("id").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// sitemapModel scopes db to the table of a kind of resource, leaving out the deleted rows
+// and the ghost user, which has no page.
+func sitemapModel(db *gorm.DB, kind string) *gorm.DB {
+	switch kind {
+	case dto.SitemapPosts:
+		return db.Model(&dto.Post{})
+	case dto.SitemapTags:
+		return db.Model(&dto.Tag{})
+	}
+	return db.Model(&dto.User{}).Where("name <> ?", dto.GhostUserName)
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+func TestSitemapPages(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&dto.User{Name: "ada"})
+	db.Create(&dto.User{Name: "charles"})
+	db.Create(&dto.User{Name: dto.GhostUserName})
+	db.Create(&dto.Post{ID: 1, Title: "notes", Content: "on the analytical engine", AuthorID: 1})
+	db.Create(&dto.Post{ID: 2, Title: "sketch", Content: "of the engine", AuthorID: 1})
+	// the first id of the second page
+	db.Create(&dto.Post{ID: dto.SitemapPageSize + 1, Title: "difference engine", Content: "no. 2", AuthorID: 2})
+	db.Create(&dto.Tag{Name: "math", PostID: 1})
+	db.Model(&dto.Post{ID: 1}).UpdateColumn("version", 3)
+	db.Delete(&dto.Post{ID: 2})
+
+	uc := NewFeedUsecase(db)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	pages, err := uc.GetSitemapPages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the deleted post and the ghost user are left out
+	want := []dto.SitemapPage{
+		{Kind: dto.SitemapPosts, Number: 1, Count: 1, Versions: 3, MaxID: 1},
+		{Kind: dto.SitemapPosts, Number: 2, Count: 1, Versions: 1, MaxID: dto.SitemapPageSize + 1},
+		{Kind: dto.SitemapTags, Number: 1, Count: 1, Versions: 1, MaxID: 1},
+		{Kind: dto.SitemapUsers, Number: 1, Count: 2, Versions: 2, MaxID: 2},
+	}
+	if !reflect.DeepEqual(pages, want) {
+		t.Fatalf("pages %+v, want %+v", pages, want)
+	}
+
+	for _, tt := range []struct {
+		page  dto.SitemapPage
+		ids   []int64
+		names []string
+	}{
+		{pages[0], []int64{1}, []string{""}},
+		{pages[1], []int64{dto.SitemapPageSize + 1}, []string{""}},
+		// the pages of the tags are found by name
+		{pages[2], []int64{1}, []string{"math"}},
+		{pages[3], []int64{1, 2}, []string{"", ""}},
+	} {
+		entries, err := uc.GetSitemapEntries(ctx, tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		var names []string
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+			names = append(names, e.Name)
+			if e.UpdatedAt.IsZero() {
+				t.Errorf("%s-%d: entry %d without an update time", tt.page.Kind, tt.page.Number, e.ID)
+			}
+		}
+		if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%s-%d: ids %v, names %q, want %v, %q", tt.page.Kind, tt.page.Number, ids, names, tt.ids, tt.names)
+		}
+	}
+
+	if _, err := uc.GetSitemapEntries(ctx, dto.SitemapPage{Kind: "comments", Number: 1}); err == nil {
+		t.Error("entries of an unknown kind")
+	}
+}
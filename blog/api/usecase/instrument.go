@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"blog/utils/log"
+	"blog/utils/metrics"
+	"blog/utils/tracing"
+)
+
+// instrument opens the span of a usecase method and returns the handle on db whose
+// statements are measured, traced and logged under it. The caller ends the span.
+func instrument(ctx context.Context, db *gorm.DB, usecase, method string) (*gorm.DB, trace.Span) {
+	ctx, span := tracing.Start(requestContext(ctx), usecase+"."+method)
+	db = metrics.Method(db, usecase, method)
+	db = log.WithDB(db, log.FromContext(ctx))
+	return tracing.WithContext(ctx, db), span
+}
+
+// logger returns the logger of the request ctx belongs to.
+func logger(ctx context.Context) *zap.Logger {
+	return log.FromContext(requestContext(ctx))
+}
+
+// requestContext returns the context of the request behind a gin context, where the
+// middlewares store the span and the logger of the request.
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		return c.Request.Context()
+	}
+	return ctx
+}
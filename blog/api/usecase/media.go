@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/imaging"
+)
+
+// sniffLength is how much of a file http.DetectContentType looks at.
+const sniffLength = 512
+
+// mediaExtensions are the extensions of the keys of the common media types, whose
+// extensions in the system MIME tables vary.
+var mediaExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"video/mp4":       ".mp4",
+}
+
+type mediaUsecase struct {
+	db        *gorm.DB
+	storage   interfaces.MediaStorage
+	limits    dto.MediaLimits
+	processor interfaces.MediaProcessor
+}
+
+// NewMediaUsecase stores the uploads in storage and hands the images to processor.
+func NewMediaUsecase(db *gorm.DB, storage interfaces.MediaStorage, limits dto.MediaLimits, processor interfaces.MediaProcessor) interfaces.MediaUsecase {
+	return &mediaUsecase{
+		db:        db,
+		storage:   storage,
+		limits:    limits,
+		processor: processor,
+	}
+}
+
+func (uc *mediaUsecase) UploadMedia(ctx *gin.Context, authorID, postID int64, file *dto.MediaFile) (*dto.Media, error) {
+	db, span := instrument(ctx, uc.db, "media", "UploadMedia")
+	defer span.End()
+
+	if file.Size > uc.limits.MaxSize {
+		return nil, errors.Wrapf(dto.ErrMediaTooLarge, "%d bytes, the limit is %d", file.Size, uc.limits.MaxSize)
+	}
+	if err := db.Take(&dto.User{}, authorID).Error; err != nil {
+		return nil, errors.Wrapf(err, "author %d", authorID)
+	}
+	if postID != 0 {
+		if err := db.Where("id = ? AND author_id = ?", postID, authorID).Take(&dto.Post{}).Error; err != nil {
+			return nil, errors.Wrapf(err, "post %d of author %d", postID, authorID)
+		}
+	}
+
+	// the type is the one of the content, whatever the name or the client say
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file.Body, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return nil, err
+	}
+	if !uc.allowed(contentType) {
+		return nil, errors.Wrapf(dto.ErrUnsupportedMediaType, "%s", contentType)
+	}
+
+	media := &dto.Media{
+		Filename:    mediaFilename(file.Name),
+		ContentType: contentType,
+		Size:        file.Size,
+		AuthorID:    authorID,
+		Status:      dto.MediaSkipped,
+	}
+	if postID != 0 {
+		media.PostID = &postID
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), file.Body)
+	if imaging.Supported(contentType) {
+		// the metadata of the images, such as where they were taken, is removed before
+		// they are stored anywhere
+		data, err := io.ReadAll(io.LimitReader(body, uc.limits.MaxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = imaging.StripMetadata(data); err != nil {
+			return nil, errors.Wrapf(dto.ErrUnsupportedMediaType, "%s: %v", contentType, err)
+		}
+		if media.Width, media.Height, err = imaging.Size(data); err != nil {
+			return nil, errors.Wrapf(dto.ErrUnsupportedMediaType, "%s: %v", contentType, err)
+		}
+		// the size is read from the header, before anything decodes the pixels
+		if pixels := int64(media.Width) * int64(media.Height); pixels > uc.limits.MaxPixels {
+			return nil, errors.Wrapf(dto.ErrUnsupportedMediaType, "%dx%d image, the limit is %d pixels", media.Width, media.Height, uc.limits.MaxPixels)
+		}
+		body, media.Size = bytes.NewReader(data), int64(len(data))
+		media.Status = dto.MediaPending
+	}
+
+	key, err := mediaKey(contentType)
+	if err != nil {
+		return nil, err
+	}
+	media.Key = key
+	checksum := sha256.New()
+	if err := uc.storage.Put(ctx, key, io.TeeReader(body, checksum), media.Size, contentType); err != nil {
+		return nil, errors.Wrap(err, "store the file")
+	}
+	media.Checksum = hex.EncodeToString(checksum.Sum(nil))
+
+	if err := db.Create(media).Error; err != nil {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			logger(ctx).Warn("delete orphan media file", zap.String("key", key), zap.Error(err))
+		}
+		return nil, err
+	}
+
+	logger(ctx).Info("media uploaded", zap.Int64("media_id", media.ID), zap.String("content_type", contentType), zap.Int64("size", media.Size))
+	if media.Status == dto.MediaPending {
+		uc.processor.Enqueue(media.ID)
+	}
+	return withURL(media), nil
+}
+
+func (uc *mediaUsecase) GetMediaById(ctx *gin.Context, mediaID int64) (*dto.Media, error) {
+	db, span := instrument(ctx, uc.db, "media", "GetMediaById")
+	defer span.End()
+
+	var media dto.Media
+	if err := db.Preload("Variants", orderByID).Take(&media, mediaID).Error; err != nil {
+		return nil, errors.Wrapf(err, "media %d", mediaID)
+	}
+	return withURL(&media), nil
+}
+
+func (uc *mediaUsecase) GetAuthorMedia(ctx *gin.Context, authorID int64) ([]dto.Media, error) {
+	db, span := instrument(ctx, uc.db, "media", "GetAuthorMedia")
+	defer span.End()
+
+	media := []dto.Media{}
+	if err := db.Preload("Variants", orderByID).Where("author_id = ?", authorID).Order("id desc").Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for i := range media {
+		withURL(&media[i])
+	}
+	return media, nil
+}
+
+func (uc *mediaUsecase) GetPostMedia(ctx *gin.Context, postID int64) ([]dto.Media, error) {
+	db, span := instrument(ctx, uc.db, "media", "GetPostMedia")
+	defer span.End()
+
+	media := []dto.Media{}
+	if err := db.Preload("Variants", orderByID).Where("post_id = ?", postID).Order("id").Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for i := range media {
+		withURL(&media[i])
+	}
+	return media, nil
+}
+
+func (uc *mediaUsecase) DeleteMedia(ctx *gin.Context, mediaID, authorID int64) error {
+	db, span := instrument(ctx, uc.db, "media", "DeleteMedia")
+	defer span.End()
+
+	var media dto.Media
+	if err := db.Preload("Variants").Where("id = ? AND author_id = ?", mediaID, authorID).Take(&media).Error; err != nil {
+		return errors.Wrapf(err, "media %d of author %d", mediaID, authorID)
+	}
+	var avatars int
+	if err := db.Model(&dto.User{}).Where("avatar_id = ?", media.ID).Count(&avatars).Error; err != nil {
+		return err
+	}
+	if avatars > 0 {
+		return errors.Wrapf(dto.ErrDeleteBlocked, "media %d is the avatar of its author", media.ID)
+	}
+	tx := db.Begin()
+	if err := tx.Where("media_id = ?", media.ID).Delete(&dto.MediaVariant{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(&media).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	// the records are gone, files left behind are unreachable
+	keys := []string{media.Key}
+	for _, v := range media.Variants {
+		keys = append(keys, v.Key)
+	}
+	for _, key := range keys {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			logger(ctx).Warn("delete media file", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (uc *mediaUsecase) OpenMedia(ctx *gin.Context, key string) (*dto.Media, io.ReadSeekCloser, error) {
+	db, span := instrument(ctx, uc.db, "media", "OpenMedia")
+	defer span.End()
+
+	var media dto.Media
+	err := db.Where("key = ?", key).Take(&media).Error
+	if gorm.IsRecordNotFoundError(err) {
+		// a variant is described by the record of its image, narrowed to the variant
+		var variant dto.MediaVariant
+		if err := db.Where("key = ?", key).Take(&variant).Error; err != nil {
+			return nil, nil, errors.Wrapf(err, "media %s", key)
+		}
+		if err := db.Take(&media, variant.MediaID).Error; err != nil {
+			return nil, nil, errors.Wrapf(err, "media %s", key)
+		}
+		ext := path.Ext(media.Filename)
+		media.Key = variant.Key
+		media.Filename = strings.TrimSuffix(media.Filename, ext) + "-" + variant.Name + path.Ext(variant.Key)
+		media.ContentType = variant.ContentType
+		media.Size = variant.Size
+		media.Checksum = variant.Checksum
+		media.Width, media.Height = variant.Width, variant.Height
+		media.CreatedAt = variant.CreatedAt
+	} else if err != nil {
+		return nil, nil, errors.Wrapf(err, "media %s", key)
+	}
+	file, err := uc.storage.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return withURL(&media), file, nil
+}
+
+func (uc *mediaUsecase) allowed(contentType string) bool {
+	for _, t := range uc.limits.AllowedTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaKey builds a new key for a file of contentType, grouped by month of upload.
+func mediaKey(contentType string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	ext, ok := mediaExtensions[contentType]
+	if !ok {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return time.Now().UTC().Format("2006/01/") + hex.EncodeToString(id) + ext, nil
+}
+
+// mediaFilename keeps the base of the name the client gave to the file, for the downloads.
+func mediaFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+func withURL(media *dto.Media) *dto.Media {
+	media.URL = dto.MediaPath + media.Key
+	for i := range media.Variants {
+		media.Variants[i].URL = dto.MediaPath + media.Variants[i].Key
+	}
+	return media
+}
+
+func orderByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
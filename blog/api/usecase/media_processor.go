@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/imaging"
+	"blog/utils/metrics"
+)
+
+const (
+	// mediaQueueSize bounds the images waiting for a worker, the others wait for a sweep.
+	mediaQueueSize = 100
+	// mediaSweepInterval is how often the pending images are queued again.
+	mediaSweepInterval = time.Minute
+	// mediaProcessingLease is how long an image stays claimed by a worker. Past it, the
+	// worker is deemed gone, stopped with its server, and another one takes the image over.
+	mediaProcessingLease = 10 * time.Minute
+)
+
+type mediaProcessor struct {
+	db       *gorm.DB
+	storage  interfaces.MediaStorage
+	variants dto.ImageVariants
+	workers  int
+	queue    chan int64
+}
+
+// NewMediaProcessor renders the variants of the images of storage with workers goroutines.
+func NewMediaProcessor(db *gorm.DB, storage interfaces.MediaStorage, variants dto.ImageVariants, workers int) interfaces.MediaProcessor {
+	return &mediaProcessor{
+		db:       db,
+		storage:  storage,
+		variants: variants,
+		workers:  workers,
+		queue:    make(chan int64, mediaQueueSize),
+	}
+}
+
+func (p *mediaProcessor) Enqueue(mediaID int64) {
+	select {
+	case p.queue <- mediaID:
+	default:
+	}
+}
+
+func (p *mediaProcessor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case mediaID := <-p.queue:
+					if err := p.Process(ctx, mediaID); err != nil && ctx.Err() == nil {
+						logger(ctx).Error("failed to process media", zap.Int64("media_id", mediaID), zap.Error(err))
+					}
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(mediaSweepInterval)
+	defer ticker.Stop()
+	for {
+		// the first sweep picks up the images left pending by the previous run
+		if err := p.sweep(ctx); err != nil {
+			logger(ctx).Error("failed to sweep pending media", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep queues the pending images and the ones whose worker is gone, as many as fit.
+func (p *mediaProcessor) sweep(ctx context.Context) error {
+	db, span := instrument(ctx, p.db, "media", "SweepMedia")
+	defer span.End()
+
+	var ids []int64
+	err := db.Model(&dto.Media{}).
+		Where("status = ? OR (status = ? AND updated_at < ?)", dto.MediaPending, dto.MediaProcessing, time.Now().Add(-mediaProcessingLease)).
+		Order("id").Limit(mediaQueueSize).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		select {
+		case p.queue <- id:
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *mediaProcessor) Process(ctx context.Context, mediaID int64) error {
+	db, span := instrument(ctx, p.db, "media", "ProcessMedia")
+	defer span.End()
+	start := time.Now()
+
+	// claiming the image keeps the other workers, of this server or another, off it
+	res := db.Model(&dto.Media{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", mediaID, dto.MediaPending, dto.MediaProcessing, start.Add(-mediaProcessingLease)).
+		Updates(map[string]interface{}{"status": dto.MediaProcessing, "updated_at": start})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+
+	var media dto.Media
+	if err := db.Take(&media, mediaID).Error; err != nil {
+		return errors.Wrapf(err, "media %d", mediaID)
+	}
+
+	variants, err := p.render(ctx, &media)
+	if err != nil && ctx.Err() != nil {
+		// stopped with the server, the image is taken over once the lease is over
+		return ctx.Err()
+	}
+	if err != nil {
+		p.deleteFiles(ctx, variants)
+		logger(ctx).Warn("media processing failed", zap.Int64("media_id", mediaID), zap.Error(err))
+		metrics.MediaProcessed(string(dto.MediaFailed), time.Since(start))
+
+		message := err.Error()
+		if len(message) > 1024 {
+			message = message[:1024]
+		}
+		return db.Model(&dto.Media{}).Where("id = ? AND status = ?", mediaID, dto.MediaProcessing).
+			Updates(map[string]interface{}{"status": dto.MediaFailed, "processing_error": message}).Error
+	}
+
+	tx := db.Begin()
+	res = tx.Model(&dto.Media{}).Where("id = ? AND status = ?", mediaID, dto.MediaProcessing).Updates(map[string]interface{}{
+		"status":           dto.MediaReady,
+		"processing_error": "",
+		"width":            media.Width,
+		"height":           media.Height,
+		"blurhash":         media.Blurhash,
+		"dominant_color":   media.DominantColor,
+	})
+	if res.Error == nil && res.RowsAffected == 0 {
+		// deleted while it was processed
+		tx.Rollback()
+		p.deleteFiles(ctx, variants)
+		return nil
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		p.deleteFiles(ctx, variants)
+		return res.Error
+	}
+	for i := range variants {
+		if err := tx.Create(&variants[i]).Error; err != nil {
+			tx.Rollback()
+			p.deleteFiles(ctx, variants)
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		p.deleteFiles(ctx, variants)
+		return err
+	}
+
+	logger(ctx).Info("media processed", zap.Int64("media_id", mediaID), zap.Int("variants", len(variants)))
+	metrics.MediaProcessed(string(dto.MediaReady), time.Since(start))
+	return nil
+}
+
+// render stores the variants of the image and fills in its size and placeholders. The
+// variants stored are returned even on failure, for them to be deleted.
+func (p *mediaProcessor) render(ctx context.Context, media *dto.Media) ([]dto.MediaVariant, error) {
+	file, err := p.storage.Get(ctx, media.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "open the image")
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "read the image")
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	media.Width, media.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	if media.Blurhash, err = imaging.Blurhash(img); err != nil {
+		return nil, errors.Wrap(err, "blurhash")
+	}
+	media.DominantColor = imaging.DominantColor(img)
+
+	var variants []dto.MediaVariant
+	put := func(name string, scaled image.Image) error {
+		for _, f := range p.variants.Formats {
+			format := imaging.Format(f)
+			var buf bytes.Buffer
+			if err := imaging.Encode(&buf, scaled, format, p.variants.Quality); err != nil {
+				return errors.Wrapf(err, "encode the %s variant as %s", name, format)
+			}
+			sum := sha256.Sum256(buf.Bytes())
+			variant := dto.MediaVariant{
+				MediaID:     media.ID,
+				Key:         variantKey(media.Key, name, format),
+				Name:        name,
+				ContentType: format.ContentType(),
+				Width:       scaled.Bounds().Dx(),
+				Height:      scaled.Bounds().Dy(),
+				Size:        int64(buf.Len()),
+				Checksum:    hex.EncodeToString(sum[:]),
+			}
+			if err := p.storage.Put(ctx, variant.Key, &buf, variant.Size, variant.ContentType); err != nil {
+				return errors.Wrapf(err, "store the %s variant as %s", name, format)
+			}
+			variants = append(variants, variant)
+		}
+		return nil
+	}
+
+	if err := put("thumbnail", imaging.Thumbnail(img, p.variants.ThumbnailSize)); err != nil {
+		return variants, err
+	}
+	// images are never scaled up
+	for _, width := range p.variants.Widths {
+		if width >= media.Width {
+			continue
+		}
+		if err := put(strconv.Itoa(width)+"w", imaging.Resize(img, width)); err != nil {
+			return variants, err
+		}
+	}
+	return variants, nil
+}
+
+func (p *mediaProcessor) deleteFiles(ctx context.Context, variants []dto.MediaVariant) {
+	for _, v := range variants {
+		if err := p.storage.Delete(ctx, v.Key); err != nil {
+			logger(ctx).Warn("delete media file", zap.String("key", v.Key), zap.Error(err))
+		}
+	}
+}
+
+// variantKey derives the key of a variant from the key of its image. The keys of the
+// images being never reused, neither are those of their variants.
+func variantKey(key, name string, format imaging.Format) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + format.Extension()
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/utils/etag"
+	"blog/utils/mergepatch"
+)
+
+// applyMergePatch applies a JSON merge patch to doc in place and validates the result
+// with the same rules as request bodies, so a patch cannot clear a required field.
+func applyMergePatch(doc interface{}, patch []byte) error {
+	current, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergepatch.Apply(current, patch)
+	if err != nil {
+		return errors.Wrap(dto.ErrInvalidPatch, err.Error())
+	}
+
+	// Members removed by the patch must end up as zero values, not keep their old ones.
+	v := reflect.ValueOf(doc).Elem()
+	v.Set(reflect.Zero(v.Type()))
+
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(doc); err != nil {
+		return errors.Wrap(dto.ErrInvalidPatch, err.Error())
+	}
+
+	return binding.Validator.ValidateStruct(doc)
+}
+
+// checkIfMatch fails with ErrPreconditionFailed when the client sent an If-Match header
+// that does not match the current version of the resource.
+func checkIfMatch(ifMatch, current string) error {
+	if ifMatch != "" && !etag.Match(ifMatch, current, false) {
+		return dto.ErrPreconditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/utils/etag"
+	"blog/utils/validation"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	current := etag.Strong("post", 1, 2)
+	tests := []struct {
+		ifMatch string
+		want    error
+	}{
+		{"", nil},
+		{current, nil},
+		{"*", nil},
+		{etag.Strong("post", 1, 1) + ", " + current, nil},
+		{etag.Strong("post", 1, 1), dto.ErrPreconditionFailed},
+		// If-Match compares strongly
+		{"W/" + current, dto.ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		if err := checkIfMatch(tt.ifMatch, current); err != tt.want {
+			t.Errorf("checkIfMatch(%q) = %v, want %v", tt.ifMatch, err, tt.want)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := dto.PostPatch{Title: "notes", Content: "on the engine", TagsID: 1}
+	if err := applyMergePatch(&doc, []byte(`{"title":"sketch","tags_id":null}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := (dto.PostPatch{Title: "sketch", Content: "on the engine"}); doc != want {
+		t.Errorf("patched %+v, want %+v", doc, want)
+	}
+
+	// a patch cannot clear a required member
+	doc = dto.PostPatch{Title: "notes", Content: "on the engine"}
+	if err := applyMergePatch(&doc, []byte(`{"title":null}`)); !validation.Failed(err) {
+		t.Errorf("clearing the title: %v, want a validation error", err)
+	}
+	for _, patch := range []string{`{"author_id":2}`, `{"title":1}`, `{`} {
+		doc = dto.PostPatch{Title: "notes", Content: "on the engine"}
+		if err := applyMergePatch(&doc, []byte(patch)); !errors.Is(err, dto.ErrInvalidPatch) {
+			t.Errorf("applyMergePatch(%s) = %v, want ErrInvalidPatch", patch, err)
+		}
+	}
+}
+
+func TestPostVersions(t *testing.T) {
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+	db := newTestDB(t)
+	db.Create(&dto.User{Name: "ada"})
+	db.Create(&dto.User{Name: "charles"})
+	db.Create(&dto.Post{Title: "notes", Content: "on the analytical engine", AuthorID: 1})
+
+	posts := NewPostUsecase(db, dto.CascadeDelete, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	read, err := posts.GetPostById(ctx, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readTag, _ := read.Validators()
+
+	patched, err := posts.PatchPost(ctx, 1, 1, []byte(`{"title":"sketch"}`), readTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Version != read.Version+1 || patched.Title != "sketch" {
+		t.Errorf("patched version %d titled %q, want version %d titled sketch", patched.Version, patched.Title, read.Version+1)
+	}
+	// the tag read before the patch is stale
+	if _, err := posts.PatchPost(ctx, 1, 1, []byte(`{"title":"notes"}`), readTag); !errors.Is(err, dto.ErrPreconditionFailed) {
+		t.Errorf("PatchPost with a stale If-Match = %v, want ErrPreconditionFailed", err)
+	}
+
+	// a full update answers the version it made, which a patch can then be based on
+	updated, err := posts.UpdatePost(ctx, 1, 1, &dto.UpdatePostBodyRequest{Content: "on the difference engine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version != patched.Version+1 || updated.Content != "on the difference engine" || updated.Author.Name != "ada" {
+		t.Errorf("updated %+v, want version %d with the new content by ada", updated, patched.Version+1)
+	}
+	updatedTag, _ := updated.Validators()
+	if _, err := posts.PatchPost(ctx, 1, 1, []byte(`{"title":"notes"}`), updatedTag); err != nil {
+		t.Errorf("PatchPost with the tag of the update = %v", err)
+	}
+
+	// only the author updates a post
+	if _, err := posts.UpdatePost(ctx, 1, 2, &dto.UpdatePostBodyRequest{Title: "mine"}); !gorm.IsRecordNotFoundError(errors.Cause(err)) {
+		t.Errorf("UpdatePost by another user = %v, want not found", err)
+	}
+	if _, err := posts.PatchPost(ctx, 1, 2, []byte(`{"title":"mine"}`), ""); !gorm.IsRecordNotFoundError(errors.Cause(err)) {
+		t.Errorf("PatchPost by another user = %v, want not found", err)
+	}
+}
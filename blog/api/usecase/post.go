@@ -7,67 +7,112 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"blog/domain/dto"
 	"blog/domain/interfaces"
+	"blog/utils/metrics"
 )
 
 type postUsecase struct {
-	db *gorm.DB
+	db      *gorm.DB
+	cascade dto.CascadeMode
+	cache   *ReadCache
 }
 
-func NewPostUsecase(db *gorm.DB) interfaces.PostUsecase {
+func NewPostUsecase(db *gorm.DB, cascade dto.CascadeMode, cache *ReadCache) interfaces.PostUsecase {
 	return &postUsecase{
-		db: db,
+		db:      db,
+		cascade: cascade,
+		cache:   cache,
 	}
 }
 
 func (uc *postUsecase) GetPostById(ctx *gin.Context, postID, authorID int64) (*dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "GetPostById")
+	defer span.End()
+	key := fmt.Sprintf("id:%d:%d", postID, authorID)
+
 	var post dto.Post
-	res := uc.db.Debug().Find(&post, dto.Post{ID: postID})
-	if res.RecordNotFound() {
-		return nil, errors.New("post does not exist")
+	if uc.cache.get(ctx, postsNamespace, key, &post) {
+		return &post, nil
 	}
 
-	if post.ID != 0 {
-		err := uc.db.Model(&dto.User{}).Where("id = ?", authorID).Take(&post.Author).Error
-		if err != nil {
-			return &dto.Post{}, err
-		}
+	res := db.Find(&post, dto.Post{ID: postID})
+	if res.RecordNotFound() {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "post %d", postID)
 	}
-	err := uc.db.Debug().Model(&dto.Tag{}).Where("id=?", post.TagsID).Take(&post.Tags).Error
-	if err != nil {
+
+	if err := embedPost(db, &post); err != nil {
 		return &dto.Post{}, err
 	}
 
+	uc.cache.set(ctx, postsNamespace, key, &post)
 	return &post, nil
 }
 
 func (uc *postUsecase) GetAllPosts(ctx *gin.Context, limit int, offset int) (*[]dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "GetAllPosts")
+	defer span.End()
+	key := fmt.Sprintf("list:%d:%d", limit, offset)
+
 	posts := []dto.Post{}
-	err := uc.db.Model(&dto.Post{}).Limit(limit).Find(&posts).Error
+	if uc.cache.get(ctx, postsNamespace, key, &posts) {
+		return &posts, nil
+	}
+
+	err := db.Model(&dto.Post{}).Order("id").Limit(limit).Offset(offset).Find(&posts).Error
 	if err != nil {
 		return nil, err
 	}
 
-	if len(posts) > 0 {
-		for i, _ := range posts {
-			err := uc.db.Model(&dto.Post{}).Where("id = ?", posts[i].AuthorID).Take(&posts[i].Author).Error
-			if err != nil {
-				return &[]dto.Post{}, err
-			}
-			err = uc.db.Debug().Model(&dto.Tag{}).Where("id=?", posts[i].TagsID).Take(&posts[i].Tags).Error
-			if err != nil {
-				return &[]dto.Post{}, err
-			}
-		}
+	if err := loadPostRelations(db, posts); err != nil {
+		return &[]dto.Post{}, err
 	}
 
+	uc.cache.set(ctx, postsNamespace, key, posts)
 	return &posts, nil
 }
 
+func (uc *postUsecase) GetPostsByIds(ctx *gin.Context, postIDs []int64) ([]dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "GetPostsByIds")
+	defer span.End()
+	return findPostsIn(db, "id", postIDs)
+}
+
+func (uc *postUsecase) GetPostsByAuthorIds(ctx *gin.Context, authorIDs []int64) ([]dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "GetPostsByAuthorIds")
+	defer span.End()
+	return findPostsIn(db, "author_id", authorIDs)
+}
+
+// findPostsIn returns the posts whose column holds one of values, with their relations.
+func findPostsIn(db *gorm.DB, column string, values []int64) ([]dto.Post, error) {
+	posts := []dto.Post{}
+	err := inBatches(values, func(batch []int64) error {
+		var found []dto.Post
+		if err := db.Where(column+" IN (?)", batch).Order("id").Find(&found).Error; err != nil {
+			return err
+		}
+		posts = append(posts, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadPostRelations(db, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
 func (uc *postUsecase) CreatePost(ctx *gin.Context, authorID int64, request *dto.PostCreate) (dto.CreatePostResponse, error) {
-	tx := uc.db.Begin()
+	db, span := instrument(ctx, uc.db, "post", "CreatePost")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace, tagsNamespace)
+
+	tx := db.Begin()
 	if tx.Error != nil {
 		return dto.CreatePostResponse{}, tx.Error
 	}
@@ -81,7 +126,7 @@ func (uc *postUsecase) CreatePost(ctx *gin.Context, authorID int64, request *dto
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	err := uc.db.Debug().Create(&post).Error
+	err := db.Create(&post).Error
 	if err != nil {
 		return dto.CreatePostResponse{}, err
 	}
@@ -90,9 +135,10 @@ func (uc *postUsecase) CreatePost(ctx *gin.Context, authorID int64, request *dto
 	if res.Error != nil {
 		return dto.CreatePostResponse{}, res.Error
 	}
+	metrics.PostCreated()
 
 	if post.ID != 0 {
-		err := uc.db.Debug().Model(&dto.Tag{}).Where("id = ?", request.TagsID).Take(&post.Tags).Error
+		err := db.Model(&dto.Tag{}).Where("id = ?", request.TagsID).Take(&post.Tags).Error
 		if err != nil {
 			return dto.CreatePostResponse{}, err
 		}
@@ -115,43 +161,147 @@ func (uc *postUsecase) CreatePost(ctx *gin.Context, authorID int64, request *dto
 }
 
 func (uc *postUsecase) UpdatePost(ctx *gin.Context, postID, authorID int64, request *dto.UpdatePostBodyRequest) (*dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "UpdatePost")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace, tagsNamespace)
 
-	var resp dto.Post
 	author := map[string]interface{}{
 		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
 	}
 
 	if len(request.Title) != 0 {
 		author["title"] = request.Title
 	}
 
-	res := uc.db.Model(&resp).Where("id=?", postID).Take(&resp).Updates(author)
+	if len(request.Content) != 0 {
+		author["content"] = request.Content
+	}
+
+	res := db.Model(&dto.Post{}).Where("id = ? AND author_id = ?", postID, authorID).Updates(author)
 	if res.Error != nil {
 		return &dto.Post{}, res.Error
 	}
+	if res.RowsAffected == 0 {
+		return &dto.Post{}, errors.Wrapf(gorm.ErrRecordNotFound, "post %d of user %d", postID, authorID)
+	}
 
-	if resp.ID != 0 {
-		err := uc.db.Debug().Model(&dto.User{}).Where("id = ?", resp.AuthorID).Take(&resp.Author).Error
-		if err != nil {
-			return &dto.Post{}, err
-		}
+	// read back the version the update made
+	var resp dto.Post
+	err := db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp).Error
+	if err != nil {
+		return &dto.Post{}, err
+	}
+
+	if err := embedPost(db, &resp); err != nil {
+		return &dto.Post{}, err
 	}
 
 	return &resp, nil
 }
 
-func (uc *postUsecase) DeletePost(ctx *gin.Context, postID, authorID int64) error {
-	res := uc.db.Model(&dto.Post{}).Where("id = ? and author_id=?", postID, authorID).Take(&dto.Post{}).Delete(&dto.Post{})
+func (uc *postUsecase) PatchPost(ctx *gin.Context, postID, authorID int64, patch []byte, ifMatch string) (*dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "post", "PatchPost")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace, tagsNamespace)
+
+	var post dto.Post
+	err := db.Model(&dto.Post{}).Where("id = ? AND author_id = ?", postID, authorID).Take(&post).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the client read the post with the rows it embeds
+	if err := embedPost(db, &post); err != nil {
+		return nil, err
+	}
+	current, _ := post.Validators()
+	if err := checkIfMatch(ifMatch, current); err != nil {
+		return nil, err
+	}
+
+	doc := dto.PostPatch{Title: post.Title, Content: post.Content, TagsID: post.TagsID}
+	if err := applyMergePatch(&doc, patch); err != nil {
+		return nil, err
+	}
+
+	res := db.Model(&dto.Post{}).Where("id = ? AND version = ?", postID, post.Version).UpdateColumns(map[string]interface{}{
+		"title":      doc.Title,
+		"content":    doc.Content,
+		"tags_id":    doc.TagsID,
+		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
+	})
 	if res.Error != nil {
-		return res.Error
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, dto.ErrPreconditionFailed
 	}
+
+	var resp dto.Post
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := embedPost(db, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (uc *postUsecase) DeletePost(ctx *gin.Context, postID, authorID int64) error {
+	db, span := instrument(ctx, uc.db, "post", "DeletePost")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace, tagsNamespace)
+
+	err := db.Model(&dto.Post{}).Where("id = ? and author_id=?", postID, authorID).Take(&dto.Post{}).Error
+	if err != nil {
+		return err
+	}
+
+	if uc.cascade == dto.CascadeBlock {
+		var count int
+		if err := db.Model(&dto.Comment{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.Wrapf(dto.ErrDeleteBlocked, "post %d still has %d comments", postID, count)
+		}
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := softDeletePosts(tx, []int64{postID}, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	logger(ctx).Info("post deleted", zap.Int64("post_id", postID), zap.String("cascade", string(uc.cascade)))
 	return nil
 }
 
-func AddTag(db *gorm.DB, post *dto.Post, tag *dto.Tag) error {
+// embedPost loads the author, tags and comment count the representation of a post embeds.
+func embedPost(db *gorm.DB, post *dto.Post) error {
+	posts := []dto.Post{*post}
+	if err := loadPostRelations(db, posts); err != nil {
+		return err
+	}
+	*post = posts[0]
+	return nil
+}
 
-	fmt.Println("t----", tag)
-	res := db.Model(&post).Debug().Association("Tags").Append(tag)
+func AddTag(db *gorm.DB, post *dto.Post, tag *dto.Tag) error {
+	res := db.Model(&post).Association("Tags").Append(tag)
 	return res.Error
 }
 
@@ -163,7 +313,7 @@ func CreateTag(db *gorm.DB, tagName string, postId int64) (*dto.Tag, error) {
 	}
 
 	if tag.ID != 0 {
-		err := db.Debug().Model(&dto.Post{}).Where("id = ?", postId).Take(&tag.Post).Error
+		err := db.Model(&dto.Post{}).Where("id = ?", postId).Take(&tag.Post).Error
 		if err != nil {
 			return &dto.Tag{}, err
 		}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+
+	"blog/domain/dto"
+)
+
+// queryCounter counts the statements gorm sends to the database.
+type queryCounter struct {
+	n int64
+}
+
+func (c *queryCounter) register(db *gorm.DB) {
+	count := func(*gorm.Scope) { atomic.AddInt64(&c.n, 1) }
+	db.Callback().Query().After("gorm:query").Register("bench:count_query", count)
+	db.Callback().RowQuery().After("gorm:row_query").Register("bench:count_row_query", count)
+}
+
+func (c *queryCounter) reset() { atomic.StoreInt64(&c.n, 0) }
+
+func (c *queryCounter) load() int64 { return atomic.LoadInt64(&c.n) }
+
+// seedPosts creates n posts spread over a handful of authors and tags, each with two comments.
+func seedPosts(b *testing.B, n int) (*gorm.DB, *queryCounter) {
+	b.Helper()
+
+	db, err := gorm.Open("sqlite3", filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	db.LogMode(false)
+	db.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{})
+
+	tx := db.Begin()
+	for i := 1; i <= 10; i++ {
+		tx.Create(&dto.User{Name: fmt.Sprintf("user %d", i)})
+		tx.Create(&dto.Tag{Name: fmt.Sprintf("tag %d", i)})
+	}
+	for i := 1; i <= n; i++ {
+		post := dto.Post{
+			Title:    fmt.Sprintf("post %d", i),
+			Content:  "content",
+			AuthorID: int64(i%10 + 1),
+			TagsID:   int64(i%10 + 1),
+		}
+		tx.Create(&post)
+		for j := 0; j < 2; j++ {
+			tx.Create(&dto.Comment{PostID: post.ID, Name: "reader", Body: "comment"})
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		b.Fatal(err)
+	}
+
+	counter := &queryCounter{}
+	counter.register(db)
+	return db, counter
+}
+
+func benchContext() *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	return ctx
+}
+
+func BenchmarkGetAllPosts(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("posts=%d", n), func(b *testing.B) {
+			db, counter := seedPosts(b, n)
+			uc := NewPostUsecase(db, dto.CascadeDelete, nil)
+			ctx := benchContext()
+
+			b.ReportAllocs()
+			counter.reset()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				posts, err := uc.GetAllPosts(ctx, n, 0)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(*posts) != n {
+					b.Fatalf("got %d posts, want %d", len(*posts), n)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(counter.load())/float64(b.N), "queries/op")
+		})
+	}
+}
+
+func BenchmarkGetPostById(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("posts=%d", n), func(b *testing.B) {
+			db, counter := seedPosts(b, n)
+			uc := NewPostUsecase(db, dto.CascadeDelete, nil)
+			ctx := benchContext()
+
+			b.ReportAllocs()
+			counter.reset()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				postID := int64(i%n + 1)
+				if _, err := uc.GetPostById(ctx, postID, postID%10+1); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(counter.load())/float64(b.N), "queries/op")
+		})
+	}
+}
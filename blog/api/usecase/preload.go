@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"blog/domain/dto"
+)
+
+// preloadBatchSize keeps IN lists below the bound variable limit of the database
+// (999 on older sqlite builds).
+const preloadBatchSize = 500
+
+type postCommentsCount struct {
+	PostID int64
+	Count  int64
+}
+
+// loadPostRelations fills the author, tags and comment count of the given posts with
+// one query per relation and batch of preloadBatchSize posts, instead of one per post.
+func loadPostRelations(db *gorm.DB, posts []dto.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]int64, 0, len(posts))
+	authorIDs := make([]int64, 0, len(posts))
+	tagIDs := make([]int64, 0, len(posts))
+	seenAuthors := map[int64]bool{}
+	seenTags := map[int64]bool{}
+	for i := range posts {
+		postIDs = append(postIDs, posts[i].ID)
+		if !seenAuthors[posts[i].AuthorID] {
+			seenAuthors[posts[i].AuthorID] = true
+			authorIDs = append(authorIDs, posts[i].AuthorID)
+		}
+		if posts[i].TagsID != 0 && !seenTags[posts[i].TagsID] {
+			seenTags[posts[i].TagsID] = true
+			tagIDs = append(tagIDs, posts[i].TagsID)
+		}
+	}
+
+	authorsByID := make(map[int64]dto.User, len(authorIDs))
+	err := inBatches(authorIDs, func(ids []int64) error {
+		var authors []dto.User
+		if err := db.Where("id IN (?)", ids).Find(&authors).Error; err != nil {
+			return err
+		}
+		for _, a := range authors {
+			authorsByID[a.ID] = a
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tagsByID := make(map[int64]dto.Tag, len(tagIDs))
+	err = inBatches(tagIDs, func(ids []int64) error {
+		var tags []dto.Tag
+		if err := db.Where("id IN (?)", ids).Find(&tags).Error; err != nil {
+			return err
+		}
+		for _, t := range tags {
+			tagsByID[t.ID] = t
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	countsByPost := make(map[int64]int64, len(postIDs))
+	err = inBatches(postIDs, func(ids []int64) error {
+		var counts []postCommentsCount
+		err := db.Model(&dto.Comment{}).
+			Select("post_id, count(*) AS count").
+			Where("post_id IN (?)", ids).
+			Group("post_id").
+			Scan(&counts).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range counts {
+			countsByPost[c.PostID] = c.Count
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Author = authorsByID[posts[i].AuthorID]
+		if tag, ok := tagsByID[posts[i].TagsID]; ok {
+			posts[i].Tags = []dto.Tag{tag}
+		}
+		posts[i].CommentsCount = countsByPost[posts[i].ID]
+	}
+
+	return nil
+}
+
+// inBatches calls fn with consecutive chunks of ids of at most preloadBatchSize elements.
+func inBatches(ids []int64, fn func(ids []int64) error) error {
+	for start := 0; start < len(ids); start += preloadBatchSize {
+		end := start + preloadBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+type siteUsecase struct {
+	db *gorm.DB
+}
+
+func NewSiteUsecase(db *gorm.DB) interfaces.SiteUsecase {
+	return &siteUsecase{
+		db: db,
+	}
+}
+
+func (uc *siteUsecase) GetHomePage(ctx *gin.Context, page int) (*dto.PostList, error) {
+	db, span := instrument(ctx, uc.db, "site", "GetHomePage")
+	defer span.End()
+
+	list := &dto.PostList{Page: page}
+	if err := listPosts(db, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (uc *siteUsecase) GetAuthorPage(ctx *gin.Context, authorID int64, page int) (*dto.PostList, error) {
+	db, span := instrument(ctx, uc.db, "site", "GetAuthorPage")
+	defer span.End()
+
+	var author dto.User
+	if err := db.Where("id = ?", authorID).Take(&author).Error; err != nil {
+		return nil, errors.Wrapf(err, "author %d", authorID)
+	}
+
+	list := &dto.PostList{Author: &author, Page: page}
+	if err := listPosts(db.Where("author_id = ?", authorID), list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (uc *siteUsecase) GetTagPage(ctx *gin.Context, name string, page int) (*dto.PostList, error) {
+	db, span := instrument(ctx, uc.db, "site", "GetTagPage")
+	defer span.End()
+
+	var tag dto.Tag
+	if err := db.Where("name = ?", name).Take(&tag).Error; err != nil {
+		return nil, errors.Wrapf(err, "tag %q", name)
+	}
+
+	list := &dto.PostList{Tag: &tag, Page: page}
+	if err := listPosts(taggedWith(db, &tag), list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (uc *siteUsecase) GetPostPage(ctx *gin.Context, postID int64) (*dto.PostView, error) {
+	db, span := instrument(ctx, uc.db, "site", "GetPostPage")
+	defer span.End()
+
+	var post dto.Post
+	if err := db.Where("id = ?", postID).Take(&post).Error; err != nil {
+		return nil, errors.Wrapf(err, "post %d", postID)
+	}
+	posts := []dto.Post{post}
+	if err := loadPostRelations(db, posts); err != nil {
+		return nil, err
+	}
+
+	view := &dto.PostView{Post: posts[0], Tags: posts[0].Tags, Comments: []dto.Comment{}}
+	var created []dto.Tag
+	if err := db.Where("post_id = ?", postID).Order("id").Find(&created).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range created {
+		if post.TagsID != t.ID {
+			view.Tags = append(view.Tags, t)
+		}
+	}
+	if err := db.Where("post_id = ?", postID).Order("id").Find(&view.Comments).Error; err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// listPosts fills list with its page of the posts selected by db, newest first, and their
+// total count.
+func listPosts(db *gorm.DB, list *dto.PostList) error {
+	if err := db.Model(&dto.Post{}).Count(&list.Total).Error; err != nil {
+		return err
+	}
+
+	list.Posts = []dto.Post{}
+	err := db.Order("created_at desc, id desc").
+		Limit(dto.SitePageSize).
+		Offset((list.Page - 1) * dto.SitePageSize).
+		Find(&list.Posts).Error
+	if err != nil {
+		return err
+	}
+	return loadPostRelations(db.New(), list.Posts)
+}
@@ -13,40 +13,73 @@ import (
 )
 
 type tagsUsecase struct {
-	db *gorm.DB
+	db    *gorm.DB
+	cache *ReadCache
 }
 
-func NewTagsUsecase(db *gorm.DB) interfaces.TagsUsecase {
+func NewTagsUsecase(db *gorm.DB, cache *ReadCache) interfaces.TagsUsecase {
 	return &tagsUsecase{
-		db: db,
+		db:    db,
+		cache: cache,
 	}
 }
 
 func (uc *tagsUsecase) GetTagById(ctx *gin.Context, tagsID, postID int64) (*dto.Tag, error) {
+	db, span := instrument(ctx, uc.db, "tags", "GetTagById")
+	defer span.End()
+	key := fmt.Sprintf("id:%d:%d", tagsID, postID)
+
 	var tag dto.Tag
-	res := uc.db.Debug().Find(&tag, dto.Tag{ID: tagsID})
+	if uc.cache.get(ctx, tagsNamespace, key, &tag) {
+		return &tag, nil
+	}
+
+	res := db.Find(&tag, dto.Tag{ID: tagsID})
 	if res.RecordNotFound() {
-		return nil, errors.New("post does not exist")
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "tag %d", tagsID)
 	}
 
 	if tag.ID != 0 {
-		err := uc.db.Model(&dto.Post{}).Where("id = ?", postID).Take(&tag.Post).Error
+		err := db.Model(&dto.Post{}).Where("id = ?", postID).Take(&tag.Post).Error
 		if err != nil {
 			return &dto.Tag{}, err
 		}
 	}
-	fmt.Println("tags---", tag.Post.Tags)
+	uc.cache.set(ctx, tagsNamespace, key, &tag)
 	return &tag, nil
 }
 
+func (uc *tagsUsecase) GetTagsByPostIds(ctx *gin.Context, postIDs []int64) ([]dto.Tag, error) {
+	db, span := instrument(ctx, uc.db, "tags", "GetTagsByPostIds")
+	defer span.End()
+
+	tags := []dto.Tag{}
+	err := inBatches(postIDs, func(ids []int64) error {
+		var batch []dto.Tag
+		if err := db.Where("post_id IN (?)", ids).Order("id").Find(&batch).Error; err != nil {
+			return err
+		}
+		tags = append(tags, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
 func (uc *tagsUsecase) CreateTag(ctx *gin.Context, postID int64, request *dto.Tag) (dto.CreateTagsResponse, error) {
+	db, span := instrument(ctx, uc.db, "tags", "CreateTag")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, tagsNamespace, postsNamespace)
+
 	request.PostID = postID
 	tag := &dto.Tag{
 		Name:   request.Name,
 		PostID: request.PostID,
 	}
 
-	err := uc.db.Debug().Create(&tag).Error
+	err := db.Create(&tag).Error
 	if err != nil {
 		return dto.CreateTagsResponse{}, err
 	}
@@ -59,32 +92,100 @@ func (uc *tagsUsecase) CreateTag(ctx *gin.Context, postID int64, request *dto.Ta
 }
 
 func (uc *tagsUsecase) UpdateTags(ctx *gin.Context, tagID, postID int64, request *dto.UpdateTagsBodyRequest) (*dto.Tag, error) {
-	var resp dto.Tag
+	db, span := instrument(ctx, uc.db, "tags", "UpdateTags")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, tagsNamespace, postsNamespace)
+
 	tag := map[string]interface{}{
 		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
 	}
 
 	if len(request.Name) != 0 {
 		tag["name"] = request.Name
 	}
 
-	res := uc.db.Model(&resp).Where("id=?", tagID).Take(&resp).Updates(tag)
+	res := db.Model(&dto.Tag{}).Where("id = ? AND post_id = ?", tagID, postID).Updates(tag)
 	if res.Error != nil {
 		return &dto.Tag{}, res.Error
 	}
+	if res.RowsAffected == 0 {
+		return &dto.Tag{}, errors.Wrapf(gorm.ErrRecordNotFound, "tag %d of post %d", tagID, postID)
+	}
 
-	if resp.ID != 0 {
-		err := uc.db.Debug().Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
-		if err != nil {
-			return &dto.Tag{}, err
-		}
+	// read back the version the update made
+	var resp dto.Tag
+	err := db.Model(&dto.Tag{}).Where("id = ?", tagID).Take(&resp).Error
+	if err != nil {
+		return &dto.Tag{}, err
+	}
+
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
+	if err != nil {
+		return &dto.Tag{}, err
+	}
+
+	return &resp, nil
+}
+
+func (uc *tagsUsecase) PatchTag(ctx *gin.Context, tagID, postID int64, patch []byte, ifMatch string) (*dto.Tag, error) {
+	db, span := instrument(ctx, uc.db, "tags", "PatchTag")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, tagsNamespace, postsNamespace)
+
+	var tag dto.Tag
+	err := db.Model(&dto.Tag{}).Where("id = ? AND post_id = ?", tagID, postID).Take(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the client read the tag with its post
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&tag.Post).Error
+	if err != nil {
+		return nil, err
+	}
+	current, _ := tag.Validators()
+	if err := checkIfMatch(ifMatch, current); err != nil {
+		return nil, err
+	}
+
+	doc := dto.TagPatch{Name: tag.Name}
+	if err := applyMergePatch(&doc, patch); err != nil {
+		return nil, err
+	}
+
+	res := db.Model(&dto.Tag{}).Where("id = ? AND version = ?", tagID, tag.Version).UpdateColumns(map[string]interface{}{
+		"name":       doc.Name,
+		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, dto.ErrPreconditionFailed
+	}
+
+	var resp dto.Tag
+	err = db.Model(&dto.Tag{}).Where("id = ?", tagID).Take(&resp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&resp.Post).Error
+	if err != nil {
+		return nil, err
 	}
 
 	return &resp, nil
 }
 
 func (uc *tagsUsecase) DeleteTags(ctx *gin.Context, tagsID, PostID int64) error {
-	res := uc.db.Model(&dto.Tag{}).Where("id = ? and post_id=?", tagsID, PostID).Take(&dto.Tag{}).Delete(&dto.Tag{})
+	db, span := instrument(ctx, uc.db, "tags", "DeleteTags")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, tagsNamespace, postsNamespace)
+
+	res := db.Model(&dto.Tag{}).Where("id = ? and post_id=?", tagsID, PostID).Take(&dto.Tag{}).Delete(&dto.Tag{})
 	if res.Error != nil {
 		return res.Error
 	}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+type trashUsecase struct {
+	db    *gorm.DB
+	cache *ReadCache
+}
+
+func NewTrashUsecase(db *gorm.DB, cache *ReadCache) interfaces.TrashUsecase {
+	return &trashUsecase{
+		db:    db,
+		cache: cache,
+	}
+}
+
+func (uc *trashUsecase) GetTrash(ctx *gin.Context, limit int, offset int) (*dto.Trash, error) {
+	db, span := instrument(ctx, uc.db, "trash", "GetTrash")
+	defer span.End()
+	trash := dto.Trash{
+		Users:    []dto.User{},
+		Posts:    []dto.Post{},
+		Comments: []dto.Comment{},
+	}
+
+	// one page across the three tables, so that limit and offset count rows of any kind
+	var page []struct {
+		Kind string
+		ID   int64
+	}
+	err := db.Raw(`SELECT kind, id FROM (
+		SELECT 'user' AS kind, id, deleted_at FROM users WHERE deleted_at IS NOT NULL
+		UNION ALL SELECT 'post', id, deleted_at FROM posts WHERE deleted_at IS NOT NULL
+		UNION ALL SELECT 'comment', id, deleted_at FROM comments WHERE deleted_at IS NOT NULL
+	) AS trash ORDER BY deleted_at DESC, kind, id LIMIT ? OFFSET ?`, limit, offset).Scan(&page).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string][]int64{}
+	for _, row := range page {
+		ids[row.Kind] = append(ids[row.Kind], row.ID)
+	}
+	deleted := db.Unscoped().Order("deleted_at desc, id")
+	if len(ids["user"]) > 0 {
+		if err := deleted.Where("id IN (?)", ids["user"]).Find(&trash.Users).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids["post"]) > 0 {
+		if err := deleted.Where("id IN (?)", ids["post"]).Find(&trash.Posts).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(ids["comment"]) > 0 {
+		if err := deleted.Where("id IN (?)", ids["comment"]).Find(&trash.Comments).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &trash, nil
+}
+
+func (uc *trashUsecase) RestoreUser(ctx *gin.Context, userID int64) (*dto.User, error) {
+	db, span := instrument(ctx, uc.db, "trash", "RestoreUser")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	var user dto.User
+	err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).Take(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// Only the posts removed by the same cascade share the user's deletion time.
+	var postIDs []int64
+	err = tx.Unscoped().Model(&dto.Post{}).Where("author_id = ? AND deleted_at = ?", userID, *user.DeletedAt).Pluck("id", &postIDs).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := restorePosts(tx, postIDs, *user.DeletedAt); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := restore(tx, &dto.User{}, "id = ?", userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	logger(ctx).Info("user restored", zap.Int("posts", len(postIDs)))
+	user.DeletedAt = nil
+	return &user, nil
+}
+
+func (uc *trashUsecase) RestorePost(ctx *gin.Context, postID, authorID int64) (*dto.Post, error) {
+	db, span := instrument(ctx, uc.db, "trash", "RestorePost")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	var post dto.Post
+	err := db.Unscoped().Where("id = ? AND author_id = ? AND deleted_at IS NOT NULL", postID, authorID).Take(&post).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&dto.User{}).Where("id = ?", post.AuthorID).Take(&post.Author).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, errors.New("the author of the post is deleted, restore the author first")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if err := restorePosts(tx, []int64{postID}, *post.DeletedAt); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	logger(ctx).Info("post restored", zap.Int64("post_id", postID))
+	post.DeletedAt = nil
+	return &post, nil
+}
+
+func (uc *trashUsecase) RestoreComment(ctx *gin.Context, commentID, postID int64) (*dto.Comment, error) {
+	db, span := instrument(ctx, uc.db, "trash", "RestoreComment")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, postsNamespace)
+
+	var comment dto.Comment
+	err := db.Unscoped().Where("id = ? AND post_id = ? AND deleted_at IS NOT NULL", commentID, postID).Take(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&dto.Post{}).Where("id = ?", postID).Take(&comment.Post).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, errors.New("the post of the comment is deleted, restore the post first")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restore(db, &dto.Comment{}, "id = ?", commentID); err != nil {
+		return nil, err
+	}
+
+	logger(ctx).Info("comment restored", zap.Int64("comment_id", commentID))
+	comment.DeletedAt = nil
+	return &comment, nil
+}
+
+// Purge hard deletes the rows that were soft deleted before the given time.
+// Children are removed first so no row is left pointing at a purged parent.
+func (uc *trashUsecase) Purge(ctx context.Context, before time.Time) (dto.PurgeResult, error) {
+	db, span := instrument(ctx, uc.db, "trash", "Purge")
+	defer span.End()
+	defer uc.cache.invalidate(ctx, usersNamespace, postsNamespace, tagsNamespace)
+
+	result := dto.PurgeResult{Before: before}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+
+	var postIDs []int64
+	err := tx.Unscoped().Model(&dto.Post{}).Where("deleted_at < ?", before).Pluck("id", &postIDs).Error
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	res := tx.Unscoped().Where("deleted_at < ? OR post_id IN (?)", before, postIDs).Delete(&dto.Comment{})
+	if res.Error != nil {
+		tx.Rollback()
+		return result, res.Error
+	}
+	result.Comments = res.RowsAffected
+
+	if len(postIDs) > 0 {
+		if err := tx.Unscoped().Table("posts_tags").Where("post_id IN (?)", postIDs).Delete(&dto.PostsTags{}).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(&dto.Tag{}).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+	}
+
+	res = tx.Unscoped().Where("id IN (?)", postIDs).Delete(&dto.Post{})
+	if res.Error != nil {
+		tx.Rollback()
+		return result, res.Error
+	}
+	result.Posts = res.RowsAffected
+
+	res = tx.Unscoped().Where("deleted_at < ?", before).Delete(&dto.User{})
+	if res.Error != nil {
+		tx.Rollback()
+		return result, res.Error
+	}
+	result.Users = res.RowsAffected
+
+	return result, tx.Commit().Error
+}
+
+// RunPurgeJob purges the trash every interval, removing rows older than the retention period,
+// until the context is cancelled.
+func RunPurgeJob(ctx context.Context, trash interfaces.TrashUsecase, retention, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			res, err := trash.Purge(ctx, now.Add(-retention))
+			if err != nil {
+				logger.Error("failed to purge trash", zap.Error(err))
+				continue
+			}
+			logger.Info("purged trash",
+				zap.Int64("users", res.Users),
+				zap.Int64("posts", res.Posts),
+				zap.Int64("comments", res.Comments))
+		}
+	}
+}
+
+// softDeletePosts marks the given posts and their comments as deleted at the same time,
+// so that restoring a post brings back exactly the comments removed with it.
+func softDeletePosts(tx *gorm.DB, postIDs []int64, deletedAt time.Time) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	err := tx.Model(&dto.Comment{}).Where("post_id IN (?)", postIDs).UpdateColumn("deleted_at", deletedAt).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&dto.Post{}).Where("id IN (?)", postIDs).UpdateColumn("deleted_at", deletedAt).Error
+}
+
+func restorePosts(tx *gorm.DB, postIDs []int64, deletedAt time.Time) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	err := restore(tx, &dto.Comment{}, "post_id IN (?) AND deleted_at = ?", postIDs, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	return restore(tx, &dto.Post{}, "id IN (?)", postIDs)
+}
+
+func restore(tx *gorm.DB, model interface{}, query interface{}, args ...interface{}) error {
+	return tx.Unscoped().Model(model).Where(query, args...).UpdateColumn("deleted_at", gorm.Expr("NULL")).Error
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+)
+
+// newTestDB opens an empty blog database in a temporary file.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "blog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.LogMode(false)
+	db.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{})
+	return db
+}
+
+func TestTrash(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&dto.User{Name: "ada"})
+	db.Create(&dto.User{Name: "charles"})
+	db.Create(&dto.Post{Title: "notes", Content: "on the analytical engine", AuthorID: 1})
+	db.Create(&dto.Post{Title: "sketch", Content: "of the engine", AuthorID: 1})
+	db.Create(&dto.Post{Title: "difference engine", Content: "no. 2", AuthorID: 2})
+	db.Create(&dto.Comment{Name: "charles", Body: "splendid", PostID: 1})
+
+	users := NewUserUsecase(db, dto.CascadeDelete, nil, nil)
+	trash := NewTrashUsecase(db, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	// ada goes with her posts and the comment on them, the posts of charles stay
+	if err := users.DeleteUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// a page counts the rows of every kind, the comment, the posts, then the user
+	var pages [][3]int
+	for offset := 0; offset < 6; offset += 2 {
+		page, err := trash.GetTrash(ctx, 2, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, [3]int{len(page.Users), len(page.Posts), len(page.Comments)})
+	}
+	if want := [][3]int{{0, 1, 1}, {1, 1, 0}, {0, 0, 0}}; !equalPages(pages, want) {
+		t.Errorf("trash pages of users, posts and comments %v, want %v", pages, want)
+	}
+
+	if _, err := trash.RestoreUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	var posts, comments int
+	db.Model(&dto.Post{}).Count(&posts)
+	db.Model(&dto.Comment{}).Count(&comments)
+	if posts != 3 || comments != 1 {
+		t.Errorf("%d posts and %d comments after the restore, want 3 and 1", posts, comments)
+	}
+
+	if err := users.DeleteUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	// rows deleted after the cutoff are kept
+	res, err := trash.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Users+res.Posts+res.Comments != 0 {
+		t.Errorf("purged %+v of the rows deleted after the cutoff", res)
+	}
+	res, err = trash.Purge(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Users != 1 || res.Posts != 2 || res.Comments != 1 {
+		t.Errorf("purged %d users, %d posts and %d comments, want 1, 2 and 1", res.Users, res.Posts, res.Comments)
+	}
+	db.Unscoped().Model(&dto.Post{}).Count(&posts)
+	if posts != 1 {
+		t.Errorf("%d posts left after the purge, want the one of charles", posts)
+	}
+}
+
+func TestGhostNameIsReserved(t *testing.T) {
+	db := newTestDB(t)
+	db.Create(&dto.User{Name: "ada"})
+	db.Create(&dto.Post{Title: "notes", Content: "on the analytical engine", AuthorID: 1})
+
+	users := NewUserUsecase(db, dto.CascadeReassign, nil, nil)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if _, err := users.CreateUser(ctx, &dto.User{Name: " Ghost "}); !errors.Is(err, dto.ErrNameReserved) {
+		t.Errorf("CreateUser(Ghost) = %v, want ErrNameReserved", err)
+	}
+	if _, err := users.UpdateUser(ctx, 1, &dto.UpdateUserBodyRequest{Name: "GHOST"}); !errors.Is(err, dto.ErrNameReserved) {
+		t.Errorf("UpdateUser(GHOST) = %v, want ErrNameReserved", err)
+	}
+
+	// deleting ada hands her post to the ghost, which keeps its name
+	if err := users.DeleteUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	var ghost dto.User
+	if err := db.Where("name = ?", dto.GhostUserName).Take(&ghost).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.UpdateUser(ctx, ghost.ID, &dto.UpdateUserBodyRequest{Name: dto.GhostUserName}); err != nil {
+		t.Errorf("UpdateUser(ghost) = %v", err)
+	}
+}
+
+func equalPages(a, b [][3]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+// Command openapi writes the OpenAPI description of the API generated from the route tables
+// of the handlers, the one the server serves at /docs/swagger.yaml.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"blog/api/delivery/httphandler"
+)
+
+func main() {
+	out := flag.String("o", "docs/swagger.yaml", "file the description is written to")
+	flag.Parse()
+
+	doc, err := httphandler.OpenAPI(httphandler.BasePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to describe the API: %+v\n", err)
+		os.Exit(1)
+	}
+	spec, err := doc.YAML()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to encode the description: %+v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to write the description: %+v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -9,36 +11,130 @@ import (
 	"github.com/gin-contrib/static"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"blog/api/delivery/feedhandler"
+	"blog/api/delivery/graphqlhandler"
+	"blog/api/delivery/grpchandler"
 	"blog/api/delivery/httphandler"
+	"blog/api/delivery/webhandler"
 	"blog/api/middleware"
 	"blog/api/middleware/swagger"
 	"blog/api/usecase"
+	"blog/config"
 	"blog/db"
 	"blog/domain/dto"
+	"blog/domain/interfaces"
+	"blog/utils/cache"
+	"blog/utils/httputil"
+	"blog/utils/log"
+	"blog/utils/mail"
+	"blog/utils/metrics"
+	"blog/utils/ratelimit"
+	"blog/utils/storage"
+	"blog/utils/tracing"
+	"blog/utils/validation"
 )
 
 func main() {
 
+	cfg, err := config.Load()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to load config: %+v\n", err)
+		os.Exit(1)
+	}
+
+	if err := validation.Register(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to register validators: %+v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := log.NewLogger(cfg.Log.Level)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to create logger: %+v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = logger.Sync() }()
+	zap.ReplaceGlobals(logger)
+
+	sqlLevel, err := log.ParseLevel(cfg.Log.SQLLevel)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Invalid LOG_SQL_LEVEL: %+v\n", err)
+		os.Exit(1)
+	}
+
+	// tracer provider of the request spans
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to set up tracing: %+v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	// connect to db
-	conn, err := db.Connect()
+	conn, err := db.Connect(logger)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to connect to db: %+v\n", err)
 	}
 
+	// gorm's own messages go to zap instead of stdout
+	conn.SetLogger(log.NewGormLogger(logger))
+
 	//auto migrations
-	conn.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{})
+	conn.AutoMigrate(&dto.User{}, &dto.Post{}, &dto.Tag{}, &dto.Comment{}, &dto.Media{}, &dto.MediaVariant{}, &dto.AccountToken{})
+
+	// time, trace and log the statements of the usecases
+	metrics.InstrumentDB(conn)
+	tracing.InstrumentDB(conn)
+	log.InstrumentDB(conn, logger, sqlLevel, cfg.Log.SlowQuery)
+
 	// New gin server
 	r := gin.New()
 
+	// believe the forwarded client address of the trusted proxies only, clients could pick
+	// their own address, and rate limit bucket, otherwise
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Invalid TRUSTED_PROXIES: %+v\n", err)
+		os.Exit(1)
+	}
+
+	// tag every request and response with an id, first so that even early errors carry it
+	r.Use(middleware.RequestID())
+
+	// count every request, including the ones answered by the middlewares below
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.RequestLogger(logger))
+
+	// cross-origin calls from the allowed browser origins, preflights included
+	cors, err := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Invalid CORS configuration: %+v\n", err)
+		os.Exit(1)
+	}
+	r.Use(cors)
+
 	// inject middlewares
 	// newLogger
 	// recover
 	// swagger editor
 
-	logger, _ := zap.NewProduction()
-	r.Use(middleware.JSONMiddleware())
+	// JSON, XML, YAML or MessagePack API responses, as accepted by the client
+	r.Use(middleware.ContentNegotiation("/api/"))
 
 	/*  Add a ginzap middleware, which:
 	    - Logs all requests, like a combined access and error log.
@@ -46,38 +142,254 @@ func main() {
 		- RFC3339 with UTC time format.
 	*/
 
-	// Host Swagger middleware
-	r.Use(gin.WrapH(swagger.Middleware()))
+	// Host Swagger middleware, serving the description generated from the handlers
+	apiDoc, err := httphandler.OpenAPI(httphandler.BasePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to describe the API: %+v\n", err)
+		os.Exit(1)
+	}
+	spec, err := apiDoc.YAML()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to encode the API description: %+v\n", err)
+		os.Exit(1)
+	}
+	r.Use(gin.WrapH(swagger.Middleware(spec)))
 
-	r.Use(ginzap.Ginzap(logger, time.RFC3339, true))
+	r.Use(ginzap.GinzapWithConfig(logger, &ginzap.Config{
+		TimeFormat: time.RFC3339,
+		UTC:        true,
+		// tie the access log lines to the request ids and traces
+		Context: func(c *gin.Context) []zapcore.Field {
+			ctx := c.Request.Context()
+			return append(tracing.LogFields(ctx), zap.String("request_id", httputil.RequestID(ctx)))
+		},
+	}))
 
 	/* Logs all panic to error log - stack means whether output the stack info. */
 	r.Use(ginzap.RecoveryWithZap(logger, true))
 
-	r.Use(gzip.Gzip(gzip.DefaultCompression))
+	// the uploaded files are compressed already, and served in ranges
+	r.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{dto.MediaPath})))
 
-	// Serve UI files
-	r.Use(static.Serve("/", static.LocalFile("/app/assets", true)))
-	r.NoRoute(func(c *gin.Context) {
-		c.File("/app/assets")
-	})
+	// Serve UI files, with the index of the single-page app when it replaces the rendered pages
+	r.Use(static.Serve("/", static.LocalFile("/app/assets", cfg.Site.SPA)))
 
-	// users endpoints
-	userUsecase := usecase.NewUserUsecase(conn)
-	httphandler.NewUserHandler(r, userUsecase)
+	// one client for all the redis backends
+	var redisClient redis.UniversalClient
+	if cfg.Cache.Backend == "redis" || cfg.RateLimit.Backend == "redis" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+		})
+	}
 
-	//tags endpoints
-	tagsUsecase := usecase.NewTagsUsecase(conn)
-	httphandler.NewTagsHandler(r, tagsUsecase)
+	// read cache shared by the usecases
+	cacheStats := cache.NewStats()
+	readCache := newReadCache(cfg.Cache, redisClient, cacheStats)
+	if err := metrics.RegisterCacheStats(cacheStats); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to register cache metrics: %+v\n", err)
+	}
 
-	//posts endpoints
-	postUsecase := usecase.NewPostUsecase(conn)
-	httphandler.NewPostHandler(r, postUsecase)
+	// prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	//comments endpoints
-	commentsUsecase := usecase.NewCommentsUsecase(conn)
-	httphandler.NewCommentsHandler(r, commentsUsecase)
+	// token buckets of the rate limits
+	rateLimits := ratelimit.NewMemory()
+	if cfg.RateLimit.Backend == "redis" {
+		rateLimits = ratelimit.NewRedis(redisClient, "blog:ratelimit:")
+	}
+	// identify the callers sending an API key, the user and API key limits key on them
+	r.Use(middleware.APIKey(cfg.RateLimit.APIKeys))
+
+	// uploaded files
+	mediaStorage, err := newMediaStorage(cfg.Media)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to open the media storage: %+v\n", err)
+		os.Exit(1)
+	}
+
+	// emails of the verifications and password resets
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to set up the mailer: %+v\n", err)
+		os.Exit(1)
+	}
+
+	accountUsecase := usecase.NewAccountUsecase(conn, mailer, dto.AccountSettings{
+		SiteTitle: cfg.Site.Title,
+		VerifyURL: cfg.Accounts.VerifyURL,
+		ResetURL:  cfg.Accounts.ResetURL,
+		VerifyTTL: cfg.Accounts.VerifyTTL,
+		ResetTTL:  cfg.Accounts.ResetTTL,
+	}, readCache)
+	userUsecase := usecase.NewUserUsecase(conn, cfg.CascadeMode, readCache, accountUsecase)
+	tagsUsecase := usecase.NewTagsUsecase(conn, readCache)
+	postUsecase := usecase.NewPostUsecase(conn, cfg.CascadeMode, readCache)
+	commentsUsecase := usecase.NewCommentsUsecase(conn, readCache)
+	trashUsecase := usecase.NewTrashUsecase(conn, readCache)
+	feedUsecase := usecase.NewFeedUsecase(conn)
+	siteUsecase := usecase.NewSiteUsecase(conn)
+	mediaProcessor := usecase.NewMediaProcessor(conn, mediaStorage, dto.ImageVariants{
+		Widths:        cfg.Media.Widths,
+		ThumbnailSize: cfg.Media.ThumbnailSize,
+		Formats:       cfg.Media.Formats,
+		Quality:       cfg.Media.Quality,
+	}, cfg.Media.Workers)
+	mediaUsecase := usecase.NewMediaUsecase(conn, mediaStorage, dto.MediaLimits{
+		MaxSize:      cfg.Media.MaxSize,
+		AllowedTypes: cfg.Media.AllowedTypes,
+		MaxPixels:    cfg.Media.MaxPixels,
+	}, mediaProcessor)
+
+	// mountV1 registers the v1 endpoints on api. Breaking changes to the resources go to a
+	// new /api/v2 group with its own handlers, leaving v1 clients unaffected.
+	mountV1 := func(api *gin.RouterGroup) {
+		httphandler.NewCacheHandler(api, cacheStats)
+
+		//users endpoints
+		httphandler.NewUserHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "users", cfg.RateLimit.Users),
+			middleware.CacheControl(cfg.CacheControl.Users)), userUsecase)
+
+		//email verification and password reset endpoints
+		httphandler.NewAccountHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "accounts", cfg.RateLimit.Accounts)), accountUsecase)
+
+		//tags endpoints
+		httphandler.NewTagsHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "tags", cfg.RateLimit.Tags),
+			middleware.CacheControl(cfg.CacheControl.Tags)), tagsUsecase)
+
+		//posts endpoints
+		httphandler.NewPostHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "posts", cfg.RateLimit.Posts),
+			middleware.CacheControl(cfg.CacheControl.Posts)), postUsecase)
+
+		//comments endpoints
+		httphandler.NewCommentsHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "comments", cfg.RateLimit.Comments),
+			middleware.CacheControl(cfg.CacheControl.Comments)), commentsUsecase)
+
+		//media endpoints
+		httphandler.NewMediaHandler(api.Group("",
+			middleware.RateLimit(rateLimits, "media", cfg.RateLimit.Media)), mediaUsecase, cfg.Media.MaxSize)
+
+		//trash endpoints
+		httphandler.NewTrashHandler(api, trashUsecase)
+	}
+	mountV1(r.Group(httphandler.BasePath))
+
+	// the unversioned routes predate /api/v1 and serve it until their sunset
+	if cfg.API.LegacyRoutes {
+		mountV1(r.Group("/api", middleware.Deprecation("/api", httphandler.BasePath, cfg.API.Deprecation, cfg.API.Sunset)))
+	}
+
+	// GraphQL endpoint resolving the same usecases, for clients fetching related resources at once
+	graphqlSchema, err := graphqlhandler.NewSchema(userUsecase, postUsecase, tagsUsecase, commentsUsecase)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to build the GraphQL schema: %+v\n", err)
+		os.Exit(1)
+	}
+	graphqlhandler.NewGraphQLHandler(r.Group("",
+		middleware.RateLimit(rateLimits, "graphql", cfg.RateLimit.GraphQL)), graphqlSchema, cfg.GraphQL.MaxComplexity)
+
+	// RSS, Atom and JSON feeds of the latest posts, revalidated like the posts themselves
+	feedhandler.NewFeedHandler(r.Group("",
+		middleware.CacheControl(cfg.CacheControl.Posts)), feedUsecase, cfg.Site.URL, cfg.Site.Title)
+	// sitemap of the posts, tags and authors for the search engines
+	feedhandler.NewSitemapHandler(r.Group("",
+		middleware.CacheControl(cfg.CacheControl.Posts)), feedUsecase, cfg.Site.URL)
+
+	// uploaded files, immutable under their keys
+	webhandler.NewMediaFileHandler(r.Group(""), mediaUsecase)
+	if cfg.Site.SPA {
+		// the single-page app routes the pages in the browser
+		r.NoRoute(webhandler.NewAppHandler("/app/assets/index.html"))
+	} else {
+		// HTML pages of the blog, readable without JavaScript, and the assets of their theme
+		theme, err := webhandler.LoadTheme(webhandler.ThemeConfig{
+			Dir:          cfg.Site.Themes,
+			Name:         cfg.Site.Theme,
+			SettingsFile: cfg.Site.ThemeSettings,
+			Reload:       cfg.Site.ThemeReload,
+		})
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to load the site theme: %+v\n", err)
+			os.Exit(1)
+		}
+		webhandler.NewSiteHandler(r.Group("",
+			middleware.CacheControl(cfg.CacheControl.Posts)), siteUsecase, theme, cfg.Site.URL, cfg.Site.Title)
+		webhandler.NewThemeHandler(r.Group(""), theme)
+		// pages the links of the account emails open
+		webhandler.NewAccountHandler(r.Group("",
+			middleware.RateLimit(rateLimits, "accounts", cfg.RateLimit.Accounts)), accountUsecase, theme, cfg.Site.URL, cfg.Site.Title)
+		r.NoRoute(webhandler.NewNotFoundHandler(theme, cfg.Site.URL, cfg.Site.Title))
+	}
+
+	// gRPC API over the same usecases, for internal services
+	if cfg.GRPC.Addr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "[ERROR] Failed to listen for gRPC on %s: %+v\n", cfg.GRPC.Addr, err)
+			os.Exit(1)
+		}
+		grpcServer := grpchandler.NewServer(logger, userUsecase, postUsecase, tagsUsecase, commentsUsecase)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("gRPC server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// hard delete soft-deleted rows once they are past the retention period
+	go usecase.RunPurgeJob(context.Background(), trashUsecase, cfg.TrashRetention, cfg.PurgeInterval, logger)
+
+	// variants and placeholders of the uploaded images
+	go mediaProcessor.Run(log.WithContext(context.Background(), logger))
 
 	// Start the server
 	_ = r.Run(":8080")
 }
+
+// newMediaStorage opens the storage of the uploaded files.
+func newMediaStorage(cfg config.Media) (interfaces.MediaStorage, error) {
+	if cfg.Storage == "s3" {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			Secure:    cfg.S3.Secure,
+		})
+	}
+	return storage.NewLocal(cfg.Dir)
+}
+
+// newMailer creates the mailer of the configured driver.
+func newMailer(cfg config.Mail) (interfaces.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	case "file":
+		return mail.NewFile(cfg.Dir, cfg.From)
+	}
+	return mail.NewLog(), nil
+}
+
+// newReadCache builds the read cache of the configured backend, or nil when caching is disabled.
+func newReadCache(cfg config.Cache, client redis.UniversalClient, stats *cache.Stats) *usecase.ReadCache {
+	switch cfg.Backend {
+	case "memory":
+		return usecase.NewReadCache(cache.NewLRU(cfg.Size), cfg.TTL, stats)
+	case "redis":
+		return usecase.NewReadCache(cache.NewRedis(client, "blog:"), cfg.TTL, stats)
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+package config
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/utils/ratelimit"
+)
+
+// Config holds the runtime settings read from the environment.
+type Config struct {
+	// CascadeMode decides what happens to the posts and comments of a deleted user or post.
+	CascadeMode dto.CascadeMode
+	// TrashRetention is how long soft-deleted rows are kept before being purged.
+	TrashRetention time.Duration
+	// PurgeInterval is how often the purge job looks for expired rows.
+	PurgeInterval time.Duration
+	// CacheControl is the Cache-Control policy of each route group.
+	CacheControl CacheControl
+	// Cache configures the application read cache.
+	Cache Cache
+	// RateLimit configures the request rate limits of each route group.
+	RateLimit RateLimit
+	// TrustedProxies lists the addresses and CIDR ranges of the proxies whose forwarding
+	// headers give the client address, which the logs and rate limits use. None by default.
+	TrustedProxies []string
+	// Redis is the server of the redis backends of the cache and the rate limiter.
+	Redis Redis
+	// API configures the versions of the API.
+	API API
+	// GraphQL bounds the operations of the GraphQL endpoint.
+	GraphQL GraphQL
+	// GRPC configures the gRPC server.
+	GRPC GRPC
+	// Site describes the public site, for its pages and the links of the feeds.
+	Site Site
+	// Media configures the uploaded files.
+	Media Media
+	// Mail configures how the emails to the users are sent.
+	Mail Mail
+	// Accounts configures the verification of the emails and the password resets.
+	Accounts Accounts
+	// CORS lists the origins allowed to call the API from a browser.
+	CORS CORS
+	// Tracing configures the export of the OpenTelemetry spans.
+	Tracing Tracing
+	// Log configures the application and SQL logs.
+	Log Log
+}
+
+// API configures the unversioned legacy routes, which serve /api/v1 under /api.
+type API struct {
+	// LegacyRoutes mounts the legacy routes.
+	LegacyRoutes bool
+	// Deprecation is when the legacy routes were deprecated.
+	Deprecation time.Time
+	// Sunset is when the legacy routes will be removed, unannounced when zero.
+	Sunset time.Time
+}
+
+// GraphQL bounds the operations run by the GraphQL endpoint.
+type GraphQL struct {
+	// MaxComplexity is the highest estimated cost of an operation: one per field, multiplied
+	// by the number of items of the lists they are selected in.
+	MaxComplexity int
+}
+
+// GRPC configures the gRPC server started next to the HTTP one.
+type GRPC struct {
+	// Addr is the address the server listens on, empty when GRPC_ADDR is "none".
+	Addr string
+}
+
+// Site is the public face of the blog.
+type Site struct {
+	// URL is the absolute URL the site is reached at, without a trailing slash.
+	URL   string
+	Title string
+	// Themes is the directory of the themes, a directory per theme.
+	Themes string
+	// Theme names the theme the pages are rendered with.
+	Theme string
+	// ThemeSettings is a JSON file of values of the settings of the theme, none when empty.
+	ThemeSettings string
+	// ThemeReload parses the theme again when its files change, for development.
+	ThemeReload bool
+	// SPA serves the single-page app of /app/assets for every page instead of rendering them.
+	SPA bool
+}
+
+// Media configures where the uploaded files are stored and which ones are accepted.
+type Media struct {
+	// Storage is "local" to keep the files in Dir or "s3" to keep them in a bucket.
+	Storage string
+	Dir     string
+	S3      S3
+	// MaxSize is the largest file accepted, in bytes.
+	MaxSize int64
+	// AllowedTypes lists the media types accepted, as sniffed from the content of the files.
+	AllowedTypes []string
+	// MaxPixels is the largest image accepted, width times height.
+	MaxPixels int64
+	// Workers is the number of images processed at once.
+	Workers int
+	// Widths lists the widths of the responsive variants of the images.
+	Widths []int
+	// ThumbnailSize is the side of the square thumbnails.
+	ThumbnailSize int
+	// Formats lists the encodings of the variants, "webp" or "jpeg".
+	Formats []string
+	// Quality is the quality of the encodings, from 1 to 100.
+	Quality int
+}
+
+// S3 locates a bucket of an S3 compatible service.
+type S3 struct {
+	// Endpoint is the host and port of the service.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Secure connects over HTTPS.
+	Secure bool
+}
+
+// Mail selects how the emails to the users are sent.
+type Mail struct {
+	// Driver is "smtp" to send the emails, "file" to write them to Dir or "log" to log them.
+	Driver string
+	// From is the sender of the emails, such as "Blog <blog@example.com>".
+	From string
+	Dir  string
+	SMTP SMTP
+}
+
+// SMTP locates the server relaying the emails. STARTTLS is used when the server offers it.
+type SMTP struct {
+	Host string
+	Port int
+	// Username and Password authenticate to the server, anonymously when Username is empty.
+	Username string
+	Password string
+}
+
+// Accounts configures the links and tokens sent to the users by email.
+type Accounts struct {
+	// VerifyURL and ResetURL are the pages the links of the emails open, with the token
+	// in their "token" query parameter. The site renders the default ones, under its URL.
+	VerifyURL string
+	ResetURL  string
+	// VerifyTTL and ResetTTL are how long the tokens can be used.
+	VerifyTTL time.Duration
+	ResetTTL  time.Duration
+}
+
+// Log sets the verbosity of the application logs.
+type Log struct {
+	// Level is the minimum level logged: DEBUG, INFO, WARN or ERROR.
+	Level string
+	// SQLLevel is the level the SQL statements are logged at.
+	SQLLevel string
+	// SlowQuery is the duration past which a statement is logged as a warning whatever SQLLevel is.
+	SlowQuery time.Duration
+}
+
+// Tracing selects where the spans of the requests are exported.
+type Tracing struct {
+	// Exporter is one of "none", "stdout", "file" or "otlp". The otlp exporter reads its
+	// endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// File receives the spans of the file exporter.
+	File string
+	// SampleRatio is the share of the traces started by this service that are recorded.
+	SampleRatio float64
+	ServiceName string
+}
+
+// Cache selects and sizes the backend of the application read cache.
+type Cache struct {
+	// Backend is one of "memory", "redis" or "none".
+	Backend string
+	// Size is the maximum number of entries of the memory backend.
+	Size int
+	// TTL bounds how long a cached read is served.
+	TTL time.Duration
+}
+
+// RateLimit holds the token bucket policy of each route group.
+type RateLimit struct {
+	// Backend is "memory" to limit each instance on its own or "redis" to share the limits.
+	Backend  string
+	Users    ratelimit.Policy
+	Posts    ratelimit.Policy
+	Tags     ratelimit.Policy
+	Comments ratelimit.Policy
+	GraphQL  ratelimit.Policy
+	Media    ratelimit.Policy
+	Accounts ratelimit.Policy
+	// APIKeys identify the callers of the policies keyed by user or API key.
+	APIKeys ratelimit.APIKeys
+}
+
+// CORS configures the cross-origin requests accepted by the API. No origin is allowed
+// by default.
+type CORS struct {
+	// AllowedOrigins holds origins, "*", wildcard patterns such as "https://*.example.com"
+	// or regular expressions prefixed with "regex:".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Redis is the address of a Redis compatible server.
+type Redis struct {
+	Addr     string
+	Password string
+}
+
+// CacheControl holds the Cache-Control header sent on the successful reads of each route group.
+type CacheControl struct {
+	Users    string
+	Posts    string
+	Tags     string
+	Comments string
+}
+
+// Load reads the configuration from the environment, falling back to defaults.
+func Load() (*Config, error) {
+	cfg := &Config{
+		CascadeMode:    dto.CascadeMode(getEnv("DELETE_CASCADE_MODE", string(dto.CascadeDelete))),
+		TrashRetention: 30 * 24 * time.Hour,
+		PurgeInterval:  time.Hour,
+		// no-cache lets clients keep responses but revalidate them with their ETag
+		CacheControl: CacheControl{
+			Users:    getEnv("CACHE_CONTROL_USERS", "no-cache"),
+			Posts:    getEnv("CACHE_CONTROL_POSTS", "no-cache"),
+			Tags:     getEnv("CACHE_CONTROL_TAGS", "no-cache"),
+			Comments: getEnv("CACHE_CONTROL_COMMENTS", "no-cache"),
+		},
+		Cache: Cache{
+			Backend: getEnv("CACHE_BACKEND", "memory"),
+			Size:    10000,
+			TTL:     5 * time.Minute,
+		},
+		RateLimit: RateLimit{
+			Backend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		},
+		TrustedProxies: getList("TRUSTED_PROXIES", ""),
+		Redis: Redis{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+		},
+		GRPC: GRPC{
+			Addr: getEnv("GRPC_ADDR", ":9090"),
+		},
+		Site: Site{
+			URL:   strings.TrimSuffix(getEnv("SITE_URL", "http://localhost:8080"), "/"),
+			Title: getEnv("SITE_TITLE", "Blog"),
+			// relative to the working directory of the server
+			Themes:        getEnv("SITE_THEMES_DIR", "themes"),
+			Theme:         getEnv("SITE_THEME", "default"),
+			ThemeSettings: os.Getenv("SITE_THEME_SETTINGS"),
+		},
+		Media: Media{
+			Storage: getEnv("MEDIA_STORAGE", "local"),
+			Dir:     getEnv("MEDIA_DIR", "media"),
+			S3: S3{
+				Endpoint:  os.Getenv("S3_ENDPOINT"),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    os.Getenv("S3_BUCKET"),
+				AccessKey: os.Getenv("S3_ACCESS_KEY"),
+				SecretKey: os.Getenv("S3_SECRET_KEY"),
+			},
+			AllowedTypes: getList("MEDIA_ALLOWED_TYPES", "image/jpeg,image/png,image/gif,image/webp,application/pdf,video/mp4"),
+			Formats:      getList("MEDIA_IMAGE_FORMATS", "webp,jpeg"),
+		},
+		Mail: Mail{
+			Driver: getEnv("MAIL_DRIVER", "log"),
+			From:   getEnv("MAIL_FROM", "Blog <blog@localhost>"),
+			Dir:    getEnv("MAIL_DIR", "mail"),
+			SMTP: SMTP{
+				Host:     os.Getenv("SMTP_HOST"),
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+			},
+		},
+		Accounts: Accounts{
+			VerifyTTL: 24 * time.Hour,
+			ResetTTL:  time.Hour,
+		},
+		CORS: CORS{
+			AllowedOrigins: getList("CORS_ALLOWED_ORIGINS", ""),
+			AllowedMethods: getList("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE"),
+			AllowedHeaders: getList("CORS_ALLOWED_HEADERS", "Content-Type,If-Match,If-None-Match,If-Modified-Since,X-Request-ID,X-API-Key"),
+			ExposedHeaders: getList("CORS_EXPOSED_HEADERS", "ETag,Last-Modified,Retry-After,X-Request-ID,Deprecation,Sunset,Link"),
+			MaxAge:         10 * time.Minute,
+		},
+		Tracing: Tracing{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			File:        getEnv("TRACING_FILE", "traces.json"),
+			SampleRatio: 1,
+			ServiceName: getEnv("TRACING_SERVICE_NAME", "blog"),
+		},
+		Log: Log{
+			Level:     getEnv("LOG_LEVEL", "INFO"),
+			SQLLevel:  getEnv("LOG_SQL_LEVEL", "DEBUG"),
+			SlowQuery: 200 * time.Millisecond,
+		},
+	}
+
+	switch cfg.CascadeMode {
+	case dto.CascadeDelete, dto.CascadeReassign, dto.CascadeBlock:
+	default:
+		return nil, errors.Errorf("invalid DELETE_CASCADE_MODE: %s", cfg.CascadeMode)
+	}
+
+	switch cfg.Cache.Backend {
+	case "memory", "redis", "none":
+	default:
+		return nil, errors.Errorf("invalid CACHE_BACKEND: %s", cfg.Cache.Backend)
+	}
+
+	switch cfg.RateLimit.Backend {
+	case "memory", "redis":
+	default:
+		return nil, errors.Errorf("invalid RATE_LIMIT_BACKEND: %s", cfg.RateLimit.Backend)
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, errors.Errorf("invalid TRUSTED_PROXIES: %q, want an IP address or a CIDR range", proxy)
+		}
+	}
+
+	switch cfg.Media.Storage {
+	case "local":
+	case "s3":
+		if cfg.Media.S3.Endpoint == "" || cfg.Media.S3.Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required by MEDIA_STORAGE=s3")
+		}
+	default:
+		return nil, errors.Errorf("invalid MEDIA_STORAGE: %s", cfg.Media.Storage)
+	}
+
+	switch cfg.Mail.Driver {
+	case "log", "file":
+	case "smtp":
+		if cfg.Mail.SMTP.Host == "" {
+			return nil, errors.New("SMTP_HOST is required by MAIL_DRIVER=smtp")
+		}
+	default:
+		return nil, errors.Errorf("invalid MAIL_DRIVER: %s", cfg.Mail.Driver)
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "none", "stdout", "file", "otlp":
+	default:
+		return nil, errors.Errorf("invalid TRACING_EXPORTER: %s", cfg.Tracing.Exporter)
+	}
+
+	var err error
+	if cfg.Cache.TTL, err = getDuration("CACHE_TTL", cfg.Cache.TTL); err != nil {
+		return nil, err
+	}
+	if cfg.Cache.Size, err = getInt("CACHE_SIZE", cfg.Cache.Size); err != nil {
+		return nil, err
+	}
+	if cfg.Cache.Size < 1 {
+		return nil, errors.Errorf("invalid CACHE_SIZE: %d, want a positive value", cfg.Cache.Size)
+	}
+	if cfg.TrashRetention, err = getDuration("TRASH_RETENTION", cfg.TrashRetention); err != nil {
+		return nil, err
+	}
+	if cfg.TrashRetention <= 0 {
+		return nil, errors.Errorf("invalid TRASH_RETENTION: %s, want a positive duration", cfg.TrashRetention)
+	}
+	if cfg.PurgeInterval, err = getDuration("TRASH_PURGE_INTERVAL", cfg.PurgeInterval); err != nil {
+		return nil, err
+	}
+	if cfg.PurgeInterval <= 0 {
+		return nil, errors.Errorf("invalid TRASH_PURGE_INTERVAL: %s, want a positive duration", cfg.PurgeInterval)
+	}
+	if cfg.RateLimit.Users, err = getPolicy("RATE_LIMIT_USERS", "60/1m,burst=10"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Posts, err = getPolicy("RATE_LIMIT_POSTS", "120/1m,burst=30"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Tags, err = getPolicy("RATE_LIMIT_TAGS", "120/1m,burst=30"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Comments, err = getPolicy("RATE_LIMIT_COMMENTS", "30/1m,burst=5"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.GraphQL, err = getPolicy("RATE_LIMIT_GRAPHQL", "60/1m,burst=10"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Media, err = getPolicy("RATE_LIMIT_MEDIA", "60/1m,burst=10"); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Accounts, err = getPolicy("RATE_LIMIT_ACCOUNTS", "10/1m,burst=5"); err != nil {
+		return nil, err
+	}
+	if cfg.GraphQL.MaxComplexity, err = getInt("GRAPHQL_MAX_COMPLEXITY", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.GraphQL.MaxComplexity < 1 {
+		return nil, errors.Errorf("invalid GRAPHQL_MAX_COMPLEXITY: %d, want a positive value", cfg.GraphQL.MaxComplexity)
+	}
+	if cfg.RateLimit.APIKeys, err = ratelimit.ParseAPIKeys(getList("RATE_LIMIT_API_KEYS", "")); err != nil {
+		return nil, errors.Wrap(err, "invalid RATE_LIMIT_API_KEYS")
+	}
+	if u, err := url.Parse(cfg.Site.URL); err != nil || !u.IsAbs() || u.Host == "" {
+		return nil, errors.Errorf("invalid SITE_URL: %q, want an absolute URL", cfg.Site.URL)
+	}
+	if cfg.Site.ThemeReload, err = getBool("SITE_THEME_RELOAD", false); err != nil {
+		return nil, err
+	}
+	if cfg.GRPC.Addr == "none" {
+		cfg.GRPC.Addr = ""
+	}
+	if cfg.Media.S3.Secure, err = getBool("S3_SECURE", true); err != nil {
+		return nil, err
+	}
+	maxSize, err := getInt("MEDIA_MAX_SIZE", 10<<20)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize < 1 {
+		return nil, errors.Errorf("invalid MEDIA_MAX_SIZE: %d, want a positive size in bytes", maxSize)
+	}
+	cfg.Media.MaxSize = int64(maxSize)
+	maxPixels, err := getInt("MEDIA_MAX_PIXELS", 50_000_000)
+	if err != nil {
+		return nil, err
+	}
+	if maxPixels < 1 {
+		return nil, errors.Errorf("invalid MEDIA_MAX_PIXELS: %d, want a positive number of pixels", maxPixels)
+	}
+	cfg.Media.MaxPixels = int64(maxPixels)
+	if cfg.Media.Workers, err = getInt("MEDIA_WORKERS", 2); err != nil {
+		return nil, err
+	}
+	if cfg.Media.Workers < 1 {
+		return nil, errors.Errorf("invalid MEDIA_WORKERS: %d, want a positive value", cfg.Media.Workers)
+	}
+	for _, w := range getList("MEDIA_IMAGE_WIDTHS", "320,640,1280,1920") {
+		width, err := strconv.Atoi(w)
+		if err != nil || width < 1 {
+			return nil, errors.Errorf("invalid MEDIA_IMAGE_WIDTHS: %q, want positive widths", w)
+		}
+		cfg.Media.Widths = append(cfg.Media.Widths, width)
+	}
+	if cfg.Media.ThumbnailSize, err = getInt("MEDIA_THUMBNAIL_SIZE", 256); err != nil {
+		return nil, err
+	}
+	if cfg.Media.ThumbnailSize < 1 {
+		return nil, errors.Errorf("invalid MEDIA_THUMBNAIL_SIZE: %d, want a positive size", cfg.Media.ThumbnailSize)
+	}
+	if len(cfg.Media.Formats) == 0 {
+		return nil, errors.New("invalid MEDIA_IMAGE_FORMATS: want at least one format")
+	}
+	for _, f := range cfg.Media.Formats {
+		if f != "webp" && f != "jpeg" {
+			return nil, errors.Errorf("invalid MEDIA_IMAGE_FORMATS: %s, want webp or jpeg", f)
+		}
+	}
+	if cfg.Media.Quality, err = getInt("MEDIA_IMAGE_QUALITY", 80); err != nil {
+		return nil, err
+	}
+	if cfg.Media.Quality < 1 || cfg.Media.Quality > 100 {
+		return nil, errors.Errorf("invalid MEDIA_IMAGE_QUALITY: %d, want a value between 1 and 100", cfg.Media.Quality)
+	}
+	if _, err := mail.ParseAddress(cfg.Mail.From); err != nil {
+		return nil, errors.Errorf("invalid MAIL_FROM: %q, want an email address", cfg.Mail.From)
+	}
+	if cfg.Mail.SMTP.Port, err = getInt("SMTP_PORT", 587); err != nil {
+		return nil, err
+	}
+	if cfg.CORS.AllowCredentials, err = getBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return nil, err
+	}
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		// any site could then make requests carrying the cookies of the users
+		if cfg.CORS.AllowCredentials && strings.TrimSpace(origin) == "*" {
+			return nil, errors.New("CORS_ALLOW_CREDENTIALS cannot be combined with the * origin of CORS_ALLOWED_ORIGINS")
+		}
+	}
+	if cfg.CORS.MaxAge, err = getDuration("CORS_MAX_AGE", cfg.CORS.MaxAge); err != nil {
+		return nil, err
+	}
+	if cfg.Site.SPA, err = getBool("SITE_SPA", false); err != nil {
+		return nil, err
+	}
+	// the site renders the pages the links open by default, the single-page app has its own
+	if cfg.Site.SPA && (os.Getenv("ACCOUNT_VERIFY_URL") == "" || os.Getenv("ACCOUNT_RESET_URL") == "") {
+		return nil, errors.New("ACCOUNT_VERIFY_URL and ACCOUNT_RESET_URL are required by SITE_SPA=true")
+	}
+	cfg.Accounts.VerifyURL = getEnv("ACCOUNT_VERIFY_URL", cfg.Site.URL+"/verify-email")
+	cfg.Accounts.ResetURL = getEnv("ACCOUNT_RESET_URL", cfg.Site.URL+"/reset-password")
+	for key, link := range map[string]string{"ACCOUNT_VERIFY_URL": cfg.Accounts.VerifyURL, "ACCOUNT_RESET_URL": cfg.Accounts.ResetURL} {
+		if u, err := url.Parse(link); err != nil || !u.IsAbs() || u.Host == "" {
+			return nil, errors.Errorf("invalid %s: %q, want an absolute URL", key, link)
+		}
+	}
+	if cfg.Accounts.VerifyTTL, err = getDuration("ACCOUNT_VERIFY_TTL", cfg.Accounts.VerifyTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Accounts.VerifyTTL <= 0 {
+		return nil, errors.Errorf("invalid ACCOUNT_VERIFY_TTL: %s, want a positive duration", cfg.Accounts.VerifyTTL)
+	}
+	if cfg.Accounts.ResetTTL, err = getDuration("ACCOUNT_RESET_TTL", cfg.Accounts.ResetTTL); err != nil {
+		return nil, err
+	}
+	if cfg.Accounts.ResetTTL <= 0 {
+		return nil, errors.Errorf("invalid ACCOUNT_RESET_TTL: %s, want a positive duration", cfg.Accounts.ResetTTL)
+	}
+	if cfg.API.LegacyRoutes, err = getBool("API_LEGACY_ROUTES", true); err != nil {
+		return nil, err
+	}
+	if cfg.API.Deprecation, err = getDate("API_LEGACY_DEPRECATION", "2026-10-19"); err != nil {
+		return nil, err
+	}
+	if cfg.API.Sunset, err = getDate("API_LEGACY_SUNSET", ""); err != nil {
+		return nil, err
+	}
+	if cfg.Log.SlowQuery, err = getDuration("LOG_SLOW_QUERY", cfg.Log.SlowQuery); err != nil {
+		return nil, err
+	}
+	if cfg.Tracing.SampleRatio, err = getFloat("TRACING_SAMPLE_RATIO", cfg.Tracing.SampleRatio); err != nil {
+		return nil, err
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return nil, errors.Errorf("invalid TRACING_SAMPLE_RATIO: %v, want a value between 0 and 1", cfg.Tracing.SampleRatio)
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getList splits a comma separated variable, dropping the empty items.
+func getList(key, fallback string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getBool(key string, fallback bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid %s", key)
+	}
+	return b, nil
+}
+
+func getInt(key string, fallback int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", key)
+	}
+	return i, nil
+}
+
+func getFloat(key string, fallback float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", key)
+	}
+	return f, nil
+}
+
+func getPolicy(key, fallback string) (ratelimit.Policy, error) {
+	p, err := ratelimit.ParsePolicy(getEnv(key, fallback))
+	if err != nil {
+		return ratelimit.Policy{}, errors.Wrapf(err, "invalid %s", key)
+	}
+	return p, nil
+}
+
+// getDate reads a date (2006-01-02) or an RFC 3339 timestamp. An empty value is the zero time.
+func getDate(key, fallback string) (time.Time, error) {
+	v := getEnv(key, fallback)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, v); err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid %s", key)
+		}
+	}
+	return t, nil
+}
+
+func getDuration(key string, fallback time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", key)
+	}
+	return d, nil
+}
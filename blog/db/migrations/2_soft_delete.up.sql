@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX idx_posts_deleted_at ON posts (deleted_at);
+CREATE INDEX idx_comments_deleted_at ON comments (deleted_at);
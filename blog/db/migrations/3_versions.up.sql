@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
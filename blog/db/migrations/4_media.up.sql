@@ -0,0 +1,15 @@
+CREATE TABLE media (
+  id SERIAL PRIMARY KEY,
+  key VARCHAR(255) NOT NULL,
+  filename VARCHAR(255) NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+  size BIGINT NOT NULL,
+  checksum VARCHAR(64) NOT NULL,
+  author_id INTEGER NOT NULL REFERENCES users(id),
+  post_id INTEGER NULL REFERENCES posts(id),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX uix_media_key ON media (key);
+CREATE INDEX idx_media_author_id ON media (author_id);
+CREATE INDEX idx_media_post_id ON media (post_id);
@@ -0,0 +1,28 @@
+ALTER TABLE media ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'skipped';
+ALTER TABLE media ADD COLUMN processing_error VARCHAR(1024) NULL;
+ALTER TABLE media ADD COLUMN width INTEGER NULL;
+ALTER TABLE media ADD COLUMN height INTEGER NULL;
+ALTER TABLE media ADD COLUMN blurhash VARCHAR(64) NULL;
+ALTER TABLE media ADD COLUMN dominant_color VARCHAR(7) NULL;
+ALTER TABLE media ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- the images uploaded so far get their variants from the workers
+UPDATE media SET status = 'pending' WHERE content_type IN ('image/jpeg', 'image/png', 'image/gif', 'image/webp');
+
+CREATE INDEX idx_media_status ON media (status);
+
+CREATE TABLE media_variants (
+  id SERIAL PRIMARY KEY,
+  media_id INTEGER NOT NULL REFERENCES media(id),
+  key VARCHAR(255) NOT NULL,
+  name VARCHAR(32) NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  size BIGINT NOT NULL,
+  checksum VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX uix_media_variants_key ON media_variants (key);
+CREATE INDEX idx_media_variants_media_id ON media_variants (media_id);
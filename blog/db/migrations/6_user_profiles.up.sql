@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN display_name VARCHAR(255) NULL;
+ALTER TABLE users ADD COLUMN bio VARCHAR(2000) NULL;
+ALTER TABLE users ADD COLUMN avatar_id INTEGER NULL REFERENCES media(id);
+ALTER TABLE users ADD COLUMN website VARCHAR(2048) NULL;
+ALTER TABLE users ADD COLUMN social_links TEXT NULL;
+ALTER TABLE users ADD COLUMN email VARCHAR(254) NULL;
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL;
+
+CREATE UNIQUE INDEX uix_users_email ON users (email);
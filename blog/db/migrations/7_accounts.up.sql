@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(60) NULL;
+
+CREATE TABLE account_tokens (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id),
+  purpose VARCHAR(16) NOT NULL,
+  hash VARCHAR(64) NOT NULL,
+  email VARCHAR(254) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX uix_account_tokens_hash ON account_tokens (hash);
+CREATE INDEX idx_account_tokens_user_id ON account_tokens (user_id);
@@ -1,28 +1,27 @@
 package db
 
 import (
-	"fmt"
-	"log"
 	"os"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"go.uber.org/zap"
 )
 
-func Connect() (*gorm.DB, error) {
+func Connect(logger *zap.Logger) (*gorm.DB, error) {
 	var err error
 
 	path := os.Getenv("DB_PATH")
 	if path == "" {
-		log.Fatal("Db path is missing, please export it")
+		logger.Fatal("Db path is missing, please export it")
 	}
 
 	db, err := gorm.Open("sqlite3", os.Getenv("DB_PATH"))
 	if err != nil {
-		log.Fatal("Error connecting to database:", err)
+		logger.Fatal("Error connecting to database", zap.Error(err))
 	}
 
-	fmt.Println("Successfully connected to sqlLite DB")
+	logger.Info("Successfully connected to sqlLite DB", zap.String("path", path))
 
 	return db, nil
 }
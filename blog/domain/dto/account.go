@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+// Mail is an email to a user, in plain text.
+type Mail struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// AccountSettings configure the emails sent to verify the email of the users and reset their
+// password.
+type AccountSettings struct {
+	// SiteTitle names the blog in the emails.
+	SiteTitle string
+	// VerifyURL and ResetURL are the pages the links of the emails open, with the token in
+	// their "token" query parameter.
+	VerifyURL string
+	ResetURL  string
+	// VerifyTTL and ResetTTL are how long the tokens can be used.
+	VerifyTTL time.Duration
+	ResetTTL  time.Duration
+}
+
+// TokenPurpose tells what an account token proves.
+type TokenPurpose string
+
+const (
+	// TokenVerifyEmail tokens prove the user received an email at their address.
+	TokenVerifyEmail TokenPurpose = "verify_email"
+	// TokenResetPassword tokens let the user choose a new password.
+	TokenResetPassword TokenPurpose = "reset_password"
+)
+
+// AccountToken is a token sent to a user by email. It can be used once, before it expires,
+// and only while the user keeps the email it was sent to.
+type AccountToken struct {
+	ID      int64        `gorm:"primary_key;auto_increment" json:"id"`
+	UserID  int64        `sql:"type:int REFERENCES users(id)" gorm:"not null;index" json:"user_id"`
+	Purpose TokenPurpose `gorm:"size:16;not null" json:"purpose"`
+	// Hash is the hex encoded SHA-256 of the token, which only the email holds.
+	Hash      string     `gorm:"size:64;not null;unique_index" json:"-"`
+	Email     string     `gorm:"size:254;not null" json:"email"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type SendVerificationRequest struct {
+	UserID int64 `json:"user_id" uri:"user_id" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required,max=64"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token" form:"token" binding:"required,max=64"`
+	Password string `json:"password" form:"password" binding:"required,min=8,max=72"`
+}
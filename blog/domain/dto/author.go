@@ -1,6 +1,12 @@
 package dto
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 type CreateUserResponse struct {
 	ID        int64     `json:"createdId"`
@@ -14,8 +20,8 @@ type DeleteUserRequest struct {
 }
 
 type GetUsers struct {
-	Offset  int `json:"offset" form:"from"`
-	LastIdx int `json:"last_idx" form:"to"`
+	Offset  int `json:"offset" form:"from" binding:"min=0"`
+	LastIdx int `json:"last_idx" form:"to" binding:"min=0"`
 }
 
 type GetUserByIDRequest struct {
@@ -24,16 +30,134 @@ type GetUserByIDRequest struct {
 
 //User Represents the fields from the User Database
 type User struct {
-	ID        int64     `gorm:"primary_key;auto_increment" json:"id"`
-	Name      string    `gorm:"size:255;not null;unique" json:"name"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID          int64  `gorm:"primary_key;auto_increment" json:"id"`
+	Name        string `gorm:"size:255;not null;unique" json:"name" binding:"required,notblank,max=255"`
+	DisplayName string `gorm:"size:255" json:"display_name,omitempty" binding:"omitempty,notblank,max=255"`
+	Bio         string `gorm:"size:2000" json:"bio,omitempty" binding:"omitempty,max=2000"`
+	// AvatarID is an image uploaded by the user, shown with their profile.
+	AvatarID    *int64      `sql:"type:int REFERENCES media(id)" json:"avatar_id,omitempty"`
+	Website     string      `gorm:"size:2048" json:"website,omitempty" binding:"omitempty,weburl,max=2048"`
+	SocialLinks SocialLinks `sql:"type:text" json:"social_links,omitempty" binding:"omitempty,max=10,dive"`
+	// Email is stored lower case, at most one user has it. Any client can read the users,
+	// so it is only ever received.
+	Email *string `gorm:"size:254;unique_index" json:"-"`
+	// EmailVerifiedAt is when the user proved they own their email, nil until they do and
+	// again whenever it changes. The profile only tells whether it is set.
+	EmailVerifiedAt *time.Time `json:"-"`
+	// Password is only ever received, when the user registers: it is stored as a bcrypt
+	// hash and changed with a password reset.
+	Password     string     `gorm:"-" json:"-"`
+	PasswordHash string     `gorm:"size:60" json:"-"`
+	CreatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt    *time.Time `sql:"index" json:"deleted_at,omitempty"`
+	Version      int64      `gorm:"not null;default:1" json:"version"`
+}
+
+// CreateUserBodyRequest registers a user, with the fields of User that are only received.
+type CreateUserBodyRequest struct {
+	Name        string      `json:"name" binding:"required,notblank,max=255"`
+	DisplayName string      `json:"display_name" binding:"omitempty,notblank,max=255"`
+	Bio         string      `json:"bio" binding:"omitempty,max=2000"`
+	Website     string      `json:"website" binding:"omitempty,weburl,max=2048"`
+	SocialLinks SocialLinks `json:"social_links" binding:"omitempty,max=10,dive"`
+	// Email is sent a link to verify it.
+	Email    string `json:"email" binding:"omitempty,email,max=254"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
 }
 
+// UpdateUserBodyRequest changes the fields it holds, the empty ones are left as they are.
+// The fields are cleared with a merge patch.
 type UpdateUserBodyRequest struct {
-	Name string `json:"name"`
+	Name        string `json:"name" binding:"omitempty,notblank,max=255"`
+	DisplayName string `json:"display_name" binding:"omitempty,notblank,max=255"`
+	Bio         string `json:"bio" binding:"omitempty,max=2000"`
+	AvatarID    int64  `json:"avatar_id" binding:"omitempty,min=1"`
+	Website     string `json:"website" binding:"omitempty,weburl,max=2048"`
+	// SocialLinks replace the links of the user when present, an empty list removes them.
+	SocialLinks SocialLinks `json:"social_links" binding:"omitempty,max=10,dive"`
+	Email       string      `json:"email" binding:"omitempty,email,max=254"`
+	// CurrentPassword is required to change the email of a user with a password.
+	CurrentPassword string `json:"current_password" binding:"omitempty,max=72"`
 }
 
 type UpdateUserRequest struct {
 	UserID int64 `json:"user_id" uri:"user_id" binding:"required"`
 }
+
+// SocialLink is a link to an account of the user elsewhere.
+type SocialLink struct {
+	// Network names the site, such as "github" or "mastodon".
+	Network string `json:"network" binding:"required,notblank,max=64"`
+	URL     string `json:"url" binding:"required,weburl,max=2048"`
+}
+
+// SocialLinks are stored as a JSON array.
+type SocialLinks []SocialLink
+
+func (l SocialLinks) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *SocialLinks) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("cannot scan %T into social links", src)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return errors.Wrap(err, "social links")
+	}
+	if len(*l) == 0 {
+		*l = nil
+	}
+	return nil
+}
+
+type GetProfileRequest struct {
+	UserID int64 `json:"user_id" uri:"user_id" binding:"required"`
+	Page   int   `json:"page" form:"page" binding:"omitempty,min=1"`
+}
+
+// Profile is the public page of a user, with a page of their posts, newest first. It leaves
+// their email out, telling only whether they verified it.
+type Profile struct {
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	DisplayName string      `json:"display_name,omitempty"`
+	Bio         string      `json:"bio,omitempty"`
+	Avatar      *Media      `json:"avatar,omitempty"`
+	Website     string      `json:"website,omitempty"`
+	SocialLinks SocialLinks `json:"social_links,omitempty"`
+	// EmailVerified is true once the user proved they own their email.
+	EmailVerified bool         `json:"email_verified"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Stats         ProfileStats `json:"stats"`
+	Posts         []Post       `json:"posts"`
+	// Page is the number of the page of posts, counting from 1, out of Pages.
+	Page  int `json:"page"`
+	Pages int `json:"pages"`
+}
+
+// ProfileStats sum up the activity of a user.
+type ProfileStats struct {
+	Posts int64 `json:"posts"`
+	// Comments is the number of comments on the posts of the user.
+	Comments int64 `json:"comments"`
+	// LastPostAt is when the user last posted, nil if they never did.
+	LastPostAt *time.Time `json:"last_post_at,omitempty"`
+}
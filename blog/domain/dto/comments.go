@@ -4,13 +4,15 @@ import "time"
 
 //Comment Represents the fields from the comments Database
 type Comment struct {
-	ID        int64     `gorm:"primary_key;auto_increment" json:"id"`
-	PostID    int64     `sql:"type:int REFERENCES posts(id)" json:"post_id"`
-	Name      string    `gorm:"size:255;not null" json:"name"`
-	Body      string    `gorm:"size:255;not null" json:"body"`
-	Post      Post      `json:"post"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID        int64      `gorm:"primary_key;auto_increment" json:"id"`
+	PostID    int64      `sql:"type:int REFERENCES posts(id)" json:"post_id"`
+	Name      string     `gorm:"size:255;not null" json:"name" binding:"required,notblank,max=255"`
+	Body      string     `gorm:"size:255;not null" json:"body" binding:"required,notblank,max=255"`
+	Post      Post       `json:"post" binding:"-"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt *time.Time `sql:"index" json:"deleted_at,omitempty"`
+	Version   int64      `gorm:"not null;default:1" json:"version"`
 }
 
 type CreateCommentsRequest struct {
@@ -35,8 +37,8 @@ type GetCommentByIDRequest struct {
 }
 
 type UpdateCommentsBodyRequest struct {
-	Name string `json:"name"`
-	Body string `json:"body"`
+	Name string `json:"name" binding:"omitempty,notblank,max=255"`
+	Body string `json:"body" binding:"omitempty,notblank,max=255"`
 }
 
 type UpdateCommentsRequest struct {
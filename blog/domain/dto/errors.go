@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// ErrDeleteBlocked is returned when a delete is refused because of the cascade mode.
+var ErrDeleteBlocked = errors.New("delete blocked by existing dependents")
+
+// ErrPreconditionFailed is returned when a write is based on a stale version of the resource.
+var ErrPreconditionFailed = errors.New("the resource was modified since it was read")
+
+// ErrInvalidPatch is returned when a merge patch cannot be applied to the resource.
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// ErrNameReserved is returned when a user would take the name of the ghost user.
+var ErrNameReserved = errors.New("the name is reserved")
+
+// ErrMediaTooLarge is returned when an upload exceeds the size limit.
+var ErrMediaTooLarge = errors.New("the file is too large")
+
+// ErrUnsupportedMediaType is returned when the content of an upload is not of an allowed type.
+var ErrUnsupportedMediaType = errors.New("unsupported file type")
+
+// ErrEmailTaken is returned when an email is already the one of another user.
+var ErrEmailTaken = errors.New("the email is taken by another user")
+
+// ErrInvalidAvatar is returned when the avatar of a user is not one of their images.
+var ErrInvalidAvatar = errors.New("the avatar must be an image uploaded by the user")
+
+// ErrInvalidToken is returned when an account token is unknown, expired, used or sent to an
+// email the user no longer has.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrWrongPassword is returned when a change needs the current password of the user and it
+// is missing or wrong.
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrNoEmail is returned when a user has no email to send a verification to.
+var ErrNoEmail = errors.New("the user has no email")
+
+// ErrEmailVerified is returned when the email of a user is verified already.
+var ErrEmailVerified = errors.New("the email is verified already")
+
+// ErrorKind classifies the usecase errors. The HTTP and gRPC APIs each report a kind with
+// their own status, so that both answer the same error the same way.
+type ErrorKind int
+
+const (
+	// KindInternal is any error not classified below.
+	KindInternal ErrorKind = iota
+	// KindNotFound is a missing row.
+	KindNotFound
+	// KindInvalid is a request that cannot be applied as sent.
+	KindInvalid
+	// KindAlreadyExists is a value taken by another row or reserved.
+	KindAlreadyExists
+	// KindFailedPrecondition is a request refused in the current state of the rows.
+	KindFailedPrecondition
+	// KindStale is a write based on a version of the row that is no longer the current one.
+	KindStale
+	// KindTooLarge is an upload past the size limit.
+	KindTooLarge
+	// KindUnsupported is an upload of a type that is not accepted.
+	KindUnsupported
+	// KindPermissionDenied is a change the caller failed to prove they may make.
+	KindPermissionDenied
+)
+
+// errorKinds classifies the sentinel errors.
+var errorKinds = []struct {
+	err  error
+	kind ErrorKind
+}{
+	{ErrDeleteBlocked, KindFailedPrecondition},
+	{ErrPreconditionFailed, KindStale},
+	{ErrInvalidPatch, KindInvalid},
+	{ErrNameReserved, KindAlreadyExists},
+	{ErrMediaTooLarge, KindTooLarge},
+	{ErrUnsupportedMediaType, KindUnsupported},
+	{ErrEmailTaken, KindAlreadyExists},
+	{ErrInvalidAvatar, KindInvalid},
+	{ErrInvalidToken, KindInvalid},
+	{ErrNoEmail, KindFailedPrecondition},
+	{ErrEmailVerified, KindFailedPrecondition},
+	{ErrWrongPassword, KindPermissionDenied},
+}
+
+// KindOf returns the kind of err, KindInternal when it wraps none of the sentinel errors.
+func KindOf(err error) ErrorKind {
+	for _, e := range errorKinds {
+		if errors.Is(err, e.err) {
+			return e.kind
+		}
+	}
+	if gorm.IsRecordNotFoundError(errors.Cause(err)) {
+		return KindNotFound
+	}
+	return KindInternal
+}
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"time"
+
+	"blog/utils/etag"
+)
+
+// ETag identifies the current version of the user.
+func (u *User) ETag() string {
+	return etag.Strong("user", u.ID, u.Version, u.UpdatedAt)
+}
+
+// ETag identifies the current version of the post.
+func (p *Post) ETag() string {
+	return etag.Strong("post", p.ID, p.Version, p.UpdatedAt)
+}
+
+// ETag identifies the current version of the tag.
+func (t *Tag) ETag() string {
+	return etag.Strong("tag", t.ID, t.Version, t.UpdatedAt)
+}
+
+// ETag identifies the current version of the comment.
+func (c *Comment) ETag() string {
+	return etag.Strong("comment", c.ID, c.Version, c.UpdatedAt)
+}
+
+// Validators return the ETag and Last-Modified of the representation of the post, which
+// embeds its author, tags and comment count: a change to any of them is a new version of
+// the post. The If-Match of a write is compared with this ETag.
+func (p *Post) Validators() (string, time.Time) {
+	parts := []interface{}{"post", p.ETag(), p.Author.ETag(), p.CommentsCount}
+	lastModified := latest(p.UpdatedAt, p.Author.UpdatedAt)
+	for i := range p.Tags {
+		parts = append(parts, p.Tags[i].ETag())
+		lastModified = latest(lastModified, p.Tags[i].UpdatedAt)
+	}
+	return etag.Strong(parts...), lastModified
+}
+
+// Validators return the ETag and Last-Modified of the representation of the tag, which
+// embeds its post.
+func (t *Tag) Validators() (string, time.Time) {
+	return etag.Strong("tag", t.ETag(), t.Post.ETag()), latest(t.UpdatedAt, t.Post.UpdatedAt)
+}
+
+// Validators return the ETag and Last-Modified of the representation of the comment, which
+// embeds its post.
+func (c *Comment) Validators() (string, time.Time) {
+	return etag.Strong("comment", c.ETag(), c.Post.ETag()), latest(c.UpdatedAt, c.Post.UpdatedAt)
+}
+
+func latest(t time.Time, others ...time.Time) time.Time {
+	for _, o := range others {
+		if o.After(t) {
+			t = o
+		}
+	}
+	return t
+}
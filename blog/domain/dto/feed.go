@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+// FeedSize is the number of posts of a feed.
+const FeedSize = 50
+
+type GetAuthorFeedRequest struct {
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+}
+
+type GetTagFeedRequest struct {
+	Name string `json:"name" uri:"name" binding:"required,notblank,max=255"`
+}
+
+// Feed holds the latest posts of the blog, of an author or of a tag, most recently updated
+// first, for the syndication feeds.
+type Feed struct {
+	// Author is the author of an author feed.
+	Author *User
+	// Tag is the tag of a tag feed.
+	Tag   *Tag
+	Posts []Post
+}
+
+// SitemapPageSize is the range of ids listed by a page of the sitemap, the most URLs a
+// sitemap may list.
+const SitemapPageSize = 50000
+
+// Kinds of resources listed in the sitemap.
+const (
+	SitemapPosts = "posts"
+	SitemapTags  = "tags"
+	SitemapUsers = "users"
+)
+
+// SitemapPage is a page of the sitemap: the resources of a kind whose ids fall in the
+// Number-th range of SitemapPageSize ids, counting from 1. Count, Versions and MaxID change
+// whenever one of them is created, updated or deleted.
+type SitemapPage struct {
+	Kind     string
+	Number   int64
+	Count    int64
+	Versions int64
+	MaxID    int64
+}
+
+// SitemapEntry is a resource listed in the sitemap.
+type SitemapEntry struct {
+	ID int64
+	// Name is the name of a tag, which its page is found by.
+	Name      string
+	UpdatedAt time.Time
+}
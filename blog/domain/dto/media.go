@@ -0,0 +1,132 @@
+package dto
+
+import (
+	"io"
+	"mime/multipart"
+	"time"
+)
+
+// MediaPath is where the files of the media are served.
+const MediaPath = "/media/"
+
+// MediaLimits bound the files uploaded.
+type MediaLimits struct {
+	// MaxSize is the largest file accepted, in bytes.
+	MaxSize int64
+	// AllowedTypes lists the media types accepted, as sniffed from the content of the files.
+	AllowedTypes []string
+	// MaxPixels is the largest image accepted, in pixels. A small file can declare a huge
+	// image, which would take the memory of the server to decode.
+	MaxPixels int64
+}
+
+// ImageVariants are the variants rendered of the uploaded images.
+type ImageVariants struct {
+	// Widths lists the widths of the responsive variants, the ones narrower than the image.
+	Widths []int
+	// ThumbnailSize is the side of the square thumbnail.
+	ThumbnailSize int
+	// Formats lists the encodings of every variant, "webp" or "jpeg".
+	Formats []string
+	// Quality is the quality of the encodings, from 1 to 100.
+	Quality int
+}
+
+// MediaStatus tells where an uploaded file is in the image processing.
+type MediaStatus string
+
+const (
+	// MediaPending images wait for a worker.
+	MediaPending MediaStatus = "pending"
+	// MediaProcessing images are being processed.
+	MediaProcessing MediaStatus = "processing"
+	// MediaReady images have their variants and placeholders.
+	MediaReady MediaStatus = "ready"
+	// MediaFailed images could not be processed, see their processing error.
+	MediaFailed MediaStatus = "failed"
+	// MediaSkipped files are not images, they have no variants.
+	MediaSkipped MediaStatus = "skipped"
+)
+
+// Media is a file uploaded by an author, attached to one of their posts or not yet.
+type Media struct {
+	ID int64 `gorm:"primary_key;auto_increment" json:"id"`
+	// Key locates the file in the media storage. It is never reused, so the file of a key
+	// never changes.
+	Key         string `gorm:"size:255;not null;unique_index" json:"key"`
+	URL         string `gorm:"-" json:"url"`
+	Filename    string `gorm:"size:255;not null" json:"filename"`
+	ContentType string `gorm:"size:255;not null" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+	// Checksum is the hex encoded SHA-256 of the file.
+	Checksum string `gorm:"size:64;not null" json:"checksum"`
+	AuthorID int64  `sql:"type:int REFERENCES users(id)" json:"author_id"`
+	PostID   *int64 `sql:"type:int REFERENCES posts(id)" json:"post_id"`
+	// Status is the state of the processing of the images, done in the background after the
+	// upload.
+	Status          MediaStatus `gorm:"size:16;not null;index" json:"status"`
+	ProcessingError string      `gorm:"size:1024" json:"processing_error,omitempty"`
+	// Width and Height are the size of the images as shown, once oriented.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Blurhash and DominantColor are placeholders to show while the image loads.
+	Blurhash      string         `gorm:"size:64" json:"blurhash,omitempty"`
+	DominantColor string         `gorm:"size:7" json:"dominant_color,omitempty"`
+	Variants      []MediaVariant `json:"variants"`
+	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// MediaVariant is a copy of an uploaded image scaled down and encoded for the web.
+type MediaVariant struct {
+	ID      int64 `gorm:"primary_key;auto_increment" json:"-"`
+	MediaID int64 `sql:"type:int REFERENCES media(id)" json:"-"`
+	// Key locates the file in the media storage, next to the one of the image.
+	Key string `gorm:"size:255;not null;unique_index" json:"key"`
+	URL string `gorm:"-" json:"url"`
+	// Name is "thumbnail" for the square thumbnail, the width followed by "w" for the others,
+	// as in a srcset.
+	Name        string `gorm:"size:32;not null" json:"name"`
+	ContentType string `gorm:"size:255;not null" json:"content_type"`
+	Width       int    `gorm:"not null" json:"width"`
+	Height      int    `gorm:"not null" json:"height"`
+	Size        int64  `gorm:"not null" json:"size"`
+	// Checksum is the hex encoded SHA-256 of the file.
+	Checksum  string    `gorm:"size:64;not null" json:"checksum"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+type UploadMediaRequest struct {
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+}
+
+// MediaUpload is the multipart form of an upload.
+type MediaUpload struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+	// PostID attaches the file to a post of the author.
+	PostID int64 `form:"post_id" binding:"omitempty,min=1"`
+}
+
+// MediaFile is the content of an upload.
+type MediaFile struct {
+	Name string
+	Size int64
+	Body io.Reader
+}
+
+type GetMediaByIDRequest struct {
+	MediaID int64 `json:"media_id" uri:"media_id" binding:"required"`
+}
+
+type GetAuthorMediaRequest struct {
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+}
+
+type GetPostMediaRequest struct {
+	PostID int64 `json:"post_id" uri:"post_id" binding:"required"`
+}
+
+type DeleteMediaRequest struct {
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+	MediaID  int64 `json:"media_id" uri:"media_id" binding:"required"`
+}
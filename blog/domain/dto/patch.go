@@ -0,0 +1,33 @@
+package dto
+
+// UserPatch is the document a JSON merge patch on a user is applied to.
+type UserPatch struct {
+	Name        string      `json:"name" binding:"required,notblank,max=255"`
+	DisplayName string      `json:"display_name" binding:"omitempty,notblank,max=255"`
+	Bio         string      `json:"bio" binding:"omitempty,max=2000"`
+	AvatarID    *int64      `json:"avatar_id" binding:"omitempty,min=1"`
+	Website     string      `json:"website" binding:"omitempty,weburl,max=2048"`
+	SocialLinks SocialLinks `json:"social_links" binding:"omitempty,max=10,dive"`
+	Email       string      `json:"email" binding:"omitempty,email,max=254"`
+	// CurrentPassword is required to change the email of a user with a password, it is not
+	// part of the user.
+	CurrentPassword string `json:"current_password" binding:"omitempty,max=72"`
+}
+
+// PostPatch is the document a JSON merge patch on a post is applied to.
+type PostPatch struct {
+	Title   string `json:"title" binding:"required,notblank,max=255"`
+	Content string `json:"content" binding:"required,notblank,max=255"`
+	TagsID  int64  `json:"tags_id" binding:"omitempty,min=1"`
+}
+
+// TagPatch is the document a JSON merge patch on a tag is applied to.
+type TagPatch struct {
+	Name string `json:"name" binding:"required,notblank,max=255"`
+}
+
+// CommentPatch is the document a JSON merge patch on a comment is applied to.
+type CommentPatch struct {
+	Name string `json:"name" binding:"required,notblank,max=255"`
+	Body string `json:"body" binding:"required,notblank,max=255"`
+}
@@ -26,8 +26,8 @@ type DeletePostRequest struct {
 }
 
 type GetPosts struct {
-	Offset  int `json:"offset" form:"from"`
-	LastIdx int `json:"last_idx" form:"to"`
+	Offset  int `json:"offset" form:"from" binding:"min=0"`
+	LastIdx int `json:"last_idx" form:"to" binding:"min=0"`
 }
 
 type GetPostByIDRequest struct {
@@ -37,27 +37,30 @@ type GetPostByIDRequest struct {
 
 //Post Represents the fields from the Post Database
 type Post struct {
-	ID        int64     `gorm:"primary_key;auto_increment" json:"id"`
-	Title     string    `gorm:"size:255;not null;unique" json:"title"`
-	Content   string    `gorm:"size:255;not null;" json:"content"`
-	Author    User      `json:"author"`
-	AuthorID  int64     `sql:"type:int REFERENCES users(id)" json:"author_id"`
-	Tags      []Tag     `gorm:"many2many:posts_tags;"`
-	TagsID    int64     `sql:"type:int REFERENCES tags(id)" json:"tags_id"`
-	Comments  []Comment `gorm:"many2many:posts_comments"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID            int64      `gorm:"primary_key;auto_increment" json:"id"`
+	Title         string     `gorm:"size:255;not null;unique" json:"title"`
+	Content       string     `gorm:"size:255;not null;" json:"content"`
+	Author        User       `json:"author" binding:"-"`
+	AuthorID      int64      `sql:"type:int REFERENCES users(id)" json:"author_id"`
+	Tags          []Tag      `gorm:"many2many:posts_tags;"`
+	TagsID        int64      `sql:"type:int REFERENCES tags(id)" json:"tags_id"`
+	Comments      []Comment  `gorm:"many2many:posts_comments"`
+	CommentsCount int64      `gorm:"-" json:"comments_count"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt     *time.Time `sql:"index" json:"deleted_at,omitempty"`
+	Version       int64      `gorm:"not null;default:1" json:"version"`
 }
 
 type PostCreate struct {
 	ID        int64          `json:"id"`
-	Title     string         `json:"title"`
-	Content   string         `json:"content"`
-	Author    User           `json:"author"`
+	Title     string         `json:"title" binding:"required,notblank,max=255"`
+	Content   string         `json:"content" binding:"required,notblank,max=255"`
+	Author    User           `json:"author" binding:"-"`
 	AuthorID  int64          `json:"author_id"`
-	TagsID    int64          `json:"tags_id" binding:"required"`
-	Tags      pq.StringArray `json:"tags"`
-	Comments  pq.StringArray `json:"comments"`
+	TagsID    int64          `json:"tags_id" binding:"omitempty,min=1"`
+	Tags      pq.StringArray `json:"tags" binding:"omitempty,max=20,dive,notblank,max=255"`
+	Comments  pq.StringArray `json:"comments" binding:"omitempty,dive,max=255"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 }
@@ -68,6 +71,6 @@ type UpdatePostRequest struct {
 }
 
 type UpdatePostBodyRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string `json:"title" binding:"omitempty,notblank,max=255"`
+	Content string `json:"content" binding:"omitempty,notblank,max=255"`
 }
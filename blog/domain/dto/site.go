@@ -0,0 +1,49 @@
+package dto
+
+// SitePageSize is the number of posts listed by a page of the site.
+const SitePageSize = 10
+
+type GetSitePageRequest struct {
+	Page int `json:"page" form:"page" binding:"omitempty,min=1"`
+}
+
+type GetPostPageRequest struct {
+	PostID int64 `json:"post_id" uri:"post_id" binding:"required"`
+}
+
+type GetAuthorPageRequest struct {
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+}
+
+type GetTagPageRequest struct {
+	Name string `json:"name" uri:"name" binding:"required,notblank,max=255"`
+}
+
+// PostList is a page of the posts of the blog, of an author or of a tag, newest first.
+type PostList struct {
+	// Author is the author of an author page.
+	Author *User
+	// Tag is the tag of a tag page.
+	Tag   *Tag
+	Posts []Post
+	// Page is the number of the page, counting from 1, and Total the number of posts of
+	// every page.
+	Page  int
+	Total int64
+}
+
+// Pages is the number of pages of the listing, at least one.
+func (l *PostList) Pages() int {
+	pages := int((l.Total + SitePageSize - 1) / SitePageSize)
+	if pages == 0 {
+		return 1
+	}
+	return pages
+}
+
+// PostView is a post with its tags and comments.
+type PostView struct {
+	Post     Post
+	Tags     []Tag
+	Comments []Comment
+}
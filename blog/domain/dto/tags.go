@@ -31,14 +31,15 @@ type PostsTags struct {
 type Tag struct {
 	ID        int64     `gorm:"primary_key;auto_increment" json:"id"`
 	PostID    int64     `sql:"type:int REFERENCES posts(id)" json:"post_id"`
-	Post      Post      `json:"post"`
-	Name      string    `gorm:"size:255;not null;unique" json:"name"`
+	Post      Post      `json:"post" binding:"-"`
+	Name      string    `gorm:"size:255;not null;unique" json:"name" binding:"required,notblank,max=255"`
 	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	Version   int64     `gorm:"not null;default:1" json:"version"`
 }
 
 type TagCreate struct {
-	Name string `json:"name"`
+	Name string `json:"name" binding:"required,notblank,max=255"`
 }
 
 type UpdateTagsRequest struct {
@@ -47,5 +48,5 @@ type UpdateTagsRequest struct {
 }
 
 type UpdateTagsBodyRequest struct {
-	Name string `json:"name"`
+	Name string `json:"name" binding:"omitempty,notblank,max=255"`
 }
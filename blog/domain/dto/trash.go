@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+// CascadeMode controls what happens to the rows referencing a deleted user or post.
+type CascadeMode string
+
+const (
+	// CascadeDelete soft deletes the dependent posts and comments together with their parent.
+	CascadeDelete CascadeMode = "delete"
+	// CascadeReassign moves the posts of a deleted user to the ghost user.
+	CascadeReassign CascadeMode = "reassign"
+	// CascadeBlock refuses to delete a row that still has dependents.
+	CascadeBlock CascadeMode = "block"
+)
+
+// GhostUserName is the name of the user that inherits the posts of deleted users. It is
+// reserved, in any case, for no other user to be mistaken for the ghost.
+const GhostUserName = "ghost"
+
+type GetTrash struct {
+	Offset  int `json:"offset" form:"from" binding:"min=0"`
+	LastIdx int `json:"last_idx" form:"to" binding:"min=0"`
+}
+
+// Trash lists the soft-deleted rows that can still be restored. A page is taken from all of
+// them, most recently deleted first, and split by kind.
+type Trash struct {
+	Users    []User    `json:"users"`
+	Posts    []Post    `json:"posts"`
+	Comments []Comment `json:"comments"`
+}
+
+// PurgeResult counts the rows hard-deleted by a purge run.
+type PurgeResult struct {
+	Users    int64     `json:"users"`
+	Posts    int64     `json:"posts"`
+	Comments int64     `json:"comments"`
+	Before   time.Time `json:"before"`
+}
+
+type RestoreUserRequest struct {
+	UserID int64 `json:"user_id" uri:"user_id" binding:"required"`
+}
+
+type RestorePostRequest struct {
+	PostID   int64 `json:"post_id" uri:"post_id" binding:"required"`
+	AuthorID int64 `json:"author_id" uri:"user_id" binding:"required"`
+}
+
+type RestoreCommentRequest struct {
+	PostID    int64 `json:"post_id" uri:"post_id" binding:"required"`
+	CommentID int64 `json:"comment_id" uri:"comment_id" binding:"required"`
+}
@@ -9,7 +9,24 @@ import (
 type UserUsecase interface {
 	GetUserById(ctx *gin.Context, userID int64) (*dto.User, error)
 	GetAllUsers(ctx *gin.Context, limit int, offset int) (*[]dto.User, error)
+	// GetUsersByIds returns the users found among userIDs, in no particular order.
+	GetUsersByIds(ctx *gin.Context, userIDs []int64) ([]dto.User, error)
 	CreateUser(ctx *gin.Context, request *dto.User) (dto.CreateUserResponse, error)
 	UpdateUser(ctx *gin.Context, userID int64, requestBody *dto.UpdateUserBodyRequest) (*dto.User, error)
+	PatchUser(ctx *gin.Context, userID int64, patch []byte, ifMatch string) (*dto.User, error)
 	DeleteUser(ctx *gin.Context, userID int64) error
+	// GetProfile returns the public profile of the user with the page of their posts.
+	GetProfile(ctx *gin.Context, userID int64, page int) (*dto.Profile, error)
+}
+
+// AccountUsecase verifies the emails of the users and resets their passwords, with tokens
+// sent by email.
+type AccountUsecase interface {
+	// SendVerification emails a link verifying the email of the user.
+	SendVerification(ctx *gin.Context, userID int64) error
+	VerifyEmail(ctx *gin.Context, token string) (*dto.User, error)
+	// RequestPasswordReset emails a reset link to the user with the email, if any. Whether
+	// there is one is not told.
+	RequestPasswordReset(ctx *gin.Context, email string) error
+	ResetPassword(ctx *gin.Context, token, password string) error
 }
@@ -8,7 +8,10 @@ import (
 
 type CommentsUsecase interface {
 	GetCommentById(ctx *gin.Context, CommentID, postID int64) (*dto.Comment, error)
+	// GetCommentsByPostIds returns the comments of the given posts ordered by id.
+	GetCommentsByPostIds(ctx *gin.Context, postIDs []int64) ([]dto.Comment, error)
 	CreateComment(ctx *gin.Context, CommentID int64, request *dto.Comment) (dto.CreateCommentsResponse, error)
 	UpdateComments(ctx *gin.Context, CommentID, postID int64, requestBody *dto.UpdateCommentsBodyRequest) (*dto.Comment, error)
+	PatchComment(ctx *gin.Context, CommentID, postID int64, patch []byte, ifMatch string) (*dto.Comment, error)
 	DeleteComments(ctx *gin.Context, CommentID, postID int64) error
 }
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+type FeedUsecase interface {
+	// GetFeed returns the dto.FeedSize most recently updated posts.
+	GetFeed(ctx *gin.Context) (*dto.Feed, error)
+	// GetAuthorFeed returns the most recently updated posts of an author.
+	GetAuthorFeed(ctx *gin.Context, authorID int64) (*dto.Feed, error)
+	// GetTagFeed returns the most recently updated posts carrying the tag of the given name.
+	GetTagFeed(ctx *gin.Context, name string) (*dto.Feed, error)
+	// GetSitemapPages returns the non-empty pages of the sitemap, by kind and number.
+	GetSitemapPages(ctx *gin.Context) ([]dto.SitemapPage, error)
+	// GetSitemapEntries returns the resources of a page of the sitemap ordered by id.
+	GetSitemapEntries(ctx *gin.Context, page dto.SitemapPage) ([]dto.SitemapEntry, error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"blog/domain/dto"
+)
+
+// Mailer sends emails to the users.
+type Mailer interface {
+	// Send delivers mail, or hands it to a server that will.
+	Send(ctx context.Context, mail *dto.Mail) error
+}
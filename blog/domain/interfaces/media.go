@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+type MediaUsecase interface {
+	// UploadMedia stores a file of the author, attached to their post postID unless it is 0.
+	UploadMedia(ctx *gin.Context, authorID, postID int64, file *dto.MediaFile) (*dto.Media, error)
+	GetMediaById(ctx *gin.Context, mediaID int64) (*dto.Media, error)
+	// GetAuthorMedia returns the files of an author, the latest first.
+	GetAuthorMedia(ctx *gin.Context, authorID int64) ([]dto.Media, error)
+	// GetPostMedia returns the files attached to a post, in upload order.
+	GetPostMedia(ctx *gin.Context, postID int64) ([]dto.Media, error)
+	DeleteMedia(ctx *gin.Context, mediaID, authorID int64) error
+	// OpenMedia opens the file stored under key, an upload or one of its variants, with the
+	// record describing it.
+	OpenMedia(ctx *gin.Context, key string) (*dto.Media, io.ReadSeekCloser, error)
+}
+
+// MediaProcessor renders the variants and placeholders of the uploaded images in the
+// background.
+type MediaProcessor interface {
+	// Enqueue schedules the processing of a pending image. It never blocks: when the queue
+	// is full, the image waits for the next sweep of the pending ones.
+	Enqueue(mediaID int64)
+	// Process processes a pending image, recording the failures on its status. It does
+	// nothing when the image is not pending.
+	Process(ctx context.Context, mediaID int64) error
+	// Run processes the queue with the workers and sweeps the pending images regularly,
+	// until ctx is cancelled.
+	Run(ctx context.Context)
+}
@@ -9,7 +9,12 @@ import (
 type PostUsecase interface {
 	GetPostById(ctx *gin.Context, postID, authorID int64) (*dto.Post, error)
 	GetAllPosts(ctx *gin.Context, limit int, offset int) (*[]dto.Post, error)
+	// GetPostsByIds returns the posts found among postIDs, in no particular order.
+	GetPostsByIds(ctx *gin.Context, postIDs []int64) ([]dto.Post, error)
+	// GetPostsByAuthorIds returns the posts of the given authors ordered by id.
+	GetPostsByAuthorIds(ctx *gin.Context, authorIDs []int64) ([]dto.Post, error)
 	CreatePost(ctx *gin.Context, authorID int64, request *dto.PostCreate) (dto.CreatePostResponse, error)
 	UpdatePost(ctx *gin.Context, postID, authorID int64, requestBody *dto.UpdatePostBodyRequest) (*dto.Post, error)
+	PatchPost(ctx *gin.Context, postID, authorID int64, patch []byte, ifMatch string) (*dto.Post, error)
 	DeletePost(ctx *gin.Context, postID, authorID int64) error
 }
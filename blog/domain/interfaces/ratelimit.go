@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitStore holds the token buckets of the rate limiter. A shared implementation lets
+// several instances of the service enforce the same limits.
+type RateLimitStore interface {
+	// Take removes a token from the bucket of key, which holds at most burst tokens and
+	// gets rate tokens back per second. When the bucket is empty it returns false and how
+	// long until a token is available.
+	Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error)
+}
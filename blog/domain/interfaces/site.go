@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+type SiteUsecase interface {
+	// GetHomePage returns a page of the posts of the blog.
+	GetHomePage(ctx *gin.Context, page int) (*dto.PostList, error)
+	// GetAuthorPage returns a page of the posts of an author.
+	GetAuthorPage(ctx *gin.Context, authorID int64, page int) (*dto.PostList, error)
+	// GetTagPage returns a page of the posts carrying the tag of the given name.
+	GetTagPage(ctx *gin.Context, name string, page int) (*dto.PostList, error)
+	// GetPostPage returns a post with its tags and comments.
+	GetPostPage(ctx *gin.Context, postID int64) (*dto.PostView, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// MediaStorage keeps the files uploaded to the blog under keys such as "2026/10/f3a9c2.png".
+type MediaStorage interface {
+	// Put stores the size bytes of body under key.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the file stored under key. The error wraps fs.ErrNotExist when there is none.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the file stored under key, if any.
+	Delete(ctx context.Context, key string) error
+}
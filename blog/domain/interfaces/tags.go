@@ -8,7 +8,10 @@ import (
 
 type TagsUsecase interface {
 	GetTagById(ctx *gin.Context, tagID, postID int64) (*dto.Tag, error)
+	// GetTagsByPostIds returns the tags of the given posts ordered by id.
+	GetTagsByPostIds(ctx *gin.Context, postIDs []int64) ([]dto.Tag, error)
 	CreateTag(ctx *gin.Context, tagID int64, request *dto.Tag) (dto.CreateTagsResponse, error)
 	UpdateTags(ctx *gin.Context, tagID, postID int64, requestBody *dto.UpdateTagsBodyRequest) (*dto.Tag, error)
+	PatchTag(ctx *gin.Context, tagID, postID int64, patch []byte, ifMatch string) (*dto.Tag, error)
 	DeleteTags(ctx *gin.Context, tagID, postID int64) error
 }
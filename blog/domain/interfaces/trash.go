@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"blog/domain/dto"
+)
+
+type TrashUsecase interface {
+	GetTrash(ctx *gin.Context, limit int, offset int) (*dto.Trash, error)
+	RestoreUser(ctx *gin.Context, userID int64) (*dto.User, error)
+	RestorePost(ctx *gin.Context, postID, authorID int64) (*dto.Post, error)
+	RestoreComment(ctx *gin.Context, commentID, postID int64) (*dto.Comment, error)
+	Purge(ctx context.Context, before time.Time) (dto.PurgeResult, error)
+}
@@ -0,0 +1,24 @@
+// Package themes holds the themes shipped with the blog. The default theme is built into
+// the binary, so the site renders even when no themes directory is deployed.
+package themes
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// DefaultName is the name of the built-in theme.
+const DefaultName = "default"
+
+//go:embed default
+var files embed.FS
+
+// Default returns the files of the built-in theme.
+func Default() fs.FS {
+	theme, err := fs.Sub(files, DefaultName)
+	if err != nil {
+		// the directory is embedded above, Sub only fails on an invalid name
+		panic(err)
+	}
+	return theme
+}
@@ -0,0 +1,98 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"blog/domain/interfaces"
+	"blog/utils/cache"
+)
+
+func TestLRU(t *testing.T) {
+	c := cache.NewLRU(2)
+	testCache(t, c, func(d time.Duration) { time.Sleep(d) })
+
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a was the least recently used when c came in
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Error("a was not evicted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok, _ := c.Get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c := cache.NewRedis(client, "blog:")
+	testCache(t, c, server.FastForward)
+
+	if err := c.Set(context.Background(), "key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !server.Exists("blog:key") {
+		t.Errorf("keys %v, want blog:key", server.Keys())
+	}
+
+	server.Close()
+	if _, _, err := c.Get(context.Background(), "key"); err == nil {
+		t.Error("Get succeeded with the server down")
+	}
+}
+
+// testCache checks the behaviour every cache shares. wait lets ttl pass.
+func testCache(t *testing.T, c interfaces.Cache, wait func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get(missing) = %v, %v, want a miss", ok, err)
+	}
+
+	if err := c.Set(ctx, "user:1", []byte("ada"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "user:1", []byte("ada lovelace"), 0); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := c.Get(ctx, "user:1")
+	if err != nil || !ok || string(value) != "ada lovelace" {
+		t.Errorf("Get(user:1) = %q, %v, %v, want the last value set", value, ok, err)
+	}
+
+	if err := c.Delete(ctx, "user:1", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "user:1"); ok {
+		t.Error("user:1 is still cached after Delete")
+	}
+	if err := c.Delete(ctx); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+
+	const ttl = 10 * time.Millisecond
+	if err := c.Set(ctx, "post:1", []byte("notes"), ttl); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "post:1"); !ok {
+		t.Error("post:1 expired before its ttl")
+	}
+	wait(2 * ttl)
+	if _, ok, _ := c.Get(ctx, "post:1"); ok {
+		t.Error("post:1 is still cached after its ttl")
+	}
+}
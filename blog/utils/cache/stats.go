@@ -0,0 +1,57 @@
+package cache
+
+import "sync"
+
+// Counts are the hits and misses of the cached reads of a namespace.
+type Counts struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Stats counts cache hits and misses per namespace.
+type Stats struct {
+	mu     sync.Mutex
+	counts map[string]*Counts
+}
+
+func NewStats() *Stats {
+	return &Stats{counts: map[string]*Counts{}}
+}
+
+func (s *Stats) Hit(namespace string) {
+	s.mu.Lock()
+	s.get(namespace).Hits++
+	s.mu.Unlock()
+}
+
+func (s *Stats) Miss(namespace string) {
+	s.mu.Lock()
+	s.get(namespace).Misses++
+	s.mu.Unlock()
+}
+
+// Snapshot returns a copy of the current counts with their hit ratios.
+func (s *Stats) Snapshot() map[string]Counts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]Counts, len(s.counts))
+	for ns, c := range s.counts {
+		counts := *c
+		if total := counts.Hits + counts.Misses; total > 0 {
+			counts.HitRatio = float64(counts.Hits) / float64(total)
+		}
+		snapshot[ns] = counts
+	}
+	return snapshot
+}
+
+func (s *Stats) get(namespace string) *Counts {
+	c, ok := s.counts[namespace]
+	if !ok {
+		c = &Counts{}
+		s.counts[namespace] = c
+	}
+	return c
+}
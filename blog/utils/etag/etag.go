@@ -0,0 +1,44 @@
+package etag
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Strong builds a quoted strong entity tag from the parts identifying a version of a resource.
+func Strong(parts ...interface{}) string {
+	h := sha1.New()
+	for _, p := range parts {
+		if t, ok := p.(time.Time); ok {
+			p = t.UnixNano()
+		}
+		_, _ = fmt.Fprintf(h, "%v|", p)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// Match reports whether the etag matches one of the tags listed in an If-Match or
+// If-None-Match header. If-Match uses the strong comparison, If-None-Match the weak one.
+func Match(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(tag, "W/") && !strings.HasPrefix(etag, "W/") && tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package etag_test
+
+import (
+	"testing"
+	"time"
+
+	"blog/utils/etag"
+)
+
+func TestStrong(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tag := etag.Strong("post", 1, at)
+	if tag[0] != '"' || tag[len(tag)-1] != '"' {
+		t.Errorf("Strong() = %s, want a quoted tag", tag)
+	}
+	if again := etag.Strong("post", 1, at.In(time.FixedZone("CEST", 2*60*60))); again != tag {
+		t.Errorf("the same instant in another zone gives %s, want %s", again, tag)
+	}
+	for _, other := range []string{etag.Strong("post", 2, at), etag.Strong("post", 1, at.Add(time.Nanosecond)), etag.Strong("post1", at)} {
+		if other == tag {
+			t.Errorf("another version has the tag %s", tag)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header, etag string
+		weak, want   bool
+	}{
+		{`"a"`, `"a"`, false, true},
+		{`"a"`, `"b"`, false, false},
+		{`"b", "a"`, `"a"`, false, true},
+		{`*`, `"a"`, false, true},
+		{`W/"a"`, `"a"`, false, false},
+		{`"a"`, `W/"a"`, false, false},
+		{`W/"a"`, `"a"`, true, true},
+		{`"a"`, `W/"a"`, true, true},
+		{`W/"b",W/"a"`, `"a"`, true, true},
+		{`"b"`, `"a"`, true, false},
+		{``, `"a"`, true, false},
+	}
+	for _, tt := range tests {
+		if got := etag.Match(tt.header, tt.etag, tt.weak); got != tt.want {
+			t.Errorf("Match(%q, %q, weak=%v) = %v, want %v", tt.header, tt.etag, tt.weak, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package feed encodes syndication feeds.
+package feed
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Feed is a feed independent of its format.
+type Feed struct {
+	// ID is the permanent id of the feed, an absolute URI.
+	ID    string
+	Title string
+	// Link is the page the feed is about, Self the URL of the feed itself.
+	Link        string
+	Self        string
+	Description string
+	// Author is the author of the entries that have none.
+	Author  string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	// ID is the permanent id of the entry, an absolute URI that is not necessarily a URL.
+	ID         string
+	Title      string
+	Link       string
+	Author     string
+	Categories []string
+	// Summary and Content are plain text.
+	Summary   string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// TagURI builds a tag URI (RFC 4151) naming the resource specific of the authority of
+// siteURL, minted on date. The ids stay valid when the URLs of the site change.
+func TagURI(siteURL string, date time.Time, specific string) (string, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", u.Hostname(), date.UTC().Format("2006-01-02"), specific), nil
+}
+
+// Summarize puts text on one line and shortens it to at most n runes, cutting at a space
+// when there is one and marking the cut with an ellipsis.
+func Summarize(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	cut := n - 1
+	for i := cut; i > n/2; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	return string(runes[:cut]) + "…"
+}
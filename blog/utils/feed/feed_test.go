@@ -0,0 +1,269 @@
+package feed_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/utils/feed"
+)
+
+func TestTagURI(t *testing.T) {
+	created := time.Date(2026, 10, 19, 23, 30, 0, 0, time.FixedZone("PDT", -7*60*60))
+	got, err := feed.TagURI("https://blog.example.com:8443/path", created, "post/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the date is the one of UTC
+	if want := "tag:blog.example.com,2026-10-20:post/7"; got != want {
+		t.Errorf("TagURI = %q, want %q", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"notes on the analytical engine", 16, "notes on the…"},
+		{"ünïcödé wörds everywhere", 12, "ünïcödé…"},
+		// no space in the second half, the word is cut
+		{"supercalifragilistic", 10, "supercali…"},
+	}
+	for _, tt := range tests {
+		got := feed.Summarize(tt.text, tt.n)
+		if got != tt.want {
+			t.Errorf("Summarize(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+		if n := len([]rune(got)); n > tt.n {
+			t.Errorf("Summarize(%q, %d) has %d runes", tt.text, tt.n, n)
+		}
+	}
+}
+
+func testFeed() *feed.Feed {
+	published := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	return &feed.Feed{
+		ID:          "https://blog.example.com/",
+		Title:       "Engines & Notes",
+		Link:        "https://blog.example.com/",
+		Self:        "https://blog.example.com/feed.atom",
+		Description: "Latest posts",
+		Author:      "The Blog",
+		Updated:     published.Add(time.Hour),
+		Items: []feed.Item{
+			{
+				ID:         "tag:blog.example.com,2026-10-19:post/1",
+				Title:      "<notes>",
+				Link:       "https://blog.example.com/api/v1/user/1/post/1",
+				Author:     "ada",
+				Categories: []string{"math", "history"},
+				Summary:    "first & second",
+				Content:    "first & second\n\n<third>\nline",
+				Published:  published,
+				Updated:    published.Add(time.Hour),
+			},
+			{
+				ID:        "tag:blog.example.com,2026-10-19:post/2",
+				Title:     "short",
+				Summary:   "short",
+				Content:   "short",
+				Published: published,
+			},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := feed.RSS(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("no XML declaration: %s", data)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Self          struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+			Items []struct {
+				Title string `xml:"title"`
+				GUID  struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+				Description string   `xml:"description"`
+				Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				PubDate     string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+
+	channel := doc.Channel
+	if doc.Version != "2.0" || channel.Title != "Engines & Notes" || channel.LastBuildDate != "Mon, 19 Oct 2026 09:00:00 +0000" {
+		t.Errorf("channel %+v", channel)
+	}
+	if channel.Self.Href != "https://blog.example.com/feed.atom" || channel.Self.Rel != "self" || channel.Self.Type != "application/rss+xml" {
+		t.Errorf("self link %+v", channel.Self)
+	}
+	if len(channel.Items) != 2 {
+		t.Fatalf("%d items, want 2", len(channel.Items))
+	}
+	first, second := channel.Items[0], channel.Items[1]
+	if first.GUID.IsPermaLink != "false" || first.GUID.Value != "tag:blog.example.com,2026-10-19:post/1" {
+		t.Errorf("guid %+v", first.GUID)
+	}
+	if first.Title != "<notes>" || first.Description != "first & second" || first.PubDate != "Mon, 19 Oct 2026 08:00:00 +0000" {
+		t.Errorf("item %+v", first)
+	}
+	if want := "<p>first &amp; second</p><p>&lt;third&gt;<br>line</p>"; first.Content != want {
+		t.Errorf("content %q, want %q", first.Content, want)
+	}
+	if first.Creator != "ada" || len(first.Categories) != 2 || first.Categories[1] != "history" {
+		t.Errorf("creator %q, categories %v", first.Creator, first.Categories)
+	}
+	// an item without an author is credited to the one of the feed
+	if second.Creator != "The Blog" {
+		t.Errorf("creator %q, want the author of the feed", second.Creator)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	f := testFeed()
+	f.Items = append(f.Items, feed.Item{ID: "tag:blog.example.com,2026-10-19:post/3", Title: "undated"})
+	data, err := feed.Atom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type text struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Links   []link   `xml:"link"`
+		Author  *struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Entries []struct {
+			ID     string `xml:"id"`
+			Links  []link `xml:"link"`
+			Author *struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Summary   *text  `xml:"summary"`
+			Content   *text  `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+
+	if doc.ID != "https://blog.example.com/" || doc.Updated != "2026-10-19T09:00:00Z" || doc.Author == nil || doc.Author.Name != "The Blog" {
+		t.Errorf("feed %+v", doc)
+	}
+	if len(doc.Links) != 2 || doc.Links[0].Rel != "alternate" || doc.Links[1] != (link{Href: "https://blog.example.com/feed.atom", Rel: "self"}) {
+		t.Errorf("links %+v", doc.Links)
+	}
+	if len(doc.Entries) != 3 {
+		t.Fatalf("%d entries, want 3", len(doc.Entries))
+	}
+
+	first := doc.Entries[0]
+	if first.Author == nil || first.Author.Name != "ada" || len(first.Categories) != 2 || first.Categories[0].Term != "math" {
+		t.Errorf("entry %+v", first)
+	}
+	if first.Summary == nil || first.Summary.Value != "first & second" || first.Content == nil || first.Content.Type != "text" || first.Content.Value != "first & second\n\n<third>\nline" {
+		t.Errorf("summary %+v, content %+v", first.Summary, first.Content)
+	}
+
+	// entries without an author take the one of the feed, a summary equal to the content is left out
+	second := doc.Entries[1]
+	if second.Author != nil || len(second.Links) != 0 || second.Summary != nil || second.Content == nil {
+		t.Errorf("entry %+v", second)
+	}
+	// the dates are required, unknown ones are the epoch
+	if second.Updated != "1970-01-01T00:00:00Z" || doc.Entries[2].Published != "1970-01-01T00:00:00Z" {
+		t.Errorf("updated %q, published %q", second.Updated, doc.Entries[2].Published)
+	}
+	if doc.Entries[2].Content != nil || doc.Entries[2].Summary != nil {
+		t.Errorf("entry without text %+v", doc.Entries[2])
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	data, err := feed.JSONFeed(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type author struct {
+		Name string `json:"name"`
+	}
+	var doc struct {
+		Version     string   `json:"version"`
+		Title       string   `json:"title"`
+		HomePageURL string   `json:"home_page_url"`
+		FeedURL     string   `json:"feed_url"`
+		Authors     []author `json:"authors"`
+		Items       []struct {
+			ID            string   `json:"id"`
+			URL           string   `json:"url"`
+			ContentText   string   `json:"content_text"`
+			Summary       *string  `json:"summary"`
+			DatePublished string   `json:"date_published"`
+			DateModified  *string  `json:"date_modified"`
+			Authors       []author `json:"authors"`
+			Tags          []string `json:"tags"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+
+	if doc.Version != "https://jsonfeed.org/version/1.1" || doc.HomePageURL != "https://blog.example.com/" || doc.FeedURL != "https://blog.example.com/feed.atom" {
+		t.Errorf("feed %+v", doc)
+	}
+	if len(doc.Authors) != 1 || doc.Authors[0].Name != "The Blog" || len(doc.Items) != 2 {
+		t.Fatalf("feed %+v", doc)
+	}
+	first, second := doc.Items[0], doc.Items[1]
+	if first.Summary == nil || *first.Summary != "first & second" || first.ContentText != "first & second\n\n<third>\nline" {
+		t.Errorf("item %+v", first)
+	}
+	if len(first.Authors) != 1 || first.Authors[0].Name != "ada" || len(first.Tags) != 2 || first.DatePublished != "2026-10-19T08:00:00Z" {
+		t.Errorf("item %+v", first)
+	}
+	// the summary repeating the content and the unknown dates are left out
+	if second.Summary != nil || second.DateModified != nil || second.Authors != nil {
+		t.Errorf("item %+v", second)
+	}
+}
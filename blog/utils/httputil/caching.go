@@ -0,0 +1,41 @@
+package httputil
+
+import (
+	"net/http"
+	"time"
+
+	"blog/utils/etag"
+)
+
+// NotModified sets the ETag and Last-Modified validators of a representation and answers
+// a conditional GET. It writes a 304 and returns true when the client's copy is still fresh,
+// in which case the caller must not write a body.
+func NotModified(w http.ResponseWriter, r *http.Request, tag string, lastModified time.Time) bool {
+	if tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 7232, section 6).
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if tag == "" || !etag.Match(inm, tag, true) {
+			return false
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,56 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"blog/utils/httputil"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 10, 19, 12, 0, 0, 500, time.UTC)
+	const tag = `"v2"`
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		tag    string
+		want   bool
+	}{
+		{"unconditional", http.MethodGet, nil, tag, false},
+		{"same tag", http.MethodGet, map[string]string{"If-None-Match": tag}, tag, true},
+		{"weak copy", http.MethodHead, map[string]string{"If-None-Match": `"v1", W/"v2"`}, tag, true},
+		{"any", http.MethodGet, map[string]string{"If-None-Match": "*"}, tag, true},
+		{"stale tag", http.MethodGet, map[string]string{"If-None-Match": `"v1"`}, tag, false},
+		{"no tag to compare", http.MethodGet, map[string]string{"If-None-Match": `"v1"`}, "", false},
+		{"not modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, tag, true},
+		{"modified since", http.MethodGet, map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, tag, false},
+		{"invalid date", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, tag, false},
+		// If-None-Match wins over If-Modified-Since
+		{"stale tag, old date", http.MethodGet, map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, tag, false},
+		{"write", http.MethodPut, map[string]string{"If-None-Match": tag}, tag, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/posts", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			if got := httputil.NotModified(w, r, tt.tag, modified); got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status %d, want 304", w.Code)
+			}
+			if got := w.Header().Get("ETag"); got != tt.tag {
+				t.Errorf("ETag %q, want %q", got, tt.tag)
+			}
+			if got := w.Header().Get("Last-Modified"); got != "Mon, 19 Oct 2026 12:00:00 GMT" {
+				t.Errorf("Last-Modified %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package httputil
+
+import "context"
+
+// Caller is the client identified by the API key of a request.
+type Caller struct {
+	// Name is the caller the key was issued to.
+	Name string
+	// Key is the digest of the key, never the key itself.
+	Key string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx carrying the identified caller of the request.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerOf returns the caller carried by ctx, ok is false for anonymous requests.
+func CallerOf(ctx context.Context) (caller Caller, ok bool) {
+	caller, ok = ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
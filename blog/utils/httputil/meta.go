@@ -0,0 +1,22 @@
+package httputil
+
+import "context"
+
+type metaKey struct{}
+
+// WithMeta returns a copy of ctx whose responses carry key in StandardHeader.Meta.
+func WithMeta(ctx context.Context, key string, value interface{}) context.Context {
+	meta := map[string]interface{}{key: value}
+	for k, v := range Meta(ctx) {
+		if k != key {
+			meta[k] = v
+		}
+	}
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// Meta returns the response meta carried by ctx, or nil when there is none.
+func Meta(ctx context.Context) map[string]interface{} {
+	meta, _ := ctx.Value(metaKey{}).(map[string]interface{})
+	return meta
+}
@@ -0,0 +1,269 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v2"
+)
+
+// Media types of the encodings of StandardEnvelope.
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMEYAML    = "application/x-yaml"
+	MIMEMsgPack = "application/msgpack"
+)
+
+// offers lists the supported encodings in order of preference, each with the media types
+// clients may ask it under.
+var offers = []struct {
+	contentType string
+	aliases     []string
+}{
+	{MIMEJSON, []string{MIMEJSON}},
+	{MIMEXML, []string{MIMEXML, "text/xml"}},
+	{MIMEYAML, []string{MIMEYAML, "application/yaml", "text/yaml", "text/x-yaml"}},
+	{MIMEMsgPack, []string{MIMEMsgPack, "application/x-msgpack", "application/vnd.msgpack"}},
+}
+
+// SupportedMediaTypes returns the media types the API responses can be encoded in.
+func SupportedMediaTypes() []string {
+	types := make([]string, len(offers))
+	for i, offer := range offers {
+		types[i] = offer.contentType
+	}
+	return types
+}
+
+// Negotiate picks the encoding of a response from the Accept header of the request (RFC 7231,
+// section 5.3.2). An empty header gets JSON. It returns false when none of the accepted media
+// types is supported.
+func Negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MIMEJSON, true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// the most specific range matching the offer decides its quality
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			for _, alias := range offer.aliases {
+				if s := r.match(alias); s > specificity {
+					q, specificity = r.q, s
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer.contentType, q
+		}
+	}
+	return best, best != ""
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// match tells how specifically the range matches mediaType: 2 for the exact type, 1 for
+// "type/*", 0 for "*/*" and -1 when it does not match.
+func (r mediaRange) match(mediaType string) int {
+	switch {
+	case r.mediaType == mediaType:
+		return 2
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")):
+		return 1
+	}
+	return -1
+}
+
+// Encode marshals v in the content type of the response, the one picked by the content
+// negotiation or JSON when none was, and sets the Content-Type header accordingly.
+//
+// The other encodings are rendered from the JSON one so that every format shows the same
+// fields under the same names, and never the fields hidden from JSON.
+func Encode(w http.ResponseWriter, v interface{}) ([]byte, error) {
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+
+	data, err := json.Marshal(v)
+	if err != nil || (contentType != MIMEXML && contentType != MIMEYAML && contentType != MIMEMsgPack) {
+		w.Header().Set("Content-Type", MIMEJSON)
+		return data, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tree, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the JSON encoding")
+	}
+
+	switch contentType {
+	case MIMEXML:
+		data, err = encodeXML(tree)
+	case MIMEYAML:
+		data, err = yaml.Marshal(plainValue(tree, true))
+	case MIMEMsgPack:
+		handle := &codec.MsgpackHandle{WriteExt: true}
+		handle.Canonical = true
+		err = codec.NewEncoderBytes(&data, handle).Encode(plainValue(tree, false))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode %s", contentType)
+	}
+	w.Header().Set("Content-Type", contentType)
+	return data, nil
+}
+
+// object is a decoded JSON object keeping its members in order.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// decodeOrdered decodes the next JSON value into objects, []interface{}, strings,
+// json.Numbers, bools and nils.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// plainValue converts a decoded tree into the types of the YAML and MessagePack encoders.
+// Objects become yaml.MapSlice when ordered is set, maps otherwise.
+func plainValue(v interface{}, ordered bool) interface{} {
+	switch v := v.(type) {
+	case object:
+		if ordered {
+			slice := make(yaml.MapSlice, len(v))
+			for i, m := range v {
+				slice[i] = yaml.MapItem{Key: m.key, Value: plainValue(m.value, ordered)}
+			}
+			return slice
+		}
+		m := make(map[string]interface{}, len(v))
+		for _, member := range v {
+			m[member.key] = plainValue(member.value, ordered)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = plainValue(v[i], ordered)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// encodeXML writes a decoded tree under an <envelope> root: object members become elements
+// named after their keys and array items <item> elements.
+func encodeXML(tree interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := writeXML(enc, "envelope", tree); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXML(enc *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case object:
+		for _, m := range v {
+			if err := writeXML(enc, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXML(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
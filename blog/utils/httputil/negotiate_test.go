@@ -0,0 +1,89 @@
+package httputil_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+
+	"blog/utils/httputil"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept, want string
+		ok           bool
+	}{
+		{"", httputil.MIMEJSON, true},
+		{"*/*", httputil.MIMEJSON, true},
+		{"application/*", httputil.MIMEJSON, true},
+		{"text/xml", httputil.MIMEXML, true},
+		{"application/yaml", httputil.MIMEYAML, true},
+		{"application/vnd.msgpack", httputil.MIMEMsgPack, true},
+		{"application/json;q=0.5, application/xml", httputil.MIMEXML, true},
+		// the most specific range decides
+		{"*/*;q=0.1, application/json;q=0", httputil.MIMEXML, true},
+		{"text/*, application/json;q=0.5", httputil.MIMEXML, true},
+		{"text/html, application/xml;q=0.9", httputil.MIMEXML, true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+		{"not a media type", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := httputil.Negotiate(tt.accept)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%q) = %q, %v, want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type document struct {
+	ID     int64    `json:"id"`
+	Title  string   `json:"title"`
+	Tags   []string `json:"tags"`
+	Secret string   `json:"-"`
+}
+
+func TestEncode(t *testing.T) {
+	doc := document{ID: 9007199254740993, Title: "notes & sketches", Tags: []string{"engines"}, Secret: "hidden"}
+	encode := func(contentType string) (string, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", contentType)
+		data, err := httputil.Encode(w, doc)
+		if err != nil {
+			t.Fatalf("Encode(%s): %v", contentType, err)
+		}
+		if strings.Contains(string(data), "hidden") {
+			t.Errorf("the %s encoding shows a field hidden from JSON: %s", contentType, data)
+		}
+		return w.Header().Get("Content-Type"), string(data)
+	}
+
+	if contentType, data := encode(""); contentType != httputil.MIMEJSON || data != `{"id":9007199254740993,"title":"notes \u0026 sketches","tags":["engines"]}` {
+		t.Errorf("default encoding %s: %s", contentType, data)
+	}
+
+	wantXML := `<envelope><id>9007199254740993</id><title>notes &amp; sketches</title><tags><item>engines</item></tags></envelope>`
+	if contentType, data := encode(httputil.MIMEXML); contentType != httputil.MIMEXML || !strings.HasSuffix(data, wantXML) {
+		t.Errorf("XML encoding %s: %s, want %s", contentType, data, wantXML)
+	}
+
+	// the members keep the order of the JSON encoding
+	wantYAML := "id: 9007199254740993\ntitle: notes & sketches\ntags:\n- engines\n"
+	if contentType, data := encode(httputil.MIMEYAML); contentType != httputil.MIMEYAML || data != wantYAML {
+		t.Errorf("YAML encoding %s: %q, want %q", contentType, data, wantYAML)
+	}
+
+	contentType, data := encode(httputil.MIMEMsgPack)
+	var decoded map[string]interface{}
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	if err := codec.NewDecoderBytes([]byte(data), handle).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != httputil.MIMEMsgPack || decoded["id"] != int64(9007199254740993) || decoded["title"] != doc.Title {
+		t.Errorf("MessagePack encoding %s: %v", contentType, decoded)
+	}
+}
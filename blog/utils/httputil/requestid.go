@@ -0,0 +1,20 @@
+package httputil
+
+import "context"
+
+// RequestIDHeader carries the id correlating a request with its responses and log lines.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request id, which is also added to
+// the response meta.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithMeta(context.WithValue(ctx, requestIDKey{}, id), "request_id", id)
+}
+
+// RequestID returns the request id carried by ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
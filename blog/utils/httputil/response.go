@@ -23,12 +23,21 @@ type StandardStatus struct {
 
 // StandardError is standard JSON HTTP Error.
 type StandardError struct {
-	Code   string      `json:"code"`
-	Title  string      `json:"title"`
-	Detail string      `json:"detail"`
-	Object ErrorObject `json:"object"`
+	Code      string      `json:"code"`
+	Title     string      `json:"title"`
+	Detail    string      `json:"detail"`
+	Object    ErrorObject `json:"object"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
+// Error object types, telling clients how to read ErrorObject.Text.
+const (
+	// ErrorTypeGeneric errors carry no additional details.
+	ErrorTypeGeneric int64 = iota
+	// ErrorTypeValidation errors list the offending request fields in Text.
+	ErrorTypeValidation
+)
+
 // ErrorObject holds any additional details of an error.
 type ErrorObject struct {
 	Text []string `json:"text"`
@@ -1,9 +1,9 @@
 package httputil
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 type ResponseDecorator interface {
@@ -20,13 +20,36 @@ func NewContentTypeDecorator(contentType string) ContentTypeDecorator {
 	return ContentTypeDecorator(contentType)
 }
 
+// CORSDecorator sets the CORS headers of a response to an allowed origin.
 type CORSDecorator struct {
-	allowedOrigin string
+	allowedOrigin    string
+	allowCredentials bool
+	exposedHeaders   string
+}
+
+// NewCORSDecorator allows allowedOrigin, either an origin or "*", to read the response,
+// with cookies when allowCredentials is set. Browsers refuse credentials with "*", so pass
+// the request origin in that case. exposedHeaders lists the response headers scripts may read.
+func NewCORSDecorator(allowedOrigin string, allowCredentials bool, exposedHeaders ...string) *CORSDecorator {
+	return &CORSDecorator{
+		allowedOrigin:    allowedOrigin,
+		allowCredentials: allowCredentials,
+		exposedHeaders:   strings.Join(exposedHeaders, ", "),
+	}
 }
 
 func (d *CORSDecorator) Decorate(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	if d.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
 	w.Header().Set("Access-Control-Allow-Origin", d.allowedOrigin)
+	if d.allowedOrigin != "*" {
+		// the response depends on the origin, shared caches must keep one copy per origin
+		w.Header().Add("Vary", "Origin")
+	}
+	if d.exposedHeaders != "" {
+		w.Header().Set("Access-Control-Expose-Headers", d.exposedHeaders)
+	}
 }
 
 func WriteJSONResponse(w http.ResponseWriter, data []byte, status int) (int, error) {
@@ -45,13 +68,22 @@ func WriteResponse(w http.ResponseWriter, data []byte, status int, decorators ..
 
 func WriteErrorResponse(w http.ResponseWriter, code int, errs []StandardError) {
 	contentType := NewContentTypeDecorator("application/json")
+	// errors are never cached, whatever the route's Cache-Control policy is
+	w.Header().Set("Cache-Control", "no-store")
+	// tie every error to the request id set by the RequestID middleware
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		for i := range errs {
+			errs[i].RequestID = id
+		}
+	}
 	response := StandardEnvelope{
 		Errors: errs,
 	}
-	errResponse, err := json.Marshal(response)
+	errResponse, err := Encode(w, response)
 	if err != nil {
 		WriteResponse(w, []byte(fmt.Sprintf(`{"errors":[{"code":"500","title":"Internal Server Error","detail":"%s","object":{"text":null,"type":0}}]}`, err.Error())), http.StatusInternalServerError, contentType)
+		return
 	}
 
-	WriteResponse(w, errResponse, code, contentType)
+	WriteResponse(w, errResponse, code)
 }
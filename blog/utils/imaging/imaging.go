@@ -0,0 +1,172 @@
+// Package imaging processes the uploaded images: it removes their metadata, renders their
+// variants and computes the placeholders shown while they load.
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/chai2010/webp"
+	"github.com/pkg/errors"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Format is an encoding of the variants.
+type Format string
+
+const (
+	WebP Format = "webp"
+	JPEG Format = "jpeg"
+)
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	if f == JPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// Supported reports whether images of contentType can be decoded.
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Size returns the size of the image as shown, once oriented.
+func Size(data []byte) (width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, errors.Wrap(ErrInvalidImage, err.Error())
+	}
+	_, orientation, err := strip(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if orientation >= 5 {
+		return cfg.Height, cfg.Width, nil
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Decode decodes an image, the first frame of the animated ones, and turns it the right way
+// up after its EXIF orientation.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidImage, err.Error())
+	}
+	_, orientation, err := strip(data)
+	if err != nil {
+		return nil, err
+	}
+	return orient(img, orientation), nil
+}
+
+// orient applies an EXIF orientation: 2 to 4 mirror or turn the image upside down, 5 to 8
+// also swap its sides.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, w-1-x
+			case 7:
+				sx, sy = h-1-y, w-1-x
+			case 8:
+				sx, sy = h-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+// Resize scales img down to width, keeping its aspect ratio.
+func Resize(img image.Image, width int) *image.NRGBA {
+	b := img.Bounds()
+	height := (b.Dy()*width + b.Dx()/2) / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Rect, img, b, xdraw.Src, nil)
+	return dst
+}
+
+// Thumbnail crops the middle square of img and scales it down to size, or to the side of
+// the square when it is smaller.
+func Thumbnail(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	if size > side {
+		size = side
+	}
+	x, y := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Rect, img, image.Rect(x, y, x+side, y+side), xdraw.Src, nil)
+	return dst
+}
+
+// Encode writes img in format at quality, from 1 to 100. JPEG having no transparency, the
+// transparent parts are laid on white.
+func Encode(w io.Writer, img image.Image, format Format, quality int) error {
+	switch format {
+	case WebP:
+		return webp.Encode(w, img, &webp.Options{Quality: float32(quality)})
+	case JPEG:
+		if o, ok := img.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+			flat := image.NewRGBA(img.Bounds())
+			draw.Draw(flat, flat.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+			draw.Draw(flat, flat.Rect, img, img.Bounds().Min, draw.Over)
+			img = flat
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return errors.Errorf("unknown image format %q", format)
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,225 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/chai2010/webp"
+)
+
+// gps is the EXIF block of a photo taken at a known place.
+var gps = append([]byte("Exif\x00\x00"), tiffWithGPS(6)...)
+
+// tiffWithGPS builds a TIFF structure holding the orientation and a GPS directory.
+func tiffWithGPS(orientation int) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 2}
+	entry := func(tag, kind uint16, count, value uint32) {
+		e := make([]byte, 12)
+		binary.BigEndian.PutUint16(e, tag)
+		binary.BigEndian.PutUint16(e[2:], kind)
+		binary.BigEndian.PutUint32(e[4:], count)
+		binary.BigEndian.PutUint32(e[8:], value)
+		tiff = append(tiff, e...)
+	}
+	entry(orientationTag, shortType, 1, uint32(orientation)<<16)
+	entry(0x8825, 4, 1, 38) // GPS directory
+	tiff = append(tiff, 0, 0, 0, 0)
+	// GPS directory: latitude reference "N"
+	tiff = append(tiff, 0, 1)
+	entry(0x0001, 2, 2, 'N'<<24)
+	return append(tiff, 0, 0, 0, 0)
+}
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// a red band marks the left quarter
+			c := color.NRGBA{R: 0x20, G: 0x40, B: 0xc0, A: 0xff}
+			if x < w/4 {
+				c = color.NRGBA{R: 0xff, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestStripJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(8, 4), nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// EXIF and a comment after the start of image, a video appended after its end
+	segment := func(marker byte, payload []byte) []byte {
+		s := []byte{0xff, marker, 0, 0}
+		binary.BigEndian.PutUint16(s[2:], uint16(2+len(payload)))
+		return append(s, payload...)
+	}
+	var data []byte
+	data = append(data, encoded[:2]...)
+	data = append(data, segment(markerAPP1, gps)...)
+	data = append(data, segment(markerCOM, []byte("taken at home"))...)
+	data = append(data, encoded[2:]...)
+	data = append(data, "ftypmp42"...)
+
+	stripped, err := StripMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"taken at home", "ftypmp42", "\x88\x25"} {
+		if bytes.Contains(stripped, []byte(leak)) {
+			t.Errorf("stripped image still holds %q", leak)
+		}
+	}
+
+	// the orientation is kept: the 8x4 image is shown 4x8
+	w, h, err := Size(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != 4 || h != 8 {
+		t.Errorf("Size = %dx%d, want 4x8", w, h)
+	}
+	img, err := Decode(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// turned clockwise, the left side is on top
+	if r, g, b, _ := img.At(2, 0).RGBA(); r>>8 < 0xc0 || g>>8 > 0x40 || b>>8 > 0x40 {
+		t.Errorf("top row is %x %x %x, want red", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(8, 4)); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// the header is the first chunk, 25 bytes long
+	headerEnd := len(pngSignature) + 25
+	var data []byte
+	data = append(data, encoded[:headerEnd]...)
+	data = insertChunk(data, headerEnd, "tEXt", []byte("Comment\x00taken at home"))
+	data = insertChunk(data, headerEnd, "eXIf", gps[len(exifHeader):])
+	data = append(data, encoded[headerEnd:]...)
+
+	stripped, err := StripMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("taken at home")) || bytes.Contains(stripped, []byte{0x88, 0x25}) {
+		t.Error("stripped image still holds metadata")
+	}
+	w, h, err := Size(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != 4 || h != 8 {
+		t.Errorf("Size = %dx%d, want 4x8", w, h)
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, testImage(8, 4), &webp.Options{Lossless: true}); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// extended format: a VP8X chunk announcing the EXIF chunk following the image
+	chunk := func(kind string, payload []byte) []byte {
+		c := make([]byte, 8, 8+len(payload)+1)
+		copy(c, kind)
+		binary.LittleEndian.PutUint32(c[4:], uint32(len(payload)))
+		c = append(c, payload...)
+		if len(payload)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	vp8x := []byte{0x08, 0, 0, 0, 7, 0, 0, 3, 0, 0}
+	var data []byte
+	data = append(data, encoded[:12]...)
+	data = append(data, chunk("VP8X", vp8x)...)
+	data = append(data, encoded[12:]...)
+	data = append(data, chunk("EXIF", gps[len(exifHeader):])...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	stripped, err := StripMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) || stripped[20]&0x08 != 0 {
+		t.Error("stripped image still holds its EXIF chunk")
+	}
+	if _, err := Decode(stripped); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStripInvalid(t *testing.T) {
+	for _, data := range []string{
+		"\xff\xd8\xff\xe1\xff\xff",
+		"\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR",
+		"RIFF\xff\xff\x00\x00WEBP",
+	} {
+		if _, err := StripMetadata([]byte(data)); err == nil {
+			t.Errorf("StripMetadata(%q) succeeded", data)
+		}
+	}
+	// other formats are left alone
+	if out, err := StripMetadata([]byte("GIF89a")); err != nil || string(out) != "GIF89a" {
+		t.Errorf("StripMetadata(GIF) = %q, %v", out, err)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	img := testImage(300, 200)
+	if b := Resize(img, 120).Bounds(); b.Dx() != 120 || b.Dy() != 80 {
+		t.Errorf("Resize = %v, want 120x80", b)
+	}
+	if b := Thumbnail(img, 64).Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Errorf("Thumbnail = %v, want 64x64", b)
+	}
+	if b := Thumbnail(img, 500).Bounds(); b.Dx() != 200 || b.Dy() != 200 {
+		t.Errorf("Thumbnail = %v, want 200x200", b)
+	}
+
+	for _, format := range []Format{WebP, JPEG} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, format, 80); err != nil {
+			t.Fatal(err)
+		}
+		if _, name, err := image.DecodeConfig(&buf); err != nil || name != string(format) {
+			t.Errorf("%s variant decodes as %q: %v", format, name, err)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	img := testImage(300, 200)
+	if got := DominantColor(img); got != "#2040c0" {
+		t.Errorf("DominantColor = %s, want #2040c0", got)
+	}
+	if got := DominantColor(image.NewNRGBA(image.Rect(0, 0, 4, 4))); got != "" {
+		t.Errorf("DominantColor of a transparent image = %s, want none", got)
+	}
+	hash, err := Blurhash(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4x3 components
+	if len(hash) != 4+2*4*3 {
+		t.Errorf("Blurhash = %s", hash)
+	}
+}
@@ -0,0 +1,287 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidImage is returned for the images whose structure cannot be read.
+var ErrInvalidImage = errors.New("invalid image")
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader   = []byte("Exif\x00\x00")
+)
+
+// JPEG markers.
+const (
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerRST0 = 0xd0
+	markerRST7 = 0xd7
+	markerTEM  = 0x01
+	markerAPP0 = 0xe0
+	markerAPP1 = 0xe1
+	// APP2 holds the ICC profile and APP14 the Adobe color transform, both needed to show
+	// the colors right
+	markerAPP2  = 0xe2
+	markerAPP14 = 0xee
+	markerAPP15 = 0xef
+	markerCOM   = 0xfe
+)
+
+// TIFF tag of the orientation, a SHORT from 1 to 8.
+const (
+	orientationTag = 0x0112
+	shortType      = 3
+)
+
+// StripMetadata removes the metadata a JPEG, PNG or WebP image carries: EXIF (location,
+// camera, dates), XMP, IPTC, comments and anything appended after the image. The color
+// profiles are kept, and so is the orientation of JPEG and PNG images, in an EXIF block of
+// its own, for them to be shown the right way up. Other formats are returned unchanged.
+func StripMetadata(data []byte) ([]byte, error) {
+	stripped, _, err := strip(data)
+	return stripped, err
+}
+
+func strip(data []byte) ([]byte, int, error) {
+	switch {
+	case len(data) > 2 && data[0] == 0xff && data[1] == markerSOI:
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	case isWebP(data):
+		stripped, err := stripWebP(data)
+		return stripped, 1, err
+	}
+	return data, 1, nil
+}
+
+func stripJPEG(data []byte) ([]byte, int, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	// the orientation goes after the JFIF header, which must come first
+	insertAt := len(out)
+	orientation := 1
+
+	for i := 2; i < len(data); {
+		if data[i] != 0xff || i+1 == len(data) {
+			return nil, 0, errors.Wrapf(ErrInvalidImage, "JPEG marker expected at %d", i)
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// fill byte
+			i++
+			continue
+		case marker == markerEOI:
+			// whatever follows, such as the video of a motion photo, is dropped
+			out = append(out, data[i:i+2]...)
+			return withOrientation(out, insertAt, orientation), orientation, nil
+		case marker == markerTEM || (marker >= markerRST0 && marker <= markerRST7):
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, 0, errors.Wrap(ErrInvalidImage, "truncated JPEG segment")
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, 0, errors.Wrap(ErrInvalidImage, "truncated JPEG segment")
+		}
+		payload := data[i+4 : end]
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader):
+			orientation = exifOrientation(payload[len(exifHeader):])
+		case marker == markerAPP0 || marker == markerAPP2 || marker == markerAPP14:
+			out = append(out, data[i:end]...)
+			if marker == markerAPP0 && insertAt == 2 {
+				insertAt = len(out)
+			}
+		case (marker >= markerAPP1 && marker <= markerAPP15) || marker == markerCOM:
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+
+		if marker == markerSOS {
+			// the entropy-coded data runs to the next marker, leaving aside the stuffed
+			// zero bytes and the restart markers
+			start := i
+			for i+1 < len(data) && (data[i] != 0xff || data[i+1] == 0 || (data[i+1] >= markerRST0 && data[i+1] <= markerRST7)) {
+				i++
+			}
+			if i+1 >= len(data) {
+				// a truncated image still shows, as far as it goes
+				out = append(out, data[start:]...)
+				return withOrientation(out, insertAt, orientation), orientation, nil
+			}
+			out = append(out, data[start:i]...)
+		}
+	}
+	return withOrientation(out, insertAt, orientation), orientation, nil
+}
+
+// withOrientation inserts an APP1 segment holding only the orientation at insertAt.
+func withOrientation(jpeg []byte, insertAt, orientation int) []byte {
+	if orientation == 1 {
+		return jpeg
+	}
+	exif := append(append([]byte{}, exifHeader...), orientationTIFF(orientation)...)
+	segment := []byte{0xff, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(exif)))
+	segment = append(segment, exif...)
+
+	out := make([]byte, 0, len(jpeg)+len(segment))
+	out = append(out, jpeg[:insertAt]...)
+	out = append(out, segment...)
+	return append(out, jpeg[insertAt:]...)
+}
+
+func stripPNG(data []byte) ([]byte, int, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	// the orientation goes after the header, which must come first
+	insertAt := 0
+	orientation := 1
+
+	for i := len(pngSignature); ; {
+		if i+12 > len(data) {
+			return nil, 0, errors.Wrap(ErrInvalidImage, "truncated PNG chunk")
+		}
+		length := int64(binary.BigEndian.Uint32(data[i:]))
+		if length > int64(len(data)-i-12) {
+			return nil, 0, errors.Wrap(ErrInvalidImage, "truncated PNG chunk")
+		}
+		end := i + 12 + int(length)
+		switch kind := string(data[i+4 : i+8]); kind {
+		case "eXIf":
+			orientation = exifOrientation(data[i+8 : end-4])
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+			if kind == "IHDR" {
+				insertAt = len(out)
+			}
+			if kind == "IEND" {
+				// whatever follows is dropped
+				if orientation != 1 && insertAt > 0 {
+					out = insertChunk(out, insertAt, "eXIf", orientationTIFF(orientation))
+				}
+				return out, orientation, nil
+			}
+		}
+		i = end
+	}
+}
+
+func insertChunk(png []byte, insertAt int, kind string, data []byte) []byte {
+	chunk := make([]byte, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], kind)
+	copy(chunk[8:], data)
+	binary.BigEndian.PutUint32(chunk[8+len(data):], crc32.ChecksumIEEE(chunk[4:8+len(data)]))
+
+	out := make([]byte, 0, len(png)+len(chunk))
+	out = append(out, png[:insertAt]...)
+	out = append(out, chunk...)
+	return append(out, png[insertAt:]...)
+}
+
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// stripWebP removes the EXIF and XMP chunks of a WebP image, the orientation included: WebP
+// encoders write the pixels the right way up.
+func stripWebP(data []byte) ([]byte, error) {
+	size := int64(binary.LittleEndian.Uint32(data[4:])) + 8
+	if size > int64(len(data)) {
+		return nil, errors.Wrap(ErrInvalidImage, "truncated WebP file")
+	}
+	// whatever follows the RIFF file is dropped
+	data = data[:size]
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errors.Wrap(ErrInvalidImage, "truncated WebP chunk")
+		}
+		length := int64(binary.LittleEndian.Uint32(data[i+4:]))
+		end := int64(i) + 8 + length + length&1
+		if end > int64(len(data)) {
+			return nil, errors.Wrap(ErrInvalidImage, "truncated WebP chunk")
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[i:end]...)
+			if length > 0 {
+				// the flags announcing the EXIF and XMP chunks
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = int(end)
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// exifOrientation reads the orientation in the first directory of the TIFF structure of an
+// EXIF block, 1 when there is none.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > int64(len(tiff)) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := int(ifd) + 2 + 12*n
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag && order.Uint16(tiff[entry+2:]) == shortType {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+		}
+	}
+	return 1
+}
+
+// orientationTIFF builds a TIFF structure holding only the orientation.
+func orientationTIFF(orientation int) []byte {
+	// header, one entry, then the offset of the next directory: none
+	tiff := make([]byte, 26)
+	copy(tiff, "MM\x00\x2a")
+	binary.BigEndian.PutUint32(tiff[4:], 8)
+	binary.BigEndian.PutUint16(tiff[8:], 1)
+	binary.BigEndian.PutUint16(tiff[10:], orientationTag)
+	binary.BigEndian.PutUint16(tiff[12:], shortType)
+	binary.BigEndian.PutUint32(tiff[14:], 1)
+	binary.BigEndian.PutUint16(tiff[18:], uint16(orientation))
+	return tiff
+}
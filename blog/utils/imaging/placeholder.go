@@ -0,0 +1,73 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/buckket/go-blurhash"
+)
+
+// placeholderSide is the longest side of the copy the placeholders are computed from, which
+// holds more detail than they show.
+const placeholderSide = 64
+
+// Blurhash encodes a blurred version of img shown while it loads, with 4 components along
+// its longest side and 3 along the other.
+func Blurhash(img image.Image) (string, error) {
+	small := shrink(img)
+	x, y := 4, 3
+	if small.Rect.Dy() > small.Rect.Dx() {
+		x, y = 3, 4
+	}
+	return blurhash.Encode(x, y, small)
+}
+
+// DominantColor returns the most common color of img as #rrggbb, ignoring the transparent
+// parts, or "" when it is all transparent. Similar colors count as one, whose value is
+// their average.
+func DominantColor(img image.Image) string {
+	type bucket struct {
+		n       int
+		r, g, b int
+	}
+	// 4 bits per channel
+	var buckets [1 << 12]bucket
+
+	small := shrink(img)
+	best := -1
+	for i := 0; i < len(small.Pix); i += 4 {
+		r, g, b, a := int(small.Pix[i]), int(small.Pix[i+1]), int(small.Pix[i+2]), small.Pix[i+3]
+		if a < 128 {
+			continue
+		}
+		k := r>>4<<8 | g>>4<<4 | b>>4
+		buckets[k].n++
+		buckets[k].r += r
+		buckets[k].g += g
+		buckets[k].b += b
+		if best < 0 || buckets[k].n > buckets[best].n {
+			best = k
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	c := buckets[best]
+	return fmt.Sprintf("#%02x%02x%02x", c.r/c.n, c.g/c.n, c.b/c.n)
+}
+
+// shrink scales img down for its longest side to be placeholderSide at most.
+func shrink(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if b.Dx() <= placeholderSide && b.Dy() <= placeholderSide {
+		return toNRGBA(img)
+	}
+	width := placeholderSide
+	if b.Dy() > b.Dx() {
+		width = (b.Dx()*placeholderSide + b.Dy()/2) / b.Dy()
+		if width < 1 {
+			width = 1
+		}
+	}
+	return Resize(img, width)
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	dbLoggerKey = "log:logger"
+	dbStartKey  = "log:start"
+)
+
+// GormLogger receives the messages gorm prints on its own, such as callback
+// registrations and errors, in place of its stdout logger.
+type GormLogger struct {
+	logger *zap.Logger
+}
+
+func NewGormLogger(logger *zap.Logger) *GormLogger {
+	return &GormLogger{logger: logger}
+}
+
+func (l *GormLogger) Print(v ...interface{}) {
+	if len(v) < 2 {
+		return
+	}
+	if v[0] == "error" {
+		l.logger.Error(fmt.Sprint(v[1:]...))
+		return
+	}
+	l.logger.Debug(fmt.Sprint(v[1:]...))
+}
+
+// WithDB returns a handle on db whose statements are logged through logger.
+func WithDB(db *gorm.DB, logger *zap.Logger) *gorm.DB {
+	return db.Set(dbLoggerKey, logger)
+}
+
+// InstrumentDB registers the gorm callbacks logging every statement at level, or at warn
+// level when it runs longer than slow. Statements run without a WithDB handle are logged
+// through base.
+func InstrumentDB(db *gorm.DB, base *zap.Logger, level zapcore.Level, slow time.Duration) {
+	before := func(scope *gorm.Scope) {
+		scope.Set(dbStartKey, time.Now())
+	}
+	after := func(scope *gorm.Scope) {
+		start, ok := scope.Get(dbStartKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start.(time.Time))
+
+		logger := base
+		if v, ok := scope.Get(dbLoggerKey); ok {
+			logger = v.(*zap.Logger)
+		}
+
+		lvl, msg := level, "sql"
+		if slow > 0 && elapsed >= slow {
+			lvl, msg = zapcore.WarnLevel, "slow sql"
+		}
+		err := scope.DB().Error
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			lvl, msg = zapcore.ErrorLevel, "sql failed"
+		}
+
+		ce := logger.Check(lvl, msg)
+		if ce == nil {
+			return
+		}
+		fields := []zap.Field{
+			zap.String("sql", scope.SQL),
+			zap.Any("vars", scope.SQLVars),
+			zap.Duration("duration", elapsed),
+			zap.Int64("rows", scope.DB().RowsAffected),
+		}
+		if lvl == zapcore.ErrorLevel {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+
+	cb := db.Callback()
+	cb.Create().Before("gorm:begin_transaction").Register("log:before_create", before)
+	cb.Create().After("gorm:commit_or_rollback_transaction").Register("log:after_create", after)
+	cb.Query().Before("gorm:query").Register("log:before_query", before)
+	cb.Query().After("gorm:after_query").Register("log:after_query", after)
+	cb.RowQuery().Before("gorm:row_query").Register("log:before_row_query", before)
+	cb.RowQuery().After("gorm:row_query").Register("log:after_row_query", after)
+	cb.Update().Before("gorm:begin_transaction").Register("log:before_update", before)
+	cb.Update().After("gorm:commit_or_rollback_transaction").Register("log:after_update", after)
+	cb.Delete().Before("gorm:begin_transaction").Register("log:before_delete", before)
+	cb.Delete().After("gorm:commit_or_rollback_transaction").Register("log:after_delete", after)
+}
@@ -30,6 +30,11 @@ func NewDiscard() *zap.Logger {
 	return zap.NewNop()
 }
 
+// ParseLevel parses one of DEBUG, INFO, WARN or ERROR, in any case.
+func ParseLevel(level string) (zapcore.Level, error) {
+	return logLevel(level)
+}
+
 func logLevel(level string) (zapcore.Level, error) {
 	level = strings.ToUpper(level)
 
@@ -40,6 +45,8 @@ func logLevel(level string) (zapcore.Level, error) {
 		l = zapcore.DebugLevel
 	case "INFO":
 		l = zapcore.InfoLevel
+	case "WARN":
+		l = zapcore.WarnLevel
 	case "ERROR":
 		l = zapcore.ErrorLevel
 	default:
@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+type fileMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewFile creates a mailer writing each email to a .eml file of dir, named after the time it
+// was sent, for development and tests.
+func NewFile(dir, from string) (interfaces.Mailer, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sender %q", from)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create the mail directory")
+	}
+	return &fileMailer{dir: dir, from: sender}, nil
+}
+
+func (m *fileMailer) Send(_ context.Context, email *dto.Mail) error {
+	msg, err := message(m.from, email)
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := filepath.Join(m.dir, time.Now().UTC().Format("20060102T150405.000000000")+"-"+hex.EncodeToString(suffix)+".eml")
+
+	// written aside and renamed, so that readers never see a partial email
+	tmp, err := os.CreateTemp(m.dir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(msg); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
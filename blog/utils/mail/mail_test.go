@@ -0,0 +1,194 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blog/domain/dto"
+	blogmail "blog/utils/mail"
+)
+
+var welcome = &dto.Mail{
+	To:      "Ada <ada@example.com>",
+	Subject: "Vérifiez votre email",
+	Text:    "Open this link:\n\nhttp://blog.test/verify-email?token=" + strings.Repeat("x", 80) + "\n",
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	m, err := blogmail.NewFile(dir, "Blog <blog@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), welcome); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Ext(files[0].Name()) != ".eml" {
+		t.Fatalf("files = %v, want one email", files)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMessage(t, string(data))
+}
+
+func TestInvalidMail(t *testing.T) {
+	m, err := blogmail.NewFile(t.TempDir(), "Blog <blog@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mail := range []*dto.Mail{
+		{To: "not an address", Subject: "hello"},
+		{To: "ada@example.com", Subject: "hello\r\nBcc: eve@example.com"},
+	} {
+		if err := m.Send(context.Background(), mail); err == nil {
+			t.Errorf("Send(%+v) succeeded", mail)
+		}
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	received := make(chan fakeSMTPSession, 1)
+	go serveSMTP(lis, received)
+
+	addr := lis.Addr().(*net.TCPAddr)
+	m, err := blogmail.NewSMTP(blogmail.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Username: "blog",
+		Password: "secret",
+		From:     "Blog <blog@example.com>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), welcome); err != nil {
+		t.Fatal(err)
+	}
+
+	session := <-received
+	// PLAIN credentials: no authorization identity, the username and the password
+	if session.auth != "AUTH PLAIN AGJsb2cAc2VjcmV0" {
+		t.Errorf("auth = %q", session.auth)
+	}
+	if session.from != "MAIL FROM:<blog@example.com>" || session.to != "RCPT TO:<ada@example.com>" {
+		t.Errorf("envelope = %q, %q", session.from, session.to)
+	}
+	checkMessage(t, session.data)
+}
+
+// checkMessage verifies the encoding of the welcome email.
+func checkMessage(t *testing.T, data string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from := msg.Header.Get("From"); from != `"Blog" <blog@example.com>` {
+		t.Errorf("From = %q", from)
+	}
+	if to := msg.Header.Get("To"); to != `"Ada" <ada@example.com>` {
+		t.Errorf("To = %q", to)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != welcome.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Error("the message has no id or date")
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lines of the body end with CRLF, the long link is wrapped and comes back whole
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != welcome.Text {
+		t.Errorf("body = %q", got)
+	}
+	for _, line := range strings.Split(data, "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("line of %d characters: %q", len(line), line)
+		}
+	}
+}
+
+// fakeSMTPSession is what a client sent to the fake server.
+type fakeSMTPSession struct {
+	auth, from, to, data string
+}
+
+// serveSMTP answers the first connection of lis like a server accepting any email.
+func serveSMTP(lis net.Listener, received chan<- fakeSMTPSession) {
+	conn, err := lis.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+	var session fakeSMTPSession
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			session.auth = line
+			reply("235 accepted")
+		case "MAIL":
+			session.from = line
+			reply("250 ok")
+		case "RCPT":
+			session.to = line
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			session.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			received <- session
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
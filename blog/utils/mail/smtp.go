@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"blog/domain/dto"
+	"blog/domain/interfaces"
+)
+
+// smtpTimeout bounds the delivery of an email whose context has no deadline.
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig locates the server relaying the emails.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate to the server, anonymously when Username is empty.
+	Username string
+	Password string
+	// From is the sender of the emails.
+	From string
+}
+
+type smtpMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from *mail.Address
+}
+
+// NewSMTP creates a mailer handing the emails to the server of cfg, over STARTTLS when the
+// server offers it. The credentials are only sent encrypted, or to localhost.
+func NewSMTP(cfg SMTPConfig) (interfaces.Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sender %q", cfg.From)
+	}
+	m := &smtpMailer{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host: cfg.Host,
+		from: from,
+	}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, email *dto.Mail) error {
+	msg, err := message(m.from, email)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return errors.Wrap(err, "connect to the SMTP server")
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "greet the SMTP server")
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return errors.Wrap(err, "STARTTLS")
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return errors.Wrap(err, "authenticate to the SMTP server")
+		}
+	}
+	to, _ := mail.ParseAddress(email.To)
+	if err := c.Mail(m.from.Address); err != nil {
+		return errors.Wrap(err, "MAIL FROM")
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return errors.Wrap(err, "RCPT TO")
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "DATA")
+	}
+	if _, err := w.Write(msg); err != nil {
+		return errors.Wrap(err, "send the message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "send the message")
+	}
+	return c.Quit()
+}
@@ -0,0 +1,56 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Apply applies an RFC 7386 JSON merge patch to the target document.
+// Members set to null in the patch are removed from the target, objects are merged
+// recursively and any other value replaces the target member.
+func Apply(target, patch []byte) ([]byte, error) {
+	t, err := decode(target)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid target document")
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid merge patch")
+	}
+
+	return json.Marshal(merge(t, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
+
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
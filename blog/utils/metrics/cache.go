@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"blog/utils/cache"
+)
+
+var (
+	cacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hits_total"),
+		"Read cache hits by namespace.", []string{"namespace"}, nil)
+	cacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "misses_total"),
+		"Read cache misses by namespace.", []string{"namespace"}, nil)
+	cacheHitRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hit_ratio"),
+		"Share of the cached reads served from the cache, by namespace.", []string{"namespace"}, nil)
+)
+
+// cacheCollector exports the counts of the read cache, the same ones served by
+// GET api/v1/cache/stats.
+type cacheCollector struct {
+	stats *cache.Stats
+}
+
+// RegisterCacheStats exports the counts of stats on the metrics endpoint.
+func RegisterCacheStats(stats *cache.Stats) error {
+	return Register(&cacheCollector{stats: stats})
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheHitRatioDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for ns, counts := range c.stats.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(counts.Hits), ns)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(counts.Misses), ns)
+		ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, counts.HitRatio, ns)
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	usecaseKey = "metrics:usecase"
+	methodKey  = "metrics:method"
+	startKey   = "metrics:start"
+)
+
+// Method returns a handle on db whose statements are accounted to the given usecase
+// method. Statements run without it are labelled "unknown".
+func Method(db *gorm.DB, usecase, method string) *gorm.DB {
+	return db.Set(usecaseKey, usecase).Set(methodKey, method)
+}
+
+// InstrumentDB registers the gorm callbacks timing every statement run through db.
+func InstrumentDB(db *gorm.DB) {
+	cb := db.Callback()
+	cb.Create().Before("gorm:begin_transaction").Register("metrics:before_create", before)
+	cb.Create().After("gorm:commit_or_rollback_transaction").Register("metrics:after_create", after)
+	cb.Query().Before("gorm:query").Register("metrics:before_query", before)
+	cb.Query().After("gorm:after_query").Register("metrics:after_query", after)
+	cb.RowQuery().Before("gorm:row_query").Register("metrics:before_row_query", before)
+	cb.RowQuery().After("gorm:row_query").Register("metrics:after_row_query", after)
+	cb.Update().Before("gorm:begin_transaction").Register("metrics:before_update", before)
+	cb.Update().After("gorm:commit_or_rollback_transaction").Register("metrics:after_update", after)
+	cb.Delete().Before("gorm:begin_transaction").Register("metrics:before_delete", before)
+	cb.Delete().After("gorm:commit_or_rollback_transaction").Register("metrics:after_delete", after)
+}
+
+func before(scope *gorm.Scope) {
+	scope.Set(startKey, time.Now())
+}
+
+func after(scope *gorm.Scope) {
+	start, ok := scope.Get(startKey)
+	if !ok {
+		return
+	}
+	usecase, method := label(scope, usecaseKey), label(scope, methodKey)
+
+	dbDuration.WithLabelValues(usecase, method).Observe(time.Since(start.(time.Time)).Seconds())
+	if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		dbErrors.WithLabelValues(usecase, method).Inc()
+	}
+}
+
+func label(scope *gorm.Scope, key string) string {
+	if v, ok := scope.Get(key); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,144 @@
+// Package metrics holds the Prometheus collectors of the service and the helpers the
+// handlers and usecases use to feed them.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "blog"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database statement duration by usecase method.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"usecase", "method"})
+
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "errors_total",
+		Help:      "Failed database statements by usecase method, not counting missing records.",
+	}, []string{"usecase", "method"})
+
+	postsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created.",
+	})
+
+	commentsSubmitted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_submitted_total",
+		Help:      "Comments stored.",
+	})
+
+	commentsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_rejected_total",
+		Help:      "Comments refused, by reason.",
+	}, []string{"reason"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests refused by the rate limiter, by route group.",
+	}, []string{"group"})
+
+	mediaProcessed = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "media",
+		Name:      "processing_duration_seconds",
+		Help:      "Time to render the variants and placeholders of an uploaded image, by outcome.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"status"})
+)
+
+// Reasons a comment is rejected for.
+const (
+	RejectedInvalid = "invalid"
+	RejectedError   = "error"
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbDuration,
+		dbErrors,
+		postsCreated,
+		commentsSubmitted,
+		commentsRejected,
+		rateLimited,
+		mediaProcessed,
+	)
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format. Compression
+// is left to the gzip middleware of the server.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{DisableCompression: true})
+}
+
+// Register adds a collector to the registry served by Handler.
+func Register(c prometheus.Collector) error {
+	return registry.Register(c)
+}
+
+// ObserveRequest records a served HTTP request. route is the route pattern, not the raw
+// path, to keep the label cardinality bounded.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+func PostCreated() {
+	postsCreated.Inc()
+}
+
+func CommentSubmitted() {
+	commentsSubmitted.Inc()
+}
+
+func CommentRejected(reason string) {
+	commentsRejected.WithLabelValues(reason).Inc()
+}
+
+func RateLimited(group string) {
+	rateLimited.WithLabelValues(group).Inc()
+}
+
+// MediaProcessed records the processing of an uploaded image, status being "ready" or "failed".
+func MediaProcessed(status string, elapsed time.Duration) {
+	mediaProcessed.WithLabelValues(status).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,100 @@
+// Package openapi builds the OpenAPI 3 description of the API from the route tables of the
+// handlers and the Go types they bind and respond with.
+package openapi
+
+import "gopkg.in/yaml.v2"
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI description, limited to what the generator emits.
+type Document struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Info       Info                `yaml:"info"`
+	Servers    []Server            `yaml:"servers,omitempty"`
+	Tags       []Tag               `yaml:"tags,omitempty"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components,omitempty"`
+}
+
+// YAML encodes the document.
+func (d *Document) YAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+type Info struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	Version     string `yaml:"version"`
+}
+
+type Server struct {
+	URL         string `yaml:"url"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path keyed by lower case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `yaml:"tags,omitempty"`
+	Summary     string               `yaml:"summary,omitempty"`
+	Description string               `yaml:"description,omitempty"`
+	OperationID string               `yaml:"operationId"`
+	Parameters  []Parameter          `yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `yaml:"required,omitempty"`
+	Content  map[string]MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type Response struct {
+	Description string                    `yaml:"description"`
+	Headers     map[string]ResponseHeader `yaml:"headers,omitempty"`
+	Content     map[string]MediaType      `yaml:"content,omitempty"`
+}
+
+type ResponseHeader struct {
+	Description string  `yaml:"description,omitempty"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `yaml:"schemas,omitempty"`
+}
+
+// Schema is the subset of the OpenAPI schema object the Go types map to.
+type Schema struct {
+	Ref                  string             `yaml:"$ref,omitempty"`
+	Type                 string             `yaml:"type,omitempty"`
+	Format               string             `yaml:"format,omitempty"`
+	Nullable             bool               `yaml:"nullable,omitempty"`
+	AllOf                []*Schema          `yaml:"allOf,omitempty"`
+	Properties           map[string]*Schema `yaml:"properties,omitempty"`
+	Required             []string           `yaml:"required,omitempty"`
+	Items                *Schema            `yaml:"items,omitempty"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty"`
+	Minimum              *float64           `yaml:"minimum,omitempty"`
+	MaxLength            *int               `yaml:"maxLength,omitempty"`
+	MaxItems             *int               `yaml:"maxItems,omitempty"`
+}
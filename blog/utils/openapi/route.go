@@ -0,0 +1,269 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Route describes an endpoint: what the handler binds from the request and writes back.
+type Route struct {
+	// Method and Path are the ones the route is registered with on gin, Path relative to the
+	// base path of the document.
+	Method string
+	Path   string
+
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+
+	// Params is the struct bound from the path, through its uri tags, and from the query
+	// string, through its form tags.
+	Params interface{}
+	// Headers lists the request headers the handler reads.
+	Headers []Header
+	// Body is the value the request body is bound to, nil when the route takes no body.
+	// Wrap it with MergePatch for routes taking a JSON merge patch, with Multipart for the
+	// ones taking a form with files.
+	Body interface{}
+
+	// Status is the status of a successful response.
+	Status int
+	// Data is the data of the successful responses, nil when they have no body.
+	Data interface{}
+	// ResponseHeaders lists the headers of the successful responses.
+	ResponseHeaders []Header
+	// Conditional routes answer If-None-Match and If-Modified-Since with 304 Not Modified.
+	Conditional bool
+	// Errors lists the statuses of the errors the handler writes.
+	Errors []int
+}
+
+// Header is an HTTP header read or written by a route.
+type Header struct {
+	Name        string
+	Description string
+}
+
+// Conditional request and response headers.
+var (
+	IfNoneMatch     = Header{"If-None-Match", "ETag of the cached copy, answered with 304 while it is current"}
+	IfModifiedSince = Header{"If-Modified-Since", "Date of the cached copy, answered with 304 while it is current"}
+	IfMatch         = Header{"If-Match", "ETag the change is based on, the write fails with 412 when it is stale"}
+	ETag            = Header{"ETag", "Version of the returned representation"}
+	LastModified    = Header{"Last-Modified", "Last update of the returned representation"}
+)
+
+type mergePatch struct {
+	target interface{}
+}
+
+// MergePatch describes a JSON merge patch (RFC 7396) of target: any subset of its fields,
+// null removing one.
+func MergePatch(target interface{}) interface{} {
+	return mergePatch{target: target}
+}
+
+type multipartForm struct {
+	form interface{}
+}
+
+// Multipart describes a multipart/form-data body bound to form through its form tags, its
+// *multipart.FileHeader fields being files.
+func Multipart(form interface{}) interface{} {
+	return multipartForm{form: form}
+}
+
+// Spec holds what the document says besides the routes.
+type Spec struct {
+	Info Info
+	Tags []Tag
+	// MediaTypes lists the encodings of the responses, all sharing the schemas of the JSON one.
+	MediaTypes []string
+	// Envelope is the type wrapping every response body. Its "data" field holds the data of
+	// the successful responses and its "errors" field the errors of the failed ones.
+	Envelope interface{}
+}
+
+// Build describes routes served under basePath.
+func (s Spec) Build(basePath string, routes []Route) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.Info,
+		Servers: []Server{{URL: basePath}},
+		Tags:    s.Tags,
+		Paths:   map[string]PathItem{},
+	}
+	schemas := newSchemas()
+	envelope := schemas.schema(reflect.TypeOf(s.Envelope))
+	schemas.components["ErrorEnvelope"] = &Schema{AllOf: []*Schema{envelope, {Type: "object", Required: []string{"errors"}}}}
+	failure := s.content(&Schema{Ref: "#/components/schemas/ErrorEnvelope"})
+
+	ids := map[string]bool{}
+	for _, r := range routes {
+		if ids[r.ID] {
+			return nil, errors.Errorf("duplicate operation id %q", r.ID)
+		}
+		ids[r.ID] = true
+
+		path, params := pathTemplate(r.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		method := strings.ToLower(r.Method)
+		if item[method] != nil {
+			return nil, errors.Errorf("duplicate route %s %s", r.Method, r.Path)
+		}
+
+		op := &Operation{
+			Tags:        []string{r.Tag},
+			Summary:     r.Summary,
+			Description: r.Description,
+			OperationID: r.ID,
+			Parameters:  s.parameters(schemas, r, params),
+			Responses:   map[string]*Response{},
+		}
+		if r.Tag == "" {
+			op.Tags = nil
+		}
+
+		if r.Body != nil {
+			op.RequestBody = requestBody(schemas, r.Body)
+		}
+
+		success := &Response{Description: http.StatusText(r.Status), Headers: responseHeaders(r.ResponseHeaders)}
+		if r.Data != nil {
+			// the envelope narrowed to the data of the route, named after the operation
+			name := strings.ToUpper(r.ID[:1]) + r.ID[1:] + "Envelope"
+			schemas.components[name] = &Schema{AllOf: []*Schema{envelope, {
+				Type:       "object",
+				Required:   []string{"header", "status", "data"},
+				Properties: map[string]*Schema{"data": schemas.schema(reflect.TypeOf(r.Data))},
+			}}}
+			success.Content = s.content(&Schema{Ref: "#/components/schemas/" + name})
+		}
+		if r.Conditional {
+			success.Headers = responseHeaders(append([]Header{ETag, LastModified}, r.ResponseHeaders...))
+			op.Responses[strconv.Itoa(http.StatusNotModified)] = &Response{Description: http.StatusText(http.StatusNotModified)}
+		}
+		op.Responses[strconv.Itoa(r.Status)] = success
+
+		for _, status := range r.Errors {
+			op.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status), Content: failure}
+		}
+		// rate limits, content negotiation and unexpected failures may fail any route
+		op.Responses["default"] = &Response{Description: "Error", Content: failure}
+
+		item[method] = op
+	}
+
+	doc.Components.Schemas = schemas.components
+	return doc, nil
+}
+
+// pathTemplate converts a gin path into an OpenAPI path template and lists its parameters.
+func pathTemplate(path string) (string, []string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/"), params
+}
+
+func (s Spec) parameters(schemas *schemas, r Route, pathParams []string) []Parameter {
+	var fields []reflect.StructField
+	if r.Params != nil {
+		t := indirect(reflect.TypeOf(r.Params))
+		for i := 0; i < t.NumField(); i++ {
+			fields = append(fields, t.Field(i))
+		}
+	}
+
+	var params []Parameter
+	for _, name := range pathParams {
+		param := Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		for _, f := range fields {
+			if f.Tag.Get("uri") == name {
+				param.Schema = schemas.schema(f.Type)
+			}
+		}
+		params = append(params, param)
+	}
+	for _, f := range fields {
+		name := f.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := schemas.schema(f.Type)
+		applyRules(schema, strings.Split(f.Tag.Get("binding"), ","))
+		params = append(params, Parameter{Name: name, In: "query", Schema: schema})
+	}
+
+	headers := r.Headers
+	if r.Conditional {
+		headers = append([]Header{IfNoneMatch, IfModifiedSince}, headers...)
+	}
+	for _, h := range headers {
+		params = append(params, Parameter{Name: h.Name, In: "header", Description: h.Description, Schema: &Schema{Type: "string"}})
+	}
+	return params
+}
+
+func requestBody(schemas *schemas, body interface{}) *RequestBody {
+	if patch, ok := body.(mergePatch); ok {
+		schema := schemas.object(indirect(reflect.TypeOf(patch.target)), modePatch)
+		// plain JSON is accepted too for clients that cannot set the media type
+		return &RequestBody{Required: true, Content: map[string]MediaType{
+			"application/merge-patch+json": {Schema: schema},
+			"application/json":             {Schema: schema},
+		}}
+	}
+
+	if form, ok := body.(multipartForm); ok {
+		return &RequestBody{Required: true, Content: map[string]MediaType{
+			"multipart/form-data": {Schema: schemas.object(indirect(reflect.TypeOf(form.form)), modeForm)},
+		}}
+	}
+
+	// structs are described inline, as only the request requires the fields the binding validates
+	var schema *Schema
+	if t := indirect(reflect.TypeOf(body)); t.Kind() == reflect.Struct {
+		schema = schemas.object(t, modeRequest)
+	} else {
+		schema = schemas.schema(t)
+	}
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+func responseHeaders(headers []Header) map[string]ResponseHeader {
+	if len(headers) == 0 {
+		return nil
+	}
+	m := make(map[string]ResponseHeader, len(headers))
+	for _, h := range headers {
+		m[h.Name] = ResponseHeader{Description: h.Description, Schema: &Schema{Type: "string"}}
+	}
+	return m
+}
+
+func (s Spec) content(schema *Schema) map[string]MediaType {
+	types := s.MediaTypes
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+	content := make(map[string]MediaType, len(types))
+	for _, t := range types {
+		content[t] = MediaType{Schema: schema}
+	}
+	return content
+}
@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	fileType = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// mode selects which fields of an object are required.
+type mode int
+
+const (
+	// modeResponse requires the fields encoding/json always writes, the ones without omitempty.
+	modeResponse mode = iota
+	// modeRequest requires the fields the binding validation requires.
+	modeRequest
+	// modePatch requires nothing and lets every field be null, as in a JSON merge patch.
+	modePatch
+	// modeForm describes the fields of a multipart form by their form tags, requiring the
+	// ones the binding validation requires.
+	modeForm
+)
+
+// schemas maps Go types to schemas. Named structs are described once in the components and
+// referenced from everywhere else, which also keeps recursive types finite.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == fileType {
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(s.schema(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: new(float64)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: true}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t, modeResponse)
+		}
+		return s.ref(t)
+	}
+	// interfaces hold anything, null included
+	return &Schema{Nullable: true}
+}
+
+// ref returns a reference to the component describing the named struct t.
+func (s *schemas) ref(t reflect.Type) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			pkg := pkgName(t)
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		s.names[t] = name
+
+		// register before describing the fields, they may refer back to t
+		component := &Schema{}
+		s.components[name] = component
+		*component = *s.object(t, modeResponse)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object describes the JSON encoding of the struct t, or its form in modeForm.
+func (s *schemas) object(t reflect.Type, m mode) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(obj, t, m)
+	return obj
+}
+
+func (s *schemas) fields(obj *Schema, t reflect.Type, m mode) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if m == modeForm {
+			tag = f.Tag.Get("form")
+		}
+		if tag == "-" {
+			continue
+		}
+		// untagged embedded structs are flattened by encoding/json
+		if f.Anonymous && tag == "" && indirect(f.Type).Kind() == reflect.Struct {
+			s.fields(obj, indirect(f.Type), m)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+
+		prop := s.schema(f.Type)
+		rules := strings.Split(f.Tag.Get("binding"), ",")
+		applyRules(prop, rules)
+
+		switch m {
+		case modeResponse:
+			if !omitempty {
+				obj.Required = append(obj.Required, name)
+			}
+		case modeRequest, modeForm:
+			if hasRule(rules, "required") {
+				obj.Required = append(obj.Required, name)
+			}
+		case modePatch:
+			prop = nullable(prop)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// applyRules translates the binding rules the schema can express. Rules after "dive" apply
+// to the items of a slice.
+func applyRules(prop *Schema, rules []string) {
+	for i, rule := range rules {
+		if rule == "dive" {
+			if prop.Items != nil {
+				applyRules(prop.Items, rules[i+1:])
+			}
+			return
+		}
+
+		key, value, _ := strings.Cut(rule, "=")
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case key == "max" && prop.Type == "string":
+			prop.MaxLength = &n
+		case key == "max" && prop.Type == "array":
+			prop.MaxItems = &n
+		case key == "min" && (prop.Type == "integer" || prop.Type == "number"):
+			min := float64(n)
+			prop.Minimum = &min
+		}
+	}
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable lets s be null. References cannot have siblings, so they are wrapped in an allOf.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{Nullable: true, AllOf: []*Schema{s}}
+	}
+	s.Nullable = true
+	return s
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func pkgName(t reflect.Type) string {
+	path := t.PkgPath()
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIKeys maps the digest of each accepted API key to the caller it identifies. The keys
+// themselves are secrets and are not kept.
+type APIKeys map[string]string
+
+// ParseAPIKeys reads keys written as "<caller>=<key>". A caller may have several keys,
+// they share the buckets of the policies keyed by user.
+func ParseAPIKeys(entries []string) (APIKeys, error) {
+	keys := APIKeys{}
+	for _, entry := range entries {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || kv[1] == "" {
+			return nil, errors.New("invalid API key, want <caller>=<key>")
+		}
+		keys[Digest(kv[1])] = strings.TrimSpace(kv[0])
+	}
+	return keys, nil
+}
+
+// Caller returns the caller identified by key and the digest naming the key, ok is false
+// when the key is not accepted.
+func (k APIKeys) Caller(key string) (caller, digest string, ok bool) {
+	if key == "" {
+		return "", "", false
+	}
+	digest = Digest(key)
+	caller, ok = k[digest]
+	return caller, digest, ok
+}
+
+// Digest names an API key without revealing it.
+func Digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Key selects which requests share a bucket.
+type Key string
+
+const (
+	// KeyIP gives each client address its own bucket.
+	KeyIP Key = "ip"
+	// KeyUser gives each caller identified by an API key its own bucket, shared by all
+	// of its keys.
+	KeyUser Key = "user"
+	// KeyAPIKey gives each accepted API key its own bucket.
+	KeyAPIKey Key = "api_key"
+)
+
+// Policy is the token bucket applied to the requests of a route group.
+type Policy struct {
+	// Rate is the number of requests given back per second. Zero disables the limit.
+	Rate float64
+	// Burst is the number of requests accepted at once after an idle period.
+	Burst int
+	Key   Key
+}
+
+func (p Policy) Enabled() bool {
+	return p.Rate > 0
+}
+
+// ParsePolicy reads a policy written as "<requests>/<period>[,burst=<n>][,key=ip|user|api_key]",
+// such as "60/1m,burst=10,key=ip". The period is a Go duration, its leading 1 may be left
+// out ("100/s"). Burst defaults to the requests of a period, key to ip. "off" or an empty
+// string disable the limit.
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Policy{}, nil
+	}
+
+	parts := strings.Split(s, ",")
+	rate := strings.SplitN(parts[0], "/", 2)
+	if len(rate) != 2 {
+		return Policy{}, errors.Errorf("invalid rate %q, want <requests>/<period>", parts[0])
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(rate[0]))
+	if err != nil || requests <= 0 {
+		return Policy{}, errors.Errorf("invalid request count %q", rate[0])
+	}
+
+	period := strings.TrimSpace(rate[1])
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	per, err := time.ParseDuration(period)
+	if err != nil || per <= 0 {
+		return Policy{}, errors.Errorf("invalid period %q", rate[1])
+	}
+
+	policy := Policy{
+		Rate:  float64(requests) / per.Seconds(),
+		Burst: requests,
+		Key:   KeyIP,
+	}
+	for _, opt := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(opt), "=", 2)
+		if len(kv) != 2 {
+			return Policy{}, errors.Errorf("invalid option %q", opt)
+		}
+		switch kv[0] {
+		case "burst":
+			if policy.Burst, err = strconv.Atoi(kv[1]); err != nil || policy.Burst <= 0 {
+				return Policy{}, errors.Errorf("invalid burst %q", kv[1])
+			}
+		case "key":
+			switch Key(kv[1]) {
+			case KeyIP, KeyUser, KeyAPIKey:
+				policy.Key = Key(kv[1])
+			default:
+				return Policy{}, errors.Errorf("invalid key %q, want ip, user or api_key", kv[1])
+			}
+		default:
+			return Policy{}, errors.Errorf("unknown option %q", kv[0])
+		}
+	}
+
+	return policy, nil
+}
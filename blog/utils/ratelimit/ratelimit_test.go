@@ -0,0 +1,101 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"blog/domain/interfaces"
+	"blog/utils/ratelimit"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ratelimit.Policy
+	}{
+		{"", ratelimit.Policy{}},
+		{"off", ratelimit.Policy{}},
+		{"60/1m", ratelimit.Policy{Rate: 1, Burst: 60, Key: ratelimit.KeyIP}},
+		{"100/s", ratelimit.Policy{Rate: 100, Burst: 100, Key: ratelimit.KeyIP}},
+		{"60/1m,burst=10,key=user", ratelimit.Policy{Rate: 1, Burst: 10, Key: ratelimit.KeyUser}},
+		{" 30/30s , key=api_key ", ratelimit.Policy{Rate: 1, Burst: 30, Key: ratelimit.KeyAPIKey}},
+	}
+	for _, tt := range tests {
+		got, err := ratelimit.ParsePolicy(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	if p, _ := ratelimit.ParsePolicy("off"); p.Enabled() {
+		t.Error("the off policy is enabled")
+	}
+
+	for _, in := range []string{"60", "0/1m", "-1/1m", "60/0s", "60/forever", "60/1m,burst=0", "60/1m,key=path", "60/1m,burst", "60/1m,size=2"} {
+		if p, err := ratelimit.ParsePolicy(in); err == nil {
+			t.Errorf("ParsePolicy(%q) = %+v, want an error", in, p)
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := ratelimit.ParseAPIKeys([]string{"ada=first", "ada=second", "ci=abc=="})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, firstDigest, ok := keys.Caller("first")
+	second, secondDigest, _ := keys.Caller("second")
+	if !ok || first != "ada" || second != "ada" {
+		t.Errorf("the keys of ada identify %q and %q", first, second)
+	}
+	if firstDigest == secondDigest || firstDigest == "first" {
+		t.Errorf("digests %q and %q, want distinct digests hiding the keys", firstDigest, secondDigest)
+	}
+	if caller, _, ok := keys.Caller("abc=="); !ok || caller != "ci" {
+		t.Errorf("a key ending in = identifies %q, %v", caller, ok)
+	}
+	for _, key := range []string{"", "unknown", "ada"} {
+		if caller, _, ok := keys.Caller(key); ok {
+			t.Errorf("Caller(%q) = %q, want no caller", key, caller)
+		}
+	}
+
+	for _, entries := range [][]string{{"ada"}, {"=key"}, {"ada="}} {
+		if _, err := ratelimit.ParseAPIKeys(entries); err == nil {
+			t.Errorf("ParseAPIKeys(%q) accepted", entries)
+		}
+	}
+}
+
+func TestStores(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	for name, store := range map[string]interfaces.RateLimitStore{
+		"memory": ratelimit.NewMemory(),
+		"redis":  ratelimit.NewRedis(client, "blog:ratelimit:"),
+	} {
+		ctx := context.Background()
+		// two requests at once, then one a minute
+		for i := 0; i < 2; i++ {
+			if allowed, _, err := store.Take(ctx, "ip:1", 1.0/60, 2); err != nil || !allowed {
+				t.Fatalf("%s: request %d refused: %v", name, i+1, err)
+			}
+		}
+		allowed, retryAfter, err := store.Take(ctx, "ip:1", 1.0/60, 2)
+		if err != nil || allowed {
+			t.Fatalf("%s: the third request went through: %v", name, err)
+		}
+		if retryAfter <= 0 || retryAfter.Seconds() > 60 {
+			t.Errorf("%s: retry after %s, want at most a minute", name, retryAfter)
+		}
+		// buckets are per key
+		if allowed, _, _ := store.Take(ctx, "ip:2", 1.0/60, 2); !allowed {
+			t.Errorf("%s: another key shares the empty bucket", name)
+		}
+	}
+}
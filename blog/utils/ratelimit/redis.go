@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"blog/domain/interfaces"
+)
+
+// takeScript refills and takes from a bucket atomically. Buckets expire once they would
+// be full again, as a missing bucket is a full one.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = (1 - tokens) / rate
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(retry)}
+`)
+
+type redisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis creates a store keeping the buckets in a Redis compatible server, so all the
+// instances of the service share the same limits.
+func NewRedis(client redis.UniversalClient, prefix string) interfaces.RateLimitStore {
+	return &redisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	res, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		strconv.FormatFloat(rate, 'f', -1, 64),
+		burst,
+		strconv.FormatFloat(now, 'f', 6, 64),
+	).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if len(res) != 2 {
+		return false, 0, errors.Errorf("unexpected reply of the rate limit script: %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	reply, ok := res[1].(string)
+	if !ok {
+		return false, 0, errors.Errorf("unexpected retry delay in the reply of the rate limit script: %v", res[1])
+	}
+	retry, err := strconv.ParseFloat(reply, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, time.Duration(retry * float64(time.Second)), nil
+}
@@ -0,0 +1,48 @@
+// Package sitelink builds the URLs of the pages of the rendered site, shared by the pages,
+// the feeds and the sitemap.
+package sitelink
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Links builds absolute URLs under the URL of the site.
+type Links struct {
+	siteURL string
+}
+
+// New returns the links of the site at siteURL, an absolute URL without a trailing slash.
+func New(siteURL string) Links {
+	return Links{siteURL: siteURL}
+}
+
+// URL returns the absolute URL of path, which starts with a slash.
+func (l Links) URL(path string) string {
+	return l.siteURL + path
+}
+
+func (l Links) Home() string {
+	return l.URL("/")
+}
+
+func (l Links) Post(postID int64) string {
+	return l.URL(fmt.Sprintf("/posts/%d", postID))
+}
+
+func (l Links) Author(authorID int64) string {
+	return l.URL(fmt.Sprintf("/user/%d", authorID))
+}
+
+func (l Links) Tag(name string) string {
+	return l.URL("/tag/" + url.PathEscape(name))
+}
+
+// Page returns link, the first page of a listing, on its page-th page.
+func (l Links) Page(link string, page int) string {
+	if page <= 1 {
+		return link
+	}
+	return link + "?page=" + strconv.Itoa(page)
+}
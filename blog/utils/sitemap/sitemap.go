@@ -0,0 +1,76 @@
+// Package sitemap encodes sitemaps and sitemap indexes (https://www.sitemaps.org/protocol.html).
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxURLs is the most URLs a sitemap, or sitemaps an index, may list.
+const MaxURLs = 50000
+
+// ContentType is the media type of sitemaps and sitemap indexes.
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is a page listed in a sitemap, or a sitemap listed in an index.
+type URL struct {
+	Loc string
+	// LastMod is when the page last changed, omitted when zero.
+	LastMod time.Time
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []entry  `xml:"url"`
+}
+
+type sitemapindex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []entry  `xml:"sitemap"`
+}
+
+// Encode encodes a sitemap of urls.
+func Encode(urls []URL) ([]byte, error) {
+	if len(urls) > MaxURLs {
+		return nil, errors.Errorf("sitemap of %d URLs, at most %d are allowed", len(urls), MaxURLs)
+	}
+	return encode(urlset{XMLNS: namespace, URLs: entries(urls)})
+}
+
+// EncodeIndex encodes an index of sitemaps.
+func EncodeIndex(sitemaps []URL) ([]byte, error) {
+	if len(sitemaps) > MaxURLs {
+		return nil, errors.Errorf("sitemap index of %d sitemaps, at most %d are allowed", len(sitemaps), MaxURLs)
+	}
+	return encode(sitemapindex{XMLNS: namespace, Sitemaps: entries(sitemaps)})
+}
+
+func entries(urls []URL) []entry {
+	out := make([]entry, len(urls))
+	for i, u := range urls {
+		out[i].Loc = u.Loc
+		if !u.LastMod.IsZero() {
+			out[i].LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	return out
+}
+
+func encode(doc interface{}) ([]byte, error) {
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,62 @@
+package sitemap_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/utils/sitemap"
+)
+
+func TestEncode(t *testing.T) {
+	updated := time.Date(2026, 10, 19, 8, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	data, err := sitemap.Encode([]sitemap.URL{
+		{Loc: "https://blog.example.com/api/v1/user/1?a=1&b=2", LastMod: updated},
+		{Loc: "https://blog.example.com/api/v1/user/2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		`<url><loc>https://blog.example.com/api/v1/user/1?a=1&amp;b=2</loc><lastmod>2026-10-19T06:30:00Z</lastmod></url>` +
+		`<url><loc>https://blog.example.com/api/v1/user/2</loc></url></urlset>`
+	if string(data) != want {
+		t.Errorf("Encode:\n%s\nwant\n%s", data, want)
+	}
+
+	if data, err = sitemap.Encode(nil); err != nil || !strings.HasSuffix(string(data), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`) {
+		t.Errorf("empty sitemap %s, %v", data, err)
+	}
+}
+
+func TestEncodeIndex(t *testing.T) {
+	data, err := sitemap.EncodeIndex([]sitemap.URL{
+		{Loc: "https://blog.example.com/sitemaps/posts-1.xml", LastMod: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := xml.Header + `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		`<sitemap><loc>https://blog.example.com/sitemaps/posts-1.xml</loc><lastmod>2026-10-19T00:00:00Z</lastmod></sitemap></sitemapindex>`
+	if string(data) != want {
+		t.Errorf("EncodeIndex:\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestMaxURLs(t *testing.T) {
+	urls := make([]sitemap.URL, sitemap.MaxURLs+1)
+	for i := range urls {
+		urls[i].Loc = fmt.Sprintf("https://blog.example.com/%d", i)
+	}
+	if _, err := sitemap.Encode(urls); err == nil {
+		t.Error("Encode accepted more than MaxURLs URLs")
+	}
+	if _, err := sitemap.EncodeIndex(urls); err == nil {
+		t.Error("EncodeIndex accepted more than MaxURLs sitemaps")
+	}
+	if _, err := sitemap.Encode(urls[:sitemap.MaxURLs]); err != nil {
+		t.Errorf("Encode of MaxURLs URLs: %v", err)
+	}
+}
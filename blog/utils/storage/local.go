@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"blog/domain/interfaces"
+)
+
+type localStorage struct {
+	dir string
+}
+
+// NewLocal creates a storage keeping the files in dir, the keys being their paths under it.
+func NewLocal(dir string) (interfaces.MediaStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create the media directory")
+	}
+	return &localStorage{dir: dir}, nil
+}
+
+func (s *localStorage) Put(_ context.Context, key string, body io.Reader, size int64, _ string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	// written aside and renamed, so that readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return errors.Errorf("wrote %d bytes of %s, want %d", n, key, size)
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStorage) Get(_ context.Context, key string) (io.ReadSeekCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (s *localStorage) Delete(_ context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file of key, refusing the keys reaching out of the directory.
+func (s *localStorage) path(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.Contains(key, `\`) {
+		return "", errors.Errorf("invalid media key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
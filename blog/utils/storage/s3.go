@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+
+	"blog/domain/interfaces"
+)
+
+// S3Config locates a bucket of an S3 compatible service.
+type S3Config struct {
+	// Endpoint is the host and port of the service, such as "s3.amazonaws.com".
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKey and SecretKey sign the requests.
+	AccessKey string
+	SecretKey string
+	// Secure connects over HTTPS.
+	Secure bool
+}
+
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3 creates a storage keeping the files as the objects of a bucket of an S3 compatible
+// service, the keys being their names.
+func NewS3(cfg S3Config) (interfaces.MediaStorage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.Secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create the S3 client")
+	}
+	return &s3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	return errors.Wrapf(err, "put %s", key)
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s", key)
+	}
+	// the object is fetched lazily, Stat surfaces a missing key
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, errors.Wrapf(fs.ErrNotExist, "get %s", key)
+		}
+		return nil, errors.Wrapf(err, "get %s", key)
+	}
+	return object, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	// removing a missing object succeeds
+	return errors.Wrapf(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}), "delete %s", key)
+}
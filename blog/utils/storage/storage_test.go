@@ -0,0 +1,182 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"blog/domain/interfaces"
+	"blog/utils/storage"
+)
+
+func TestLocal(t *testing.T) {
+	s, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	for _, key := range []string{"", "../outside.png", "/abs.png", "a/../b.png", `a\b.png`} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key", key)
+		}
+	}
+}
+
+func TestS3(t *testing.T) {
+	server := httptest.NewServer(newFakeS3("media"))
+	t.Cleanup(server.Close)
+
+	s, err := storage.NewS3(storage.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+}
+
+// testStorage checks the behaviour every storage shares.
+func testStorage(t *testing.T, s interfaces.MediaStorage) {
+	t.Helper()
+	ctx := context.Background()
+	const key = "2026/10/f3a9c2.txt"
+	content := "the analytical engine weaves algebraic patterns"
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get of a missing key: %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %+v", err)
+	}
+
+	file, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %+v", err)
+	}
+	got, err := io.ReadAll(file)
+	if err != nil || string(got) != content {
+		t.Fatalf("read %q, %v, want %q", got, err, content)
+	}
+	// ranges are served by seeking
+	if _, err := file.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(file, head); err != nil || string(head) != content[4:14] {
+		t.Fatalf("read %q after seeking, %v, want %q", head, err, content[4:14])
+	}
+	file.Close()
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %+v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get after Delete: %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing key: %+v", err)
+	}
+}
+
+// fakeS3 is a stand-in for an S3 compatible service holding a single bucket, enough for
+// the storage: it puts, gets and deletes objects with path-style requests.
+type fakeS3 struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: map[string][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		f.fail(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if key == r.URL.Path || key == "" {
+		f.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readPayload(r)
+		if err != nil {
+			f.fail(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, key, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), bytes.NewReader(object))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+code+"</Message></Error>")
+}
+
+// readPayload reads the body of a put, decoding the signed chunks clients send over plain HTTP.
+func readPayload(r *http.Request) ([]byte, error) {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return io.ReadAll(r.Body)
+	}
+
+	var payload bytes.Buffer
+	chunks := bufio.NewReader(r.Body)
+	for {
+		// each chunk is "<hex size>;chunk-signature=<signature>\r\n<data>\r\n"
+		line, err := chunks.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return payload.Bytes(), nil
+		}
+		if _, err := io.CopyN(&payload, chunks, size); err != nil {
+			return nil, err
+		}
+		if _, err := chunks.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	contextKey = "tracing:context"
+	spanKey    = "tracing:span"
+)
+
+// WithContext returns a handle on db whose statements are traced as children of the span
+// carried by ctx. gorm v1 has no context of its own, so it travels in the scope values.
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.Set(contextKey, ctx)
+}
+
+// InstrumentDB registers the gorm callbacks opening a span around every statement run
+// through a handle returned by WithContext.
+func InstrumentDB(db *gorm.DB) {
+	cb := db.Callback()
+	cb.Create().Before("gorm:begin_transaction").Register("tracing:before_create", before("create"))
+	cb.Create().After("gorm:commit_or_rollback_transaction").Register("tracing:after_create", after)
+	cb.Query().Before("gorm:query").Register("tracing:before_query", before("query"))
+	cb.Query().After("gorm:after_query").Register("tracing:after_query", after)
+	cb.RowQuery().Before("gorm:row_query").Register("tracing:before_row_query", before("row_query"))
+	cb.RowQuery().After("gorm:row_query").Register("tracing:after_row_query", after)
+	cb.Update().Before("gorm:begin_transaction").Register("tracing:before_update", before("update"))
+	cb.Update().After("gorm:commit_or_rollback_transaction").Register("tracing:after_update", after)
+	cb.Delete().Before("gorm:begin_transaction").Register("tracing:before_delete", before("delete"))
+	cb.Delete().After("gorm:commit_or_rollback_transaction").Register("tracing:after_delete", after)
+}
+
+func before(operation string) func(*gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(contextKey)
+		if !ok {
+			return
+		}
+		ctx, ok := v.(context.Context)
+		if !ok {
+			return
+		}
+
+		_, span := Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(scope.Dialect().GetName()),
+				semconv.DBOperationKey.String(strings.ToUpper(operation)),
+				semconv.DBSQLTableKey.String(scope.TableName()),
+			),
+		)
+		scope.Set(spanKey, span)
+	}
+}
+
+func after(scope *gorm.Scope) {
+	v, ok := scope.Get(spanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBStatementKey.String(scope.SQL),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+	if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+
+	"blog/utils/httputil"
+)
+
+// Register configures gin's validator to report request field names instead of
+// Go struct field names and adds the custom rules used by the request DTOs.
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected gin validator engine")
+	}
+
+	v.RegisterTagNameFunc(fieldName)
+
+	if err := v.RegisterValidation("notblank", notBlank); err != nil {
+		return err
+	}
+	return v.RegisterValidation("weburl", webURL)
+}
+
+// Failed reports whether err is a validation failure of one or more fields.
+func Failed(err error) bool {
+	var fieldErrs validator.ValidationErrors
+	return errors.As(err, &fieldErrs)
+}
+
+// Errors converts a binding error into one StandardError per offending field.
+// Errors that are not validation failures, such as malformed JSON, are reported as a single entry.
+func Errors(err error) []httputil.StandardError {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []httputil.StandardError{{
+			Code:   strconv.Itoa(http.StatusBadRequest),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: err.Error(),
+			Object: httputil.ErrorObject{Type: httputil.ErrorTypeGeneric},
+		}}
+	}
+
+	errs := make([]httputil.StandardError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		field := fieldPath(fe)
+		errs = append(errs, httputil.StandardError{
+			Code:   strconv.Itoa(http.StatusBadRequest),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: message(field, fe),
+			Object: httputil.ErrorObject{
+				Text: []string{field},
+				Type: httputil.ErrorTypeValidation,
+			},
+		})
+	}
+
+	return errs
+}
+
+// fieldName uses the name the client sent the field under.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// fieldPath drops the top level struct name, so "PostCreate.tags[0]" becomes "tags[0]".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func message(field string, fe validator.FieldError) string {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "notblank":
+		return fmt.Sprintf("%s must not be blank", field)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", field, fe.Param(), unit)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", field, fe.Param(), unit)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, fe.Param())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	case "weburl":
+		return fmt.Sprintf("%s must be an http or https URL", field)
+	}
+	return fmt.Sprintf("%s failed the '%s' rule", field, fe.Tag())
+}
+
+func notBlank(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return true
+	}
+	return strings.TrimSpace(field.String()) != ""
+}
+
+// webURL accepts the absolute http and https URLs, the only ones safe to link to from a page.
+func webURL(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return true
+	}
+	u, err := url.Parse(field.String())
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,75 @@
+package validation_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+
+	"blog/utils/httputil"
+	"blog/utils/validation"
+)
+
+type item struct {
+	Name string `json:"name" binding:"notblank"`
+}
+
+type request struct {
+	Title   string   `json:"title" binding:"required,max=5"`
+	Kind    string   `json:"kind" binding:"omitempty,oneof=note essay"`
+	Tags    []string `json:"tags" binding:"max=2"`
+	Items   []item   `json:"items" binding:"dive"`
+	Email   string   `json:"email" binding:"omitempty,email"`
+	PostID  int64    `uri:"post_id" binding:"min=1"`
+	Skipped string   `json:"-" binding:"required"`
+}
+
+func TestErrors(t *testing.T) {
+	if err := validation.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := binding.Validator.ValidateStruct(&request{
+		Title: "too long",
+		Kind:  "poem",
+		Tags:  []string{"a", "b", "c"},
+		Items: []item{{Name: "ok"}, {Name: " "}},
+		Email: "nobody",
+	})
+	if err == nil {
+		t.Fatal("the request passed the validation")
+	}
+
+	var fields, details []string
+	for _, e := range validation.Errors(err) {
+		if e.Code != "400" || e.Object.Type != httputil.ErrorTypeValidation {
+			t.Errorf("error %+v, want a 400 validation error", e)
+		}
+		fields = append(fields, e.Object.Text...)
+		details = append(details, e.Detail)
+	}
+	wantFields := []string{"title", "kind", "tags", "items[1].name", "email", "post_id", "Skipped"}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("fields %q, want %q", fields, wantFields)
+	}
+	wantDetails := []string{
+		"title must be at most 5 characters",
+		"kind must be one of [note essay]",
+		"tags must be at most 2 items",
+		"items[1].name must not be blank",
+		"email must be a valid email address",
+		"post_id must be at least 1",
+		"Skipped is required",
+	}
+	if !reflect.DeepEqual(details, wantDetails) {
+		t.Errorf("details %q, want %q", details, wantDetails)
+	}
+
+	// a body that is no JSON at all is one generic error
+	syntaxErr := json.Unmarshal([]byte("{"), &request{})
+	errs := validation.Errors(syntaxErr)
+	if len(errs) != 1 || errs[0].Object.Type != httputil.ErrorTypeGeneric || errs[0].Detail != syntaxErr.Error() {
+		t.Errorf("Errors(%v) = %+v, want a single generic error", syntaxErr, errs)
+	}
+}